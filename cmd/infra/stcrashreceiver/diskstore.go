@@ -11,7 +11,6 @@ import (
 	"cmp"
 	"compress/gzip"
 	"context"
-	"io"
 	"log"
 	"math"
 	"os"
@@ -116,17 +115,7 @@ func (d *diskStore) Put(path string, data []byte) bool {
 }
 
 func (d *diskStore) Get(path string) ([]byte, error) {
-	path = d.fullPath(path)
-	bs, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-	gr, err := gzip.NewReader(bytes.NewReader(bs))
-	if err != nil {
-		return nil, err
-	}
-	defer gr.Close()
-	return io.ReadAll(gr)
+	return readCompressed(d.fullPath(path))
 }
 
 func (d *diskStore) Exists(path string) bool {
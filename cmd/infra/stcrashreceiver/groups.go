@@ -0,0 +1,304 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// The number of stack frames that make up the fingerprint.
+	fingerprintFrames = 8
+	// The number of report IDs remembered per crash group.
+	groupReports = 25
+)
+
+// crashGroup is a set of crash reports with the same fingerprint.
+type crashGroup struct {
+	Fingerprint string    `json:"fingerprint"`
+	Message     string    `json:"message"`
+	Location    string    `json:"location"`
+	Count       int       `json:"count"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+	Versions    []string  `json:"versions"`
+	Reports     []string  `json:"reports"` // most recent first
+}
+
+// crashIndex groups incoming crash reports by fingerprint, so that they
+// can be browsed without Sentry. The index is rebuilt from the disk store
+// at startup.
+type crashIndex struct {
+	dir   string
+	inbox chan indexEntry
+
+	mut     sync.RWMutex
+	groups  map[string]*crashGroup
+	reports map[string]string // report ID -> fingerprint
+}
+
+type indexEntry struct {
+	reportID string
+	data     []byte
+	when     time.Time
+}
+
+func newCrashIndex(dir string, queue int) *crashIndex {
+	return &crashIndex{
+		dir:     dir,
+		inbox:   make(chan indexEntry, queue),
+		groups:  make(map[string]*crashGroup),
+		reports: make(map[string]string),
+	}
+}
+
+func (c *crashIndex) Serve(ctx context.Context) {
+	if err := c.rebuild(ctx); err != nil {
+		log.Println("Failed to index crash reports:", err)
+	}
+
+	for {
+		select {
+		case entry := <-c.inbox:
+			c.add(entry.reportID, entry.data, entry.when)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Add queues a report for indexing.
+func (c *crashIndex) Add(reportID string, data []byte) bool {
+	select {
+	case c.inbox <- indexEntry{reportID: reportID, data: data, when: time.Now()}:
+		return true
+	default:
+		return false
+	}
+}
+
+// rebuild indexes all reports found in the disk store directory, using the
+// file modification time as the time the report was received.
+func (c *crashIndex) rebuild(ctx context.Context) error {
+	t0 := time.Now()
+	var n int
+	err := filepath.Walk(c.dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if info.IsDir() || filepath.Ext(path) != ".gz" {
+			return nil
+		}
+		bs, err := readCompressed(path)
+		if err != nil {
+			log.Printf("Failed to read %s: %v", path, err)
+			return nil
+		}
+		reportID := strings.TrimSuffix(filepath.Base(path), ".gz")
+		if len(reportID) != 64 {
+			// Report IDs are split into a directory and a file name by the
+			// disk store.
+			reportID = filepath.Base(filepath.Dir(path)) + reportID
+		}
+		c.add(reportID, bs, info.ModTime())
+		n++
+		return nil
+	})
+	log.Printf("Indexed %d crash reports into %d groups in %v", n, c.numGroups(), time.Since(t0).Truncate(time.Millisecond))
+	return err
+}
+
+func (c *crashIndex) add(reportID string, data []byte, when time.Time) {
+	crash, err := parseCrash(data, 0)
+	if err != nil {
+		return
+	}
+	fp := crashFingerprint(crash)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if _, ok := c.reports[reportID]; ok {
+		return
+	}
+	c.reports[reportID] = fp
+
+	g, ok := c.groups[fp]
+	if !ok {
+		g = &crashGroup{
+			Fingerprint: fp,
+			FirstSeen:   when,
+			LastSeen:    when,
+		}
+		c.groups[fp] = g
+	}
+	g.Count++
+	if when.Before(g.FirstSeen) {
+		g.FirstSeen = when
+	}
+	if !when.Before(g.LastSeen) {
+		// This is the most recent report, which describes the group.
+		g.LastSeen = when
+		g.Message = crash.subject
+		g.Location = crashLocation(crash)
+		g.Reports = slices.Insert(g.Reports, 0, reportID)
+	} else {
+		g.Reports = append(g.Reports, reportID)
+	}
+	if len(g.Reports) > groupReports {
+		g.Reports = g.Reports[:groupReports]
+	}
+	if v := crash.version.Version; v != "" && !slices.Contains(g.Versions, v) {
+		g.Versions = append(g.Versions, v)
+		slices.Sort(g.Versions)
+	}
+}
+
+func (c *crashIndex) numGroups() int {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return len(c.groups)
+}
+
+// Groups returns copies of the crash groups whose message, location or
+// version contains the search string, most recently seen first.
+func (c *crashIndex) Groups(search string) []crashGroup {
+	search = strings.ToLower(search)
+
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	res := make([]crashGroup, 0, len(c.groups))
+	for _, g := range c.groups {
+		if search != "" && !g.matches(search) {
+			continue
+		}
+		res = append(res, g.clone())
+	}
+	slices.SortFunc(res, func(a, b crashGroup) int {
+		return cmp.Or(b.LastSeen.Compare(a.LastSeen), strings.Compare(a.Fingerprint, b.Fingerprint))
+	})
+	return res
+}
+
+// Group returns a copy of the group with the given fingerprint.
+func (c *crashIndex) Group(fp string) (crashGroup, bool) {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	g, ok := c.groups[fp]
+	if !ok {
+		return crashGroup{}, false
+	}
+	return g.clone(), true
+}
+
+func (g *crashGroup) matches(search string) bool {
+	if strings.Contains(strings.ToLower(g.Message), search) || strings.Contains(strings.ToLower(g.Location), search) {
+		return true
+	}
+	for _, v := range g.Versions {
+		if strings.Contains(strings.ToLower(v), search) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *crashGroup) clone() crashGroup {
+	c := *g
+	c.Versions = slices.Clone(g.Versions)
+	c.Reports = slices.Clone(g.Reports)
+	return c
+}
+
+var (
+	goroutineIDRe = regexp.MustCompile(`goroutine [0-9]+`)
+	hexAddrRe     = regexp.MustCompile(`0x[0-9a-f]+`)
+)
+
+// crashFingerprint returns a fingerprint for the crash that is stable
+// across versions and machines. It is derived from the normalized panic
+// message and the function names (not file names or line numbers) of the
+// innermost frames of the panicking goroutine, ignoring the runtime's own
+// panic machinery. Database corruption crashes are fingerprinted on the
+// message only, as where they occur doesn't matter.
+func crashFingerprint(crash *parsedCrash) string {
+	parts := crashReportFingerprint(crash.subject)
+	message := parts[len(parts)-1]
+	message = goroutineIDRe.ReplaceAllString(message, "goroutine x")
+	message = hexAddrRe.ReplaceAllString(message, "0x")
+
+	h := sha256.New()
+	h.Write([]byte(message))
+	if len(parts) > 1 {
+		for _, fn := range crashFunctions(crash, fingerprintFrames) {
+			h.Write([]byte{'\n'})
+			h.Write([]byte(fn))
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// crashFunctions returns up to max function names from the panicking
+// goroutine, innermost first, skipping the runtime frames at the top of
+// the stack.
+func crashFunctions(crash *parsedCrash, max int) []string {
+	var fns []string
+	skipping := true
+	for _, fn := range crash.functions {
+		if len(fns) == max {
+			break
+		}
+		if skipping && strings.HasPrefix(fn, "runtime.") {
+			continue
+		}
+		skipping = false
+		fns = append(fns, fn)
+	}
+	return fns
+}
+
+// crashLocation returns the innermost non-runtime function of the crash.
+func crashLocation(crash *parsedCrash) string {
+	if fns := crashFunctions(crash, 1); len(fns) > 0 {
+		return fns[0]
+	}
+	return ""
+}
+
+func readCompressed(path string) ([]byte, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	gr, err := gzip.NewReader(bytes.NewReader(bs))
+	if err != nil {
+		return nil, err
+	}
+	defer gr.Close()
+	return io.ReadAll(gr)
+}
@@ -0,0 +1,146 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCrashFingerprint(t *testing.T) {
+	orig, err := os.ReadFile("_testdata/panic.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	crash, err := parseCrash(orig, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp := crashFingerprint(crash)
+
+	// Another build, goroutine, line numbers and arguments should give the
+	// same fingerprint.
+	other := bytes.Replace(orig, []byte("v1.1.3+39-g62a6d619e-dirty"), []byte("v1.1.4"), 1)
+	other = bytes.Replace(other, []byte("goroutine 171 [running]"), []byte("goroutine 9 [running]"), 1)
+	other = bytes.Replace(other, []byte("service.go:689 +0x3a"), []byte("service.go:702 +0x4b"), 1)
+	crash, err = parseCrash(other, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := crashFingerprint(crash); got != fp {
+		t.Errorf("fingerprint changed with version and line numbers: %s != %s", got, fp)
+	}
+
+	// A different function should not.
+	other = bytes.ReplaceAll(orig, []byte("setConnectionStatus"), []byte("setSomethingElse"))
+	crash, err = parseCrash(other, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := crashFingerprint(crash); got == fp {
+		t.Error("fingerprint should differ for a different location")
+	}
+	if loc := crashLocation(crash); loc != "github.com/syncthing/syncthing/lib/connections.(*service).setSomethingElse" {
+		t.Errorf("unexpected location %q", loc)
+	}
+}
+
+func TestCrashIndex(t *testing.T) {
+	orig, err := os.ReadFile("_testdata/panic.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := bytes.ReplaceAll(orig, []byte("setConnectionStatus"), []byte("setSomethingElse"))
+	other = bytes.Replace(other, []byte("nil pointer dereference"), []byte("something else"), 1)
+
+	// Store three reports, two of which are the same crash, like the disk
+	// store would.
+	dir := t.TempDir()
+	ds := &diskStore{dir: dir}
+	ids := []string{strings.Repeat("a", 64), strings.Repeat("b", 64), strings.Repeat("c", 64)}
+	for i, data := range [][]byte{orig, orig, other} {
+		path := ds.fullPath(ids[i])
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := compressAndWrite(data, path); err != nil {
+			t.Fatal(err)
+		}
+		when := time.Date(2025, 1, i+1, 0, 0, 0, 0, time.UTC)
+		if err := os.Chtimes(path, when, when); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ci := newCrashIndex(dir, 1)
+	if err := ci.rebuild(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	groups := ci.Groups("")
+	if len(groups) != 2 {
+		t.Fatalf("expected two groups, got %d", len(groups))
+	}
+	if !strings.HasSuffix(groups[0].Message, "or something else") || groups[0].Count != 1 {
+		t.Errorf("unexpected first group %+v", groups[0])
+	}
+	g := groups[1]
+	if g.Count != 2 || g.Reports[0] != ids[1] || !g.FirstSeen.Before(g.LastSeen) {
+		t.Errorf("unexpected second group %+v", g)
+	}
+
+	// Adding a report again does not count it twice.
+	ci.add(ids[0], orig, time.Now())
+	if g, _ := ci.Group(g.Fingerprint); g.Count != 2 {
+		t.Errorf("duplicate report was counted, count %d", g.Count)
+	}
+
+	if res := ci.Groups("NIL POINTER"); len(res) != 1 || res[0].Fingerprint != g.Fingerprint {
+		t.Errorf("unexpected search result %+v", res)
+	}
+	if res := ci.Groups("v1.1.3"); len(res) != 2 {
+		t.Errorf("expected version search to match both groups, got %d", len(res))
+	}
+
+	mux := http.NewServeMux()
+	ui := &crashUI{index: ci, store: ds}
+	ui.register(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/crashes/api/groups/"+g.Fingerprint, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+	var details crashGroupDetails
+	if err := json.NewDecoder(rec.Body).Decode(&details); err != nil {
+		t.Fatal(err)
+	}
+	if details.Count != 2 || len(details.Frames) == 0 {
+		t.Errorf("unexpected details %+v", details)
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/crashes/?q=something", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "or something else") {
+		t.Errorf("unexpected list page (%d): %s", rec.Code, rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/crashes/group/0000", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected not found for unknown group, got %d", rec.Code)
+	}
+}
@@ -9,6 +9,9 @@
 // - uploading files (crash reports) named like a SHA256 hash using a PUT request
 // - checking whether such file exists using a HEAD request
 //
+// Crash reports are grouped by fingerprint and can be browsed under
+// /crashes/, with or without forwarding to Sentry.
+//
 // Typically this should be deployed behind something that manages HTTPS.
 package main
 
@@ -45,6 +48,7 @@ type cli struct {
 	DiskQueue      int    `help:"Maximum number of reports to queue for writing to disk" default:"64" env:"DISK_QUEUE"`
 	MetricsListen  string `help:"HTTP listen address for metrics" default:":8081" env:"METRICS_LISTEN_ADDRESS"`
 	IgnorePatterns string `help:"File containing ignore patterns (regexp)" env:"IGNORE_PATTERNS" type:"existingfile"`
+	UI             bool   `help:"Serve the crash report browser under /crashes/ on the metrics listen address" default:"true" negatable:"" env:"UI"`
 }
 
 func main() {
@@ -61,11 +65,17 @@ func main() {
 	}
 	go ds.Serve(context.Background())
 
-	ss := &sentryService{
-		dsn:   params.DSN,
-		inbox: make(chan sentryRequest, params.SentryQueue),
+	ci := newCrashIndex(ds.dir, params.DiskQueue)
+	go ci.Serve(context.Background())
+
+	var ss *sentryService
+	if params.DSN != "" {
+		ss = &sentryService{
+			dsn:   params.DSN,
+			inbox: make(chan sentryRequest, params.SentryQueue),
+		}
+		go ss.Serve(context.Background())
 	}
-	go ss.Serve(context.Background())
 
 	var ip *ignorePatterns
 	if params.IgnorePatterns != "" {
@@ -78,6 +88,7 @@ func main() {
 
 	cr := &crashReceiver{
 		store:  ds,
+		index:  ci,
 		sentry: ss,
 		ignore: ip,
 	}
@@ -87,9 +98,15 @@ func main() {
 		w.Write([]byte("OK"))
 	})
 
+	// The crash browser has no authentication, so it's only served on the
+	// internal listener, next to the metrics.
 	if params.MetricsListen != "" {
 		mmux := http.NewServeMux()
 		mmux.Handle("/metrics", promhttp.Handler())
+		if params.UI {
+			ui := &crashUI{index: ci, store: ds, sourceContext: 3}
+			ui.register(mmux)
+		}
 		go func() {
 			if err := http.ListenAndServe(params.MetricsListen, mmux); err != nil {
 				log.Fatalln("HTTP serve metrics:", err)
@@ -101,6 +101,35 @@ func sendReport(dsn string, pkt *raven.Packet, userID string) error {
 }
 
 func parseCrashReport(path string, report []byte) (*raven.Packet, error) {
+	crash, err := parseCrash(report, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	pkt := packet(crash.version, "crash")
+	pkt.Message = crash.subject
+	pkt.Extra = raven.Extra{
+		"url": reportServer + path,
+	}
+	pkt.Interfaces = []raven.Interface{&raven.Stacktrace{Frames: crash.frames}}
+	pkt.Fingerprint = crashReportFingerprint(pkt.Message)
+
+	return pkt, nil
+}
+
+// parsedCrash is the interesting parts of a crash report: the version that
+// crashed, the panic message and the stack of the panicking goroutine.
+type parsedCrash struct {
+	version   build.VersionParts
+	subject   string
+	frames    []*raven.StacktraceFrame // outermost call first
+	functions []string                 // complete function names, innermost call first
+}
+
+// parseCrash parses a crash report. When context is larger than zero, the
+// stack frames are annotated with that many lines of surrounding source code
+// from the source code loader.
+func parseCrash(report []byte, context int) (*parsedCrash, error) {
 	parts := bytes.SplitN(report, []byte("\n"), 2)
 	if len(parts) != 2 {
 		return nil, errors.New("no first line")
@@ -143,39 +172,40 @@ func parseCrashReport(path string, report []byte) (*raven.Packet, error) {
 		return nil, errors.New("no goroutines found")
 	}
 
-	// Lock the source code loader to the version we are processing here.
-	if version.Commit != "" {
-		// We have a commit hash, so we know exactly which source to use
-		loader.LockWithVersion(version.Commit)
-	} else if strings.HasPrefix(version.Tag, "v") {
-		// Lets hope the tag is close enough
-		loader.LockWithVersion(version.Tag)
-	} else {
-		// Last resort
-		loader.LockWithVersion("main")
-	}
-	defer loader.Unlock()
-
-	var trace raven.Stacktrace
+	if context > 0 {
+		// Lock the source code loader to the version we are processing here.
+		if version.Commit != "" {
+			// We have a commit hash, so we know exactly which source to use
+			loader.LockWithVersion(version.Commit)
+		} else if strings.HasPrefix(version.Tag, "v") {
+			// Lets hope the tag is close enough
+			loader.LockWithVersion(version.Tag)
+		} else {
+			// Last resort
+			loader.LockWithVersion("main")
+		}
+		defer loader.Unlock()
+	}
+
+	var frames []*raven.StacktraceFrame
+	var functions []string
 	for _, gr := range ctx.Goroutines {
 		if gr.First {
-			trace.Frames = make([]*raven.StacktraceFrame, len(gr.Stack.Calls))
+			frames = make([]*raven.StacktraceFrame, len(gr.Stack.Calls))
 			for i, sc := range gr.Stack.Calls {
-				trace.Frames[len(trace.Frames)-1-i] = raven.NewStacktraceFrame(0, sc.Func.Name, sc.RemoteSrcPath, sc.Line, 3, nil)
+				frames[len(frames)-1-i] = raven.NewStacktraceFrame(0, sc.Func.Name, sc.RemoteSrcPath, sc.Line, context, nil)
+				functions = append(functions, sc.Func.Complete)
 			}
 			break
 		}
 	}
 
-	pkt := packet(version, "crash")
-	pkt.Message = string(subjectLine)
-	pkt.Extra = raven.Extra{
-		"url": reportServer + path,
-	}
-	pkt.Interfaces = []raven.Interface{&trace}
-	pkt.Fingerprint = crashReportFingerprint(pkt.Message)
-
-	return pkt, nil
+	return &parsedCrash{
+		version:   version,
+		subject:   string(subjectLine),
+		frames:    frames,
+		functions: functions,
+	}, nil
 }
 
 var (
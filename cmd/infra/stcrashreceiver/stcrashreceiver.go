@@ -17,6 +17,7 @@ import (
 
 type crashReceiver struct {
 	store  *diskStore
+	index  *crashIndex
 	sentry *sentryService
 	ignore *ignorePatterns
 
@@ -125,8 +126,14 @@ func (r *crashReceiver) servePut(reportID string, w http.ResponseWriter, req *ht
 		result = "queue_failure"
 	}
 
+	// Group the report with similar ones
+	if !r.index.Add(reportID, bs) {
+		log.Println("Failed to index report (queue full):", reportID)
+		result = "index_failure"
+	}
+
 	// Send the report to Sentry
-	if !r.sentry.Send(reportID, userIDFor(req), bs) {
+	if r.sentry != nil && !r.sentry.Send(reportID, userIDFor(req), bs) {
 		log.Println("Failed to send report to sentry (queue full):", reportID)
 		result = "sentry_failure"
 	}
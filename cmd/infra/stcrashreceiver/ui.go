@@ -0,0 +1,174 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	raven "github.com/getsentry/raven-go"
+)
+
+// crashUI serves a small HTML and JSON interface for browsing the crash
+// groups of the index:
+//
+//	/crashes/                 HTML list of groups, searchable with ?q=
+//	/crashes/group/<fp>       HTML details of a group
+//	/crashes/api/groups       JSON list of groups, searchable with ?q=
+//	/crashes/api/groups/<fp>  JSON details of a group
+type crashUI struct {
+	index *crashIndex
+	store *diskStore
+	// Lines of source code to show around each stack frame, as loaded by
+	// the source code loader. Zero disables loading source code.
+	sourceContext int
+}
+
+// crashGroupDetails is a crash group plus the stack of its most recent
+// report, annotated with source code.
+type crashGroupDetails struct {
+	crashGroup
+	Frames []*raven.StacktraceFrame `json:"frames"` // innermost call first
+}
+
+func (u *crashUI) register(mux *http.ServeMux) {
+	mux.HandleFunc("/crashes/", u.serveList)
+	mux.HandleFunc("/crashes/group/", u.serveGroup)
+	mux.HandleFunc("/crashes/api/groups", u.serveAPIList)
+	mux.HandleFunc("/crashes/api/groups/", u.serveAPIGroup)
+}
+
+func (u *crashUI) serveList(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path != "/crashes/" {
+		http.NotFound(w, req)
+		return
+	}
+	search := req.URL.Query().Get("q")
+	data := map[string]any{
+		"Search": search,
+		"Groups": u.index.Groups(search),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := listTemplate.Execute(w, data); err != nil {
+		log.Println("Rendering crash list:", err)
+	}
+}
+
+func (u *crashUI) serveGroup(w http.ResponseWriter, req *http.Request) {
+	details, ok := u.details(strings.TrimPrefix(req.URL.Path, "/crashes/group/"))
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := groupTemplate.Execute(w, details); err != nil {
+		log.Println("Rendering crash group:", err)
+	}
+}
+
+func (u *crashUI) serveAPIList(w http.ResponseWriter, req *http.Request) {
+	writeJSON(w, u.index.Groups(req.URL.Query().Get("q")))
+}
+
+func (u *crashUI) serveAPIGroup(w http.ResponseWriter, req *http.Request) {
+	details, ok := u.details(strings.TrimPrefix(req.URL.Path, "/crashes/api/groups/"))
+	if !ok {
+		http.NotFound(w, req)
+		return
+	}
+	writeJSON(w, details)
+}
+
+// details returns the group with the stack of its most recent report that
+// is still available in the disk store.
+func (u *crashUI) details(fp string) (crashGroupDetails, bool) {
+	g, ok := u.index.Group(fp)
+	if !ok {
+		return crashGroupDetails{}, false
+	}
+	details := crashGroupDetails{crashGroup: g}
+	for _, reportID := range g.Reports {
+		bs, err := u.store.Get(reportID)
+		if err != nil {
+			continue
+		}
+		crash, err := parseCrash(bs, u.sourceContext)
+		if err != nil {
+			continue
+		}
+		for i := len(crash.frames) - 1; i >= 0; i-- {
+			if crash.frames[i] != nil {
+				details.Frames = append(details.Frames, crash.frames[i])
+			}
+		}
+		break
+	}
+	return details, true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+const pageStyle = `<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; width: 100%; }
+td, th { text-align: left; padding: 0.3em 0.6em; border-bottom: 1px solid #ddd; vertical-align: top; }
+pre { background: #f6f6f6; padding: 0.5em; overflow-x: auto; }
+.num { text-align: right; }
+.hl { font-weight: bold; background: #fff3b0; }
+</style>`
+
+var listTemplate = template.Must(template.New("list").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>Crash reports</title>` + pageStyle + `</head>
+<body>
+<h1>Crash reports</h1>
+<form method="get"><input name="q" value="{{.Search}}" placeholder="Search message, function or version" size="50"> <button>Search</button></form>
+<table>
+<tr><th>Message</th><th>Location</th><th class="num">Count</th><th>First seen</th><th>Last seen</th><th>Versions</th></tr>
+{{range .Groups}}<tr>
+<td><a href="group/{{.Fingerprint}}">{{.Message}}</a></td>
+<td><code>{{.Location}}</code></td>
+<td class="num">{{.Count}}</td>
+<td>{{.FirstSeen.Format "2006-01-02 15:04"}}</td>
+<td>{{.LastSeen.Format "2006-01-02 15:04"}}</td>
+<td>{{range $i, $v := .Versions}}{{if $i}}, {{end}}{{$v}}{{end}}</td>
+</tr>{{else}}<tr><td colspan="6">No crash reports.</td></tr>{{end}}
+</table>
+</body></html>
+`))
+
+var groupTemplate = template.Must(template.New("group").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Message}}</title>` + pageStyle + `</head>
+<body>
+<p><a href="../">All crash reports</a></p>
+<h1>{{.Message}}</h1>
+<table>
+<tr><th>Fingerprint</th><td><code>{{.Fingerprint}}</code></td></tr>
+<tr><th>Count</th><td>{{.Count}}</td></tr>
+<tr><th>First seen</th><td>{{.FirstSeen.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th>Last seen</th><td>{{.LastSeen.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th>Versions</th><td>{{range $i, $v := .Versions}}{{if $i}}, {{end}}{{$v}}{{end}}</td></tr>
+</table>
+<h2>Stack of the most recent report</h2>
+{{range .Frames}}<h3><code>{{.Module}}.{{.Function}}</code></h3>
+<p><code>{{.Filename}}:{{.Lineno}}</code></p>
+{{if .ContextLine}}<pre>{{range .PreContext}}{{.}}
+{{end}}<span class="hl">{{.ContextLine}}</span>
+{{range .PostContext}}{{.}}
+{{end}}</pre>{{end}}
+{{else}}<p>No report available on disk.</p>{{end}}
+<h2>Reports</h2>
+<ul>{{range .Reports}}<li><a href="/report/{{.}}">{{.}}</a></li>{{end}}</ul>
+</body></html>
+`))
@@ -0,0 +1,261 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+const (
+	// The number of most recent probes that make up the availability
+	// part of the health score.
+	healthWindow = 20
+	// The latency at which the latency part of the health score is halved.
+	healthLatencyHalf = 100 * time.Millisecond
+	// Weight of a new latency sample in the moving average.
+	healthLatencyAlpha = 0.3
+)
+
+var (
+	relayHealthScore = makeGauge("relay_health_score", "Health score of a relay, between zero and one", "relay")
+	relayProbeTotal  = makeCounter("relay_probes_total", "Number of latency probes towards relays.", "result")
+)
+
+// health tracks the outcome of latency probes sent from the pool server to
+// a relay. It is protected by the global mutex, like the rest of the relay.
+type health struct {
+	Score        float64   `json:"score"`
+	LatencyMs    float64   `json:"latencyMs"`
+	Probes       int       `json:"probes"`
+	Failures     int       `json:"failures"`
+	LastProbe    time.Time `json:"lastProbe"`
+	LastSuccess  time.Time `json:"lastSuccess"`
+	recent       []bool
+	latencyAvg   time.Duration
+	latencyKnown bool
+}
+
+// record adds the result of one probe and recalculates the score.
+func (h *health) record(latency time.Duration, err error, now time.Time) {
+	ok := err == nil
+	h.Probes++
+	h.LastProbe = now
+	if ok {
+		h.LastSuccess = now
+		if !h.latencyKnown {
+			h.latencyAvg = latency
+			h.latencyKnown = true
+		} else {
+			h.latencyAvg = time.Duration(healthLatencyAlpha*float64(latency) + (1-healthLatencyAlpha)*float64(h.latencyAvg))
+		}
+	} else {
+		h.Failures++
+	}
+
+	h.recent = append(h.recent, ok)
+	if len(h.recent) > healthWindow {
+		h.recent = h.recent[len(h.recent)-healthWindow:]
+	}
+
+	h.LatencyMs = math.Round(float64(h.latencyAvg)/float64(time.Millisecond)*10) / 10
+	h.Score = h.score()
+}
+
+// score returns a value between zero and one, where one is a relay that
+// answered every recent probe instantly. Availability over the recent probes
+// is weighted by a latency factor that halves every healthLatencyHalf.
+func (h *health) score() float64 {
+	if len(h.recent) == 0 || !h.latencyKnown {
+		return 0
+	}
+	var good int
+	for _, ok := range h.recent {
+		if ok {
+			good++
+		}
+	}
+	availability := float64(good) / float64(len(h.recent))
+	latency := float64(h.latencyAvg) / float64(healthLatencyHalf)
+	score := availability / (1 + latency)
+	return math.Round(score*1000) / 1000
+}
+
+func healthProber(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	for range ticker.C {
+		probeRelays()
+	}
+}
+
+type probeResult struct {
+	relay   *relay
+	latency time.Duration
+	err     error
+}
+
+func probeRelays() {
+	mut.RLock()
+	relays := make([]*relay, 0, len(permanentRelays)+len(knownRelays))
+	relays = append(relays, permanentRelays...)
+	relays = append(relays, knownRelays...)
+	mut.RUnlock()
+
+	var wg sync.WaitGroup
+	results := make(chan probeResult, len(relays))
+	for _, rel := range relays {
+		wg.Add(1)
+		go func(rel *relay) {
+			defer wg.Done()
+			latency, err := osutil.TCPPing(context.TODO(), rel.uri.Host)
+			results <- probeResult{rel, latency, err}
+		}(rel)
+	}
+	wg.Wait()
+	close(results)
+
+	now := time.Now()
+	mut.Lock()
+	for res := range results {
+		if res.relay.Health == nil {
+			res.relay.Health = &health{}
+		}
+		res.relay.Health.record(res.latency, res.err, now)
+		if res.err != nil {
+			relayProbeTotal.WithLabelValues("failed").Inc()
+			if debug {
+				log.Println("Probe for relay", res.relay, "failed:", res.err)
+			}
+		} else {
+			relayProbeTotal.WithLabelValues("success").Inc()
+		}
+		relayHealthScore.WithLabelValues(res.relay.uri.Host).Set(res.relay.Health.Score)
+	}
+	mut.Unlock()
+}
+
+// regionMap maps network prefixes to operator defined region names.
+type regionMap []regionEntry
+
+type regionEntry struct {
+	network *net.IPNet
+	region  string
+}
+
+// loadRegions reads a region file. Each non-empty line that does not start
+// with a '#' is a network in CIDR notation followed by a region name, such as
+// "10.1.0.0/16 eu-west".
+func loadRegions(file string) (regionMap, error) {
+	fd, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
+
+	var regions regionMap
+	sc := bufio.NewScanner(fd)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected network and region", file, line)
+		}
+		_, network, err := net.ParseCIDR(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", file, line, err)
+		}
+		regions = append(regions, regionEntry{network: network, region: fields[1]})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+
+	// Most specific prefixes first, so that lookups can stop at the first
+	// match.
+	slices.SortStableFunc(regions, func(a, b regionEntry) int {
+		aOnes, _ := a.network.Mask.Size()
+		bOnes, _ := b.network.Mask.Size()
+		return bOnes - aOnes
+	})
+	return regions, nil
+}
+
+// Lookup returns the region for the given address, or the empty string.
+func (m regionMap) Lookup(ip net.IP) string {
+	if ip == nil {
+		return ""
+	}
+	for _, e := range m {
+		if e.network.Contains(ip) {
+			return e.region
+		}
+	}
+	return ""
+}
+
+// getRegion returns the region of the given host, which is looked up in the
+// region map and falls back to the continent of the location.
+func getRegion(host string, loc location) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if region := regions.Lookup(net.ParseIP(host)); region != "" {
+		return region
+	}
+	return loc.Continent
+}
+
+// rankRelays orders the relays for a client in the given region. Relays in
+// the same region as the client come first, then the rest. Within each
+// group relays are ordered by descending health score, with equally scored
+// relays in random order.
+func rankRelays(relays []*relay, clientRegion string) {
+	rand.Shuffle(relays)
+	slices.SortStableFunc(relays, func(a, b *relay) int {
+		if clientRegion != "" {
+			aLocal, bLocal := a.Region == clientRegion, b.Region == clientRegion
+			if aLocal != bLocal {
+				if aLocal {
+					return -1
+				}
+				return 1
+			}
+		}
+		aScore, bScore := a.score(), b.score()
+		switch {
+		case aScore > bScore:
+			return -1
+		case aScore < bScore:
+			return 1
+		}
+		return 0
+	})
+}
+
+// score returns the health score of the relay, or zero if it has not been
+// probed yet.
+func (r *relay) score() float64 {
+	if r.Health == nil {
+		return 0
+	}
+	return r.Health.Score
+}
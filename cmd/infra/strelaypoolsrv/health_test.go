@@ -0,0 +1,124 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestHealthScore(t *testing.T) {
+	now := time.Now()
+
+	var fast, slow, flaky health
+	for range 10 {
+		fast.record(10*time.Millisecond, nil, now)
+		slow.record(300*time.Millisecond, nil, now)
+	}
+	for i := range 10 {
+		if i%2 == 0 {
+			flaky.record(0, errors.New("timeout"), now)
+		} else {
+			flaky.record(10*time.Millisecond, nil, now)
+		}
+	}
+
+	if !(fast.Score > flaky.Score && fast.Score > slow.Score) {
+		t.Errorf("fast relay should score best; fast=%v slow=%v flaky=%v", fast.Score, slow.Score, flaky.Score)
+	}
+	if fast.Score > 1 || slow.Score <= 0 {
+		t.Errorf("scores out of range; fast=%v slow=%v", fast.Score, slow.Score)
+	}
+	if flaky.Failures != 5 || flaky.Probes != 10 {
+		t.Errorf("unexpected probe counts %d/%d", flaky.Failures, flaky.Probes)
+	}
+
+	var dead health
+	dead.record(0, errors.New("refused"), now)
+	if dead.Score != 0 {
+		t.Errorf("never reachable relay should score zero, got %v", dead.Score)
+	}
+}
+
+func TestHealthWindow(t *testing.T) {
+	now := time.Now()
+
+	var h health
+	for range healthWindow {
+		h.record(0, errors.New("down"), now)
+	}
+	for range healthWindow {
+		h.record(10*time.Millisecond, nil, now)
+	}
+	if len(h.recent) != healthWindow {
+		t.Fatalf("window not trimmed, have %d entries", len(h.recent))
+	}
+	if h.Score < 0.9 {
+		t.Errorf("old failures should have aged out, score %v", h.Score)
+	}
+}
+
+func TestLoadRegions(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "regions")
+	content := "# comment\n10.0.0.0/8 global\n\n10.1.0.0/16 eu-west\n2001:db8::/32 us-east\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := loadRegions(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		ip     string
+		region string
+	}{
+		{"10.1.2.3", "eu-west"},
+		{"10.2.2.3", "global"},
+		{"192.0.2.1", ""},
+		{"2001:db8::1", "us-east"},
+	}
+	for _, tc := range cases {
+		if got := m.Lookup(net.ParseIP(tc.ip)); got != tc.region {
+			t.Errorf("Lookup(%s) = %q, expected %q", tc.ip, got, tc.region)
+		}
+	}
+
+	if err := os.WriteFile(file, []byte("10.0.0.0/8\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadRegions(file); err == nil {
+		t.Error("expected error for line without region")
+	}
+}
+
+func TestRankRelays(t *testing.T) {
+	relays := []*relay{
+		{URL: "remote-good", Region: "us", Health: &health{Score: 0.9}},
+		{URL: "local-bad", Region: "eu", Health: &health{Score: 0.2}},
+		{URL: "unprobed", Region: "eu"},
+		{URL: "local-good", Region: "eu", Health: &health{Score: 0.8}},
+	}
+
+	rankRelays(relays, "eu")
+	expected := []string{"local-good", "local-bad", "unprobed", "remote-good"}
+	for i, rel := range relays {
+		if rel.URL != expected[i] {
+			t.Fatalf("position %d: got %s, expected %s", i, rel.URL, expected[i])
+		}
+	}
+
+	rankRelays(relays, "")
+	if relays[0].URL != "remote-good" {
+		t.Errorf("without a client region the best scoring relay should be first, got %s", relays[0].URL)
+	}
+}
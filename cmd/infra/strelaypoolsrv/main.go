@@ -46,13 +46,17 @@ type location struct {
 type relay struct {
 	URL            string   `json:"url"`
 	Location       location `json:"location"`
+	Region         string   `json:"region,omitempty"`
 	uri            *url.URL
 	Stats          *stats    `json:"stats"`
 	StatsRetrieved time.Time `json:"statsRetrieved"`
+	Health         *health   `json:"health,omitempty"`
 }
 
 type relayShort struct {
-	URL string `json:"url"`
+	URL    string   `json:"url"`
+	Region string   `json:"region,omitempty"`
+	Score  *float64 `json:"score,omitempty"`
 }
 
 type stats struct {
@@ -112,6 +116,8 @@ var (
 	geoipLicenseKey   = os.Getenv("GEOIP_LICENSE_KEY")
 	geoipAccountID, _ = strconv.Atoi(os.Getenv("GEOIP_ACCOUNT_ID"))
 	maxRelaysReturned = 100
+	regionsFile       string
+	probeInterval     time.Duration
 
 	requests chan request
 
@@ -120,6 +126,7 @@ var (
 	permanentRelays = make([]*relay, 0)
 	evictionTimers  = make(map[string]*time.Timer)
 	globalBlocklist = newErrorTracker(1000)
+	regions         regionMap
 )
 
 const (
@@ -144,33 +151,49 @@ func main() {
 	flag.IntVar(&requestProcessors, "request-processors", requestProcessors, "Number of request processor routines")
 	flag.StringVar(&geoipLicenseKey, "geoip-license-key", geoipLicenseKey, "License key for GeoIP database")
 	flag.IntVar(&maxRelaysReturned, "max-relays-returned", maxRelaysReturned, "Maximum number of relays returned for a normal endpoint query")
+	flag.StringVar(&regionsFile, "regions", "", "Path to list of networks and their region names, used to prefer relays in the client's region")
+	flag.DurationVar(&probeInterval, "probe-interval", 0, "Interval at which to probe relay latency and update health scores (0 to disable)")
 
 	flag.Parse()
 
 	requests = make(chan request, requestQueueLen)
-	geoip, err := geoip.NewGeoLite2CityProvider(context.Background(), geoipAccountID, geoipLicenseKey, os.TempDir())
-	if err != nil {
-		log.Fatalln("Failed to create GeoIP provider:", err)
+
+	// The GeoIP database is optional; without it relays have no location
+	// and regions come only from the region map.
+	var geoipProvider *geoip.Provider
+	var err error
+	if geoipLicenseKey != "" {
+		geoipProvider, err = geoip.NewGeoLite2CityProvider(context.Background(), geoipAccountID, geoipLicenseKey, os.TempDir())
+		if err != nil {
+			log.Fatalln("Failed to create GeoIP provider:", err)
+		}
+		go geoipProvider.Serve(context.TODO())
+	}
+
+	if regionsFile != "" {
+		regions, err = loadRegions(regionsFile)
+		if err != nil {
+			log.Fatalln("Failed to load regions:", err)
+		}
 	}
-	go geoip.Serve(context.TODO())
 
 	var listener net.Listener
 
 	if permRelaysFile != "" {
-		permanentRelays = loadRelays(permRelaysFile, geoip)
+		permanentRelays = loadRelays(permRelaysFile, geoipProvider)
 	}
 
 	testCert = createTestCertificate()
 
 	for range requestProcessors {
-		go requestProcessor(geoip)
+		go requestProcessor(geoipProvider)
 	}
 
 	// Load relays from cache in the background.
 	// Load them in a serial fashion to make sure any genuine requests
 	// are not dropped.
 	go func() {
-		for _, relay := range loadRelays(knownRelaysFile, geoip) {
+		for _, relay := range loadRelays(knownRelaysFile, geoipProvider) {
 			resultChan := make(chan result)
 			requests <- request{relay, resultChan, nil}
 			result := <-resultChan
@@ -184,6 +207,10 @@ func main() {
 		statsRefresher(statsRefresh)
 	}()
 
+	if probeInterval > 0 {
+		go healthProber(probeInterval)
+	}
+
 	if dir != "" {
 		if debug {
 			log.Println("Starting TLS listener on", listen)
@@ -323,19 +350,36 @@ func handleEndpointFull(rw http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// handleEndpointShort returns the relay list with only the URL.
+// handleEndpointShort returns the relay list with only the URL. When health
+// probing or regions are enabled the list is ranked for the requesting
+// client, best relay first, and carries the health score of each relay.
 func handleEndpointShort(rw http.ResponseWriter, r *http.Request) {
 	rw.Header().Set("Content-Type", "application/json; charset=utf-8")
 	rw.Header().Set("Access-Control-Allow-Origin", "*")
 
+	ranked := probeInterval > 0 || len(regions) > 0
+
 	mut.RLock()
-	relays := make([]relayShort, 0, len(permanentRelays)+len(knownRelays))
-	for _, r := range append(permanentRelays, knownRelays...) {
-		relays = append(relays, relayShort{URL: slimURL(r.URL)})
+	all := make([]*relay, 0, len(permanentRelays)+len(knownRelays))
+	all = append(all, permanentRelays...)
+	all = append(all, knownRelays...)
+	if ranked {
+		rankRelays(all, clientRegion(r))
+	}
+	relays := make([]relayShort, 0, len(all))
+	for _, rel := range all {
+		short := relayShort{URL: slimURL(rel.URL), Region: rel.Region}
+		if rel.Health != nil {
+			score := rel.Health.Score
+			short.Score = &score
+		}
+		relays = append(relays, short)
 	}
 	mut.RUnlock()
 	if len(relays) > maxRelaysReturned {
-		rand.Shuffle(relays)
+		if !ranked {
+			rand.Shuffle(relays)
+		}
 		relays = relays[:maxRelaysReturned]
 	}
 
@@ -344,8 +388,17 @@ func handleEndpointShort(rw http.ResponseWriter, r *http.Request) {
 	})
 }
 
-func handleRegister(w http.ResponseWriter, r *http.Request) {
-	// Get the IP address of the client
+// clientRegion returns the region a client is asking from. An explicit
+// "region" query parameter takes precedence over the region map.
+func clientRegion(r *http.Request) string {
+	if region := r.URL.Query().Get("region"); region != "" {
+		return region
+	}
+	return regions.Lookup(net.ParseIP(remoteHost(r)))
+}
+
+// remoteHost returns the IP address of the client, without port.
+func remoteHost(r *http.Request) string {
 	rhost := r.RemoteAddr
 	if ipHeader != "" {
 		hdr := r.Header.Get(ipHeader)
@@ -357,6 +410,12 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 	if host, _, err := net.SplitHostPort(rhost); err == nil {
 		rhost = host
 	}
+	return rhost
+}
+
+func handleRegister(w http.ResponseWriter, r *http.Request) {
+	// Get the IP address of the client
+	rhost := remoteHost(r)
 
 	// Check the black list. A client is blacklisted if their last 10
 	// attempts to join have all failed. The "Unauthorized" status return
@@ -522,7 +581,12 @@ func handleRelayTest(request request, geoip *geoip.Provider) {
 				log.Println("Relay", request.relay, "already exists")
 			}
 
-			// Evict the old entry anyway, as configuration might have changed.
+			// Evict the old entry anyway, as configuration might have changed,
+			// but keep the probe history and the region.
+			request.relay.Health = current.Health
+			if request.relay.Region == "" {
+				request.relay.Region = current.Region
+			}
 			last := len(knownRelays) - 1
 			knownRelays[i] = knownRelays[last]
 			knownRelays = knownRelays[:last]
@@ -536,6 +600,10 @@ func handleRelayTest(request request, geoip *geoip.Provider) {
 	}
 
 found:
+	// Keep an explicit region from the known relays file.
+	if request.relay.Region == "" {
+		request.relay.Region = getRegion(request.relay.uri.Host, location)
+	}
 
 	knownRelays = append(knownRelays, request.relay)
 	evictionTimers[request.relay.uri.Host] = time.AfterFunc(evictionTime, evict(request.relay))
@@ -584,6 +652,12 @@ func loadRelays(file string, geoip *geoip.Provider) []*relay {
 			continue
 		}
 
+		// A relay line may be followed by an explicit region name.
+		var region string
+		if fields := strings.Fields(line); len(fields) == 2 {
+			line, region = fields[0], fields[1]
+		}
+
 		uri, err := url.Parse(line)
 		if err != nil {
 			if debug {
@@ -593,9 +667,15 @@ func loadRelays(file string, geoip *geoip.Provider) []*relay {
 
 		}
 
+		location := getLocation(uri.Host, geoip)
+		if region == "" {
+			region = getRegion(uri.Host, location)
+		}
+
 		relays = append(relays, &relay{
 			URL:      line,
-			Location: getLocation(uri.Host, geoip),
+			Location: location,
+			Region:   region,
 			uri:      uri,
 		})
 		if debug {
@@ -608,7 +688,11 @@ func loadRelays(file string, geoip *geoip.Provider) []*relay {
 func saveRelays(file string, relays []*relay) error {
 	var content string
 	for _, relay := range relays {
-		content += relay.uri.String() + "\n"
+		content += relay.uri.String()
+		if relay.Region != "" {
+			content += " " + relay.Region
+		}
+		content += "\n"
 	}
 	return os.WriteFile(file, []byte(content), 0o777)
 }
@@ -629,6 +713,10 @@ func createTestCertificate() tls.Certificate {
 }
 
 func getLocation(host string, geoip *geoip.Provider) location {
+	if geoip == nil {
+		return location{}
+	}
+
 	timer := prometheus.NewTimer(locationLookupSeconds)
 	defer timer.ObserveDuration()
 
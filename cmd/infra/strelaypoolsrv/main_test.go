@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"net/http/httptest"
 	"net/url"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -104,3 +105,28 @@ func TestSlimURL(t *testing.T) {
 		}
 	}
 }
+
+func TestSaveLoadRelaysRegion(t *testing.T) {
+	var relays []*relay
+	for _, line := range []string{"relay://192.0.2.1:22067/?id=a eu", "relay://192.0.2.2:22067/?id=b"} {
+		fields := strings.Fields(line)
+		uri, err := url.Parse(fields[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel := &relay{URL: fields[0], uri: uri}
+		if len(fields) == 2 {
+			rel.Region = fields[1]
+		}
+		relays = append(relays, rel)
+	}
+
+	file := filepath.Join(t.TempDir(), "relays")
+	if err := saveRelays(file, relays); err != nil {
+		t.Fatal(err)
+	}
+	loaded := loadRelays(file, nil)
+	if len(loaded) != 2 || loaded[0].URL != relays[0].URL || loaded[0].Region != "eu" || loaded[1].URL != relays[1].URL || loaded[1].Region != "" {
+		t.Errorf("unexpected relays after saving and loading: %+v, %+v", loaded[0], loaded[1])
+	}
+}
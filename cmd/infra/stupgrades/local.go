@@ -0,0 +1,134 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/upgrade"
+)
+
+// localReleases are releases served from a local directory of signed
+// release archives, such as an intranet mirror of internally built
+// releases. The directory is laid out as <channel>/<tag>/<archive>, where
+// each tag directory may also contain a compat.json. The archives are
+// served as is, so they must already be signed by a key the clients trust.
+type localReleases struct {
+	dir     string
+	baseURL string
+
+	mut      sync.RWMutex
+	channels map[string][]upgrade.Release
+}
+
+func (l *localReleases) Releases(channel string) []upgrade.Release {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+	return l.channels[channel]
+}
+
+func (l *localReleases) Update(_ context.Context) error {
+	channels, err := l.scan()
+	if err != nil {
+		return err
+	}
+	l.mut.Lock()
+	l.channels = channels
+	l.mut.Unlock()
+	return nil
+}
+
+func (l *localReleases) scan() (map[string][]upgrade.Release, error) {
+	chDirs, err := os.ReadDir(l.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make(map[string][]upgrade.Release)
+	for _, chDir := range chDirs {
+		if !chDir.IsDir() || strings.HasPrefix(chDir.Name(), ".") {
+			continue
+		}
+		channel := chDir.Name()
+		tagDirs, err := os.ReadDir(filepath.Join(l.dir, channel))
+		if err != nil {
+			return nil, err
+		}
+
+		var rels []upgrade.Release
+		for _, tagDir := range tagDirs {
+			if !tagDir.IsDir() || !strings.HasPrefix(tagDir.Name(), "v") {
+				continue
+			}
+			rel, err := l.release(channel, tagDir.Name())
+			if err != nil {
+				return nil, err
+			}
+			if info, err := tagDir.Info(); err == nil {
+				// The release is considered published when its directory
+				// was created.
+				rel.PublishedAt = info.ModTime().UTC().Truncate(time.Second)
+			}
+			if len(rel.Assets) > 0 {
+				rels = append(rels, rel)
+			}
+		}
+
+		sort.Sort(upgrade.SortByRelease(rels))
+		channels[channel] = rels
+	}
+	return channels, nil
+}
+
+func (l *localReleases) release(channel, tag string) (upgrade.Release, error) {
+	rel := upgrade.Release{
+		Tag:        tag,
+		Prerelease: strings.Contains(tag, "-"),
+	}
+	if channel != upgrade.DefaultChannel {
+		rel.Channel = channel
+	}
+
+	files, err := os.ReadDir(filepath.Join(l.dir, channel, tag))
+	if err != nil {
+		return rel, err
+	}
+	for _, file := range files {
+		name := file.Name()
+		switch {
+		case file.IsDir():
+			continue
+		case name == "compat.json":
+			bs, err := os.ReadFile(filepath.Join(l.dir, channel, tag, name))
+			if err != nil {
+				return rel, err
+			}
+			if err := json.Unmarshal(bs, &rel.Compatibility); err != nil {
+				return rel, fmt.Errorf("%s/%s/%s: %w", channel, tag, name, err)
+			}
+		case strings.HasPrefix(name, "syncthing-") && (strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".zip")):
+			rel.Assets = append(rel.Assets, upgrade.Asset{
+				Name: name,
+				URL:  l.downloadURL(channel, tag, name),
+			})
+		}
+	}
+	return rel, nil
+}
+
+func (l *localReleases) downloadURL(channel, tag, name string) string {
+	return strings.TrimSuffix(l.baseURL, "/") + "/download/" + url.PathEscape(channel) + "/" + url.PathEscape(tag) + "/" + url.PathEscape(name)
+}
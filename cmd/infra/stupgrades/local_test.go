@@ -0,0 +1,82 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/signature"
+	"github.com/syncthing/syncthing/lib/upgrade"
+)
+
+func TestLocalReleases(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"stable/v1.2.0/syncthing-linux-amd64-v1.2.0.tar.gz":            "archive",
+		"stable/v1.2.0/compat.json":                                    `{"runtime":"go1.24","requirements":{"darwin":"21"}}`,
+		"stable/v1.1.0/syncthing-linux-amd64-v1.1.0.tar.gz":            "archive",
+		"internal/v1.2.1-rc.1/syncthing-windows-amd64-v1.2.1-rc.1.zip": "archive",
+		"internal/v1.2.1-rc.1/README.txt":                              "not an asset",
+		"internal/empty/syncthing-linux-amd64-v0.0.0.tar.gz":           "not a tag",
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	local := &localReleases{dir: dir, baseURL: "https://upgrades.example.com/"}
+	if err := local.Update(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	stable := local.Releases(upgrade.DefaultChannel)
+	if len(stable) != 2 || stable[0].Tag != "v1.2.0" || stable[0].Channel != "" {
+		t.Fatalf("unexpected stable releases %+v", stable)
+	}
+	if c := stable[0].Compatibility; c == nil || c.Requirements["darwin"] != "21" {
+		t.Errorf("compat.json not loaded: %+v", c)
+	}
+	if u := stable[0].Assets[0].URL; u != "https://upgrades.example.com/download/stable/v1.2.0/syncthing-linux-amd64-v1.2.0.tar.gz" {
+		t.Errorf("unexpected asset URL %q", u)
+	}
+
+	internal := local.Releases("internal")
+	if len(internal) != 1 || !internal[0].Prerelease || internal[0].Channel != "internal" || len(internal[0].Assets) != 1 {
+		t.Fatalf("unexpected internal releases %+v", internal)
+	}
+
+	// The metadata is signed when we have a key.
+	priv, pub, err := signature.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &releaseServer{source: local, signingKey: priv}
+	rec := httptest.NewRecorder()
+	srv.serveReleases(rec, httptest.NewRequest(http.MethodGet, "/meta.json?channel=internal", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status %d", rec.Code)
+	}
+	sig, err := base64.StdEncoding.DecodeString(rec.Header().Get(upgrade.ManifestSignatureHeader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := signature.Verify(pub, sig, bytes.NewReader(rec.Body.Bytes())); err != nil {
+		t.Error("metadata signature does not verify:", err)
+	}
+}
@@ -7,8 +7,11 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -27,6 +30,7 @@ import (
 	"github.com/syncthing/syncthing/internal/slogutil"
 	_ "github.com/syncthing/syncthing/lib/automaxprocs"
 	"github.com/syncthing/syncthing/lib/httpcache"
+	"github.com/syncthing/syncthing/lib/signature"
 	"github.com/syncthing/syncthing/lib/upgrade"
 )
 
@@ -36,6 +40,9 @@ type cli struct {
 	URL           string        `short:"u" default:"https://api.github.com/repos/syncthing/syncthing/releases?per_page=25" help:"GitHub releases url"`
 	Forward       []string      `short:"f" help:"Forwarded pages, format: /path->https://example/com/url"`
 	CacheTime     time.Duration `default:"15m" help:"Cache time"`
+	Dir           string        `help:"Serve releases from a local directory of signed release archives, laid out as <channel>/<tag>/<archive>, instead of GitHub"`
+	BaseURL       string        `help:"Public URL of this server, used for the download links of releases in the local directory"`
+	SigningKey    string        `type:"existingfile" help:"Private key used to sign the served release metadata"`
 }
 
 func main() {
@@ -67,8 +74,19 @@ func server(params *cli) error {
 		}()
 	}
 
-	cache := &cachedReleases{url: params.URL}
-	if err := cache.Update(context.Background()); err != nil {
+	var source releaseSource
+	sourceName := params.URL
+	if params.Dir != "" {
+		if params.BaseURL == "" {
+			return errors.New("a base URL is required when serving a local directory")
+		}
+		source = &localReleases{dir: params.Dir, baseURL: params.BaseURL}
+		sourceName = params.Dir
+	} else {
+		source = &cachedReleases{url: params.URL}
+	}
+
+	if err := source.Update(context.Background()); err != nil {
 		return fmt.Errorf("initial cache update: %w", err)
 	} else {
 		slog.Info("Initial cache update done")
@@ -76,17 +94,27 @@ func server(params *cli) error {
 
 	go func() {
 		for range time.NewTicker(params.CacheTime).C {
-			slog.Info("Refreshing cached releases", slogutil.URI(params.URL))
-			if err := cache.Update(context.Background()); err != nil {
-				slog.Error("Failed to refresh cached releases", slogutil.URI(params.URL), slogutil.Error(err))
+			slog.Info("Refreshing cached releases", slogutil.URI(sourceName))
+			if err := source.Update(context.Background()); err != nil {
+				slog.Error("Failed to refresh cached releases", slogutil.URI(sourceName), slogutil.Error(err))
 			}
 		}
 	}()
 
-	ghRels := &githubReleases{cache: cache}
+	rels := &releaseServer{source: source}
+	if params.SigningKey != "" {
+		key, err := os.ReadFile(params.SigningKey)
+		if err != nil {
+			return fmt.Errorf("signing key: %w", err)
+		}
+		rels.signingKey = key
+	}
 	mux := http.NewServeMux()
-	mux.HandleFunc("/ping", ghRels.servePing)
-	mux.HandleFunc("/meta.json", ghRels.serveReleases)
+	mux.HandleFunc("/ping", rels.servePing)
+	mux.HandleFunc("/meta.json", rels.serveReleases)
+	if params.Dir != "" {
+		mux.Handle("/download/", http.StripPrefix("/download/", http.FileServerFS(os.DirFS(params.Dir))))
+	}
 
 	for _, fwd := range params.Forward {
 		path, url, ok := strings.Cut(fwd, "->")
@@ -115,12 +143,19 @@ func server(params *cli) error {
 	return srv.Serve(srvListener)
 }
 
-type githubReleases struct {
-	cache *cachedReleases
+// A releaseSource provides the current releases of each channel.
+type releaseSource interface {
+	Update(ctx context.Context) error
+	Releases(channel string) []upgrade.Release
+}
+
+type releaseServer struct {
+	source     releaseSource
+	signingKey []byte // signs the release metadata, if set
 }
 
-func (p *githubReleases) servePing(w http.ResponseWriter, req *http.Request) {
-	rels := p.cache.Releases()
+func (p *releaseServer) servePing(w http.ResponseWriter, req *http.Request) {
+	rels := p.source.Releases(upgrade.DefaultChannel)
 
 	if len(rels) == 0 {
 		http.Error(w, "No releases available", http.StatusServiceUnavailable)
@@ -131,8 +166,12 @@ func (p *githubReleases) servePing(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (p *githubReleases) serveReleases(w http.ResponseWriter, req *http.Request) {
-	rels := p.cache.Releases()
+func (p *releaseServer) serveReleases(w http.ResponseWriter, req *http.Request) {
+	channel := req.URL.Query().Get("channel")
+	if channel == "" {
+		channel = upgrade.DefaultChannel
+	}
+	rels := p.source.Releases(channel)
 
 	ua := req.Header.Get("User-Agent")
 	osv := req.Header.Get("Syncthing-Os-Version")
@@ -145,12 +184,27 @@ func (p *githubReleases) serveReleases(w http.ResponseWriter, req *http.Request)
 
 	rels = filterForLatest(rels)
 
+	bs, err := json.Marshal(rels)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if p.signingKey != nil {
+		sig, err := signature.Sign(p.signingKey, bytes.NewReader(bs))
+		if err != nil {
+			slog.Error("Failed to sign release metadata", slogutil.Error(err))
+			http.Error(w, "Failed to sign release metadata", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(upgrade.ManifestSignatureHeader, base64.StdEncoding.EncodeToString(sig))
+	}
+
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 	w.Header().Set("Access-Control-Allow-Methods", "GET")
 	w.Header().Set("Cache-Control", "public, max-age=900")
 	w.Header().Set("Vary", "User-Agent, Syncthing-Os-Version")
-	_ = json.NewEncoder(w).Encode(rels)
+	_, _ = w.Write(bs)
 
 	metricUpgradeChecks.Inc()
 }
@@ -269,7 +323,9 @@ type cachedReleases struct {
 	latestRel, latestPre string
 }
 
-func (c *cachedReleases) Releases() []upgrade.Release {
+// Releases returns the cached GitHub releases. There is only the one
+// channel on GitHub, so the same releases are returned for any channel.
+func (c *cachedReleases) Releases(_ string) []upgrade.Release {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
 	return c.current
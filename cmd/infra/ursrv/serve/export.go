@@ -0,0 +1,151 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package serve
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/ur/contract"
+)
+
+// handleExport writes the stored reports matching the query parameters
+// (see parseQuery; field and bucket are ignored) as CSV or Parquet,
+// depending on the "format" parameter. There is one row per device and day
+// and one column per report field.
+func (s *server) handleExport(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r.URL.Query(), false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var reports []*contract.Report
+	for _, rep := range s.history.reports(q.from, q.to) {
+		if q.matches(rep) {
+			reports = append(reports, rep)
+		}
+	}
+
+	format := r.URL.Query().Get("format")
+	name := fmt.Sprintf("reports-%s", time.Now().UTC().Format(dateFormat))
+	switch format {
+	case "", "csv":
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".csv"))
+		err = writeCSV(w, reports)
+	case "parquet":
+		w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".parquet"))
+		err = writeParquet(w, reports)
+	default:
+		http.Error(w, fmt.Sprintf("unknown format %q", format), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		// Headers are already sent, so all we can do is log it.
+		slog.Error("Failed to export reports", "format", format, slogutil.Error(err))
+	}
+}
+
+// writeCSV writes the reports as CSV with a header row of field names.
+// Maps and slices are encoded as JSON.
+func writeCSV(w io.Writer, reports []*contract.Report) error {
+	fields, _ := reportFields()
+	cw := csv.NewWriter(w)
+
+	row := make([]string, len(fields))
+	for i, f := range fields {
+		row[i] = f.name
+	}
+	if err := cw.Write(row); err != nil {
+		return err
+	}
+
+	for _, rep := range reports {
+		for i, f := range fields {
+			row[i] = fieldString(f.value(rep))
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// parquetSchema returns the Parquet schema for the report fields. Maps and
+// slices are stored as JSON strings.
+func parquetSchema() *parquet.Schema {
+	fields, _ := reportFields()
+	group := make(parquet.Group, len(fields))
+	for _, f := range fields {
+		group[f.name] = parquetNode(f.typ)
+	}
+	return parquet.NewSchema("report", group)
+}
+
+func parquetNode(t reflect.Type) parquet.Node {
+	if t == reflect.TypeFor[time.Time]() {
+		return parquet.Timestamp(parquet.Millisecond)
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return parquet.Leaf(parquet.BooleanType)
+	case reflect.Int, reflect.Int64:
+		return parquet.Int(64)
+	case reflect.Float64:
+		return parquet.Leaf(parquet.DoubleType)
+	default:
+		return parquet.String()
+	}
+}
+
+func parquetValue(v reflect.Value) parquet.Value {
+	if t, ok := v.Interface().(time.Time); ok {
+		return parquet.Int64Value(t.UnixMilli())
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		return parquet.BooleanValue(v.Bool())
+	case reflect.Int, reflect.Int64:
+		return parquet.Int64Value(v.Int())
+	case reflect.Float64:
+		return parquet.DoubleValue(v.Float())
+	default:
+		return parquet.ByteArrayValue([]byte(fieldString(v)))
+	}
+}
+
+// writeParquet writes the reports as a Parquet file. The column order of the
+// schema is the sorted field order, same as for reportFields.
+func writeParquet(w io.Writer, reports []*contract.Report) error {
+	fields, _ := reportFields()
+	pw := parquet.NewWriter(w, parquetSchema())
+
+	rows := make([]parquet.Row, 0, len(reports))
+	for _, rep := range reports {
+		row := make(parquet.Row, len(fields))
+		for i, f := range fields {
+			row[i] = parquetValue(f.value(rep)).Level(0, 0, i)
+		}
+		rows = append(rows, row)
+	}
+
+	if _, err := pw.WriteRows(rows); err != nil {
+		return err
+	}
+	return pw.Close()
+}
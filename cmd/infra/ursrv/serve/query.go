@@ -0,0 +1,339 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package serve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/ur/contract"
+)
+
+// reportField is a leaf field of the report, addressed by the dotted path
+// of its JSON names, such as "folderUses.sendonly".
+type reportField struct {
+	name  string
+	index []int
+	typ   reflect.Type
+}
+
+func (f reportField) value(rep *contract.Report) reflect.Value {
+	return reflect.ValueOf(rep).Elem().FieldByIndex(f.index)
+}
+
+var (
+	reportFieldsOnce sync.Once
+	reportFieldsList []reportField
+	reportFieldsMap  map[string]reportField
+)
+
+// reportFields returns all leaf fields of the report, sorted by name.
+func reportFields() ([]reportField, map[string]reportField) {
+	reportFieldsOnce.Do(func() {
+		var walk func(t reflect.Type, prefix string, index []int)
+		walk = func(t reflect.Type, prefix string, index []int) {
+			for i := range t.NumField() {
+				sf := t.Field(i)
+				name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+				if name == "" || name == "-" {
+					continue
+				}
+				idx := append(slices.Clone(index), i)
+				if sf.Type.Kind() == reflect.Struct && sf.Type != reflect.TypeFor[time.Time]() {
+					walk(sf.Type, prefix+name+".", idx)
+					continue
+				}
+				reportFieldsList = append(reportFieldsList, reportField{name: prefix + name, index: idx, typ: sf.Type})
+			}
+		}
+		walk(reflect.TypeFor[contract.Report](), "", nil)
+
+		slices.SortFunc(reportFieldsList, func(a, b reportField) int {
+			return strings.Compare(a.name, b.name)
+		})
+		reportFieldsMap = make(map[string]reportField, len(reportFieldsList))
+		for _, f := range reportFieldsList {
+			reportFieldsMap[f.name] = f
+		}
+	})
+	return reportFieldsList, reportFieldsMap
+}
+
+// A query selects the reports in a time range that match all filters,
+// groups them into time buckets and aggregates a single field.
+type query struct {
+	field   reportField
+	bucket  string
+	from    time.Time
+	to      time.Time
+	filters []queryFilter
+}
+
+type queryFilter struct {
+	field reportField
+	value string
+}
+
+// queryBucket is the result for one time bucket. Categorical fields
+// (strings, booleans and maps) are counted by value; numeric fields are
+// summarised.
+type queryBucket struct {
+	Start   time.Time      `json:"start"`
+	Reports int            `json:"reports"`
+	Values  map[string]int `json:"values,omitempty"`
+	Stats   *numericStats  `json:"stats,omitempty"`
+}
+
+type numericStats struct {
+	Count int     `json:"count"`
+	Sum   float64 `json:"sum"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+}
+
+func (n *numericStats) observe(v float64) {
+	if n.Count == 0 || v < n.Min {
+		n.Min = v
+	}
+	if n.Count == 0 || v > n.Max {
+		n.Max = v
+	}
+	n.Count++
+	n.Sum += v
+	n.Mean = n.Sum / float64(n.Count)
+}
+
+type queryResult struct {
+	Field   string         `json:"field"`
+	Bucket  string         `json:"bucket"`
+	Buckets []*queryBucket `json:"buckets"`
+}
+
+// parseQuery parses the query parameters "field", "bucket" (day, week or
+// month), "from" and "to" (dates as YYYY-MM-DD) and any number of "where"
+// filters of the form field=value.
+func parseQuery(params map[string][]string, requireField bool) (*query, error) {
+	_, fields := reportFields()
+	get := func(key string) string {
+		if vs := params[key]; len(vs) > 0 {
+			return vs[0]
+		}
+		return ""
+	}
+
+	q := &query{bucket: get("bucket")}
+	if name := get("field"); name != "" {
+		f, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		q.field = f
+	} else if requireField {
+		return nil, errors.New("missing field")
+	}
+
+	switch q.bucket {
+	case "":
+		q.bucket = "day"
+	case "day", "week", "month":
+	default:
+		return nil, fmt.Errorf("unknown bucket %q", q.bucket)
+	}
+
+	var err error
+	if v := get("from"); v != "" {
+		if q.from, err = time.Parse(time.DateOnly, v); err != nil {
+			return nil, fmt.Errorf("from: %w", err)
+		}
+	}
+	if v := get("to"); v != "" {
+		if q.to, err = time.Parse(time.DateOnly, v); err != nil {
+			return nil, fmt.Errorf("to: %w", err)
+		}
+	}
+
+	for _, where := range params["where"] {
+		name, value, ok := strings.Cut(where, "=")
+		if !ok {
+			return nil, fmt.Errorf("filter %q is not of the form field=value", where)
+		}
+		f, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown filter field %q", name)
+		}
+		q.filters = append(q.filters, queryFilter{field: f, value: value})
+	}
+
+	return q, nil
+}
+
+// matches returns true if the report passes all filters of the query.
+func (q *query) matches(rep *contract.Report) bool {
+	for _, f := range q.filters {
+		if fieldString(f.field.value(rep)) != f.value {
+			return false
+		}
+	}
+	return true
+}
+
+// bucketStart returns the start of the bucket containing t. Weeks start on
+// Monday.
+func bucketStart(t time.Time, bucket string) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case "week":
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case "month":
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// run executes the query over the given reports. Within each bucket only the
+// most recent report of each device is counted.
+func (q *query) run(reports []*contract.Report) *queryResult {
+	latest := make(map[time.Time]map[string]*contract.Report)
+	for _, rep := range reports {
+		if !q.matches(rep) {
+			continue
+		}
+		start := bucketStart(reportTime(rep), q.bucket)
+		devs, ok := latest[start]
+		if !ok {
+			devs = make(map[string]*contract.Report)
+			latest[start] = devs
+		}
+		if cur, ok := devs[rep.UniqueID]; !ok || reportTime(cur).Before(reportTime(rep)) {
+			devs[rep.UniqueID] = rep
+		}
+	}
+
+	res := &queryResult{Field: q.field.name, Bucket: q.bucket, Buckets: make([]*queryBucket, 0, len(latest))}
+	for start, devs := range latest {
+		b := &queryBucket{Start: start, Reports: len(devs)}
+		for _, rep := range devs {
+			q.aggregate(b, q.field.value(rep))
+		}
+		res.Buckets = append(res.Buckets, b)
+	}
+	slices.SortFunc(res.Buckets, func(a, b *queryBucket) int {
+		return a.Start.Compare(b.Start)
+	})
+	return res
+}
+
+func (q *query) aggregate(b *queryBucket, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int64, reflect.Float64:
+		if b.Stats == nil {
+			b.Stats = &numericStats{}
+		}
+		b.Stats.observe(numericValue(v))
+	case reflect.Slice:
+		if b.Stats == nil {
+			b.Stats = &numericStats{}
+		}
+		for i := range v.Len() {
+			b.Stats.observe(numericValue(v.Index(i)))
+		}
+	case reflect.Map:
+		if b.Values == nil {
+			b.Values = make(map[string]int)
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			b.Values[iter.Key().String()] += int(iter.Value().Int())
+		}
+	default:
+		if b.Values == nil {
+			b.Values = make(map[string]int)
+		}
+		b.Values[fieldString(v)]++
+	}
+}
+
+func numericValue(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Float64:
+		return v.Float()
+	}
+	return math.NaN()
+}
+
+// fieldString returns the string representation of a scalar field, as
+// used for filters and categorical values. Composite values are encoded as
+// JSON.
+func fieldString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.UTC().Format(time.RFC3339)
+	}
+	bs, _ := json.Marshal(v.Interface())
+	return string(bs)
+}
+
+// reportTime returns the time the report was received. Reports loaded from
+// old dumps may only have a date.
+func reportTime(rep *contract.Report) time.Time {
+	if !rep.Received.IsZero() {
+		return rep.Received
+	}
+	t, _ := time.Parse(dateFormat, rep.Date)
+	return t
+}
+
+type queryField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// handleQueryFields lists the fields that can be queried and filtered on.
+func (s *server) handleQueryFields(w http.ResponseWriter, _ *http.Request) {
+	list, _ := reportFields()
+	res := make([]queryField, 0, len(list))
+	for _, f := range list {
+		res = append(res, queryField{Name: f.name, Type: f.typ.String()})
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func (s *server) handleQuery(w http.ResponseWriter, r *http.Request) {
+	q, err := parseQuery(r.URL.Query(), true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res := q.run(s.history.reports(q.from, q.to))
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(res)
+}
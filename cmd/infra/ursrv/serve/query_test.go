@@ -0,0 +1,169 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package serve
+
+import (
+	"bytes"
+	"encoding/csv"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/syncthing/syncthing/lib/ur/contract"
+)
+
+func testReport(id, os string, folders int, received time.Time) *contract.Report {
+	rep := &contract.Report{
+		UniqueID:   id,
+		OS:         os,
+		NumFolders: folders,
+		Received:   received,
+		Date:       received.UTC().Format(dateFormat),
+	}
+	rep.FolderUsesV3.PullOrder = map[string]int{"random": folders}
+	return rep
+}
+
+func testStore() *reportStore {
+	s := newReportStore(30 * 24 * time.Hour)
+	mon := time.Date(2025, 3, 3, 12, 0, 0, 0, time.UTC) // a Monday
+	s.add(testReport("a", "linux", 1, mon))
+	s.add(testReport("b", "windows", 3, mon))
+	s.add(testReport("a", "linux", 5, mon.Add(time.Hour))) // replaces the first
+	s.add(testReport("a", "linux", 2, mon.AddDate(0, 0, 1)))
+	s.add(testReport("c", "linux", 4, mon.AddDate(0, 0, 7)))
+	return s
+}
+
+func TestReportStore(t *testing.T) {
+	s := testStore()
+	if n := s.size(); n != 4 {
+		t.Fatalf("expected 4 stored reports, got %d", n)
+	}
+
+	from := time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	if reps := s.reports(from, to); len(reps) != 1 || reps[0].NumFolders != 2 {
+		t.Errorf("unexpected reports in range: %v", reps)
+	}
+
+	s.expire(time.Date(2025, 4, 5, 0, 0, 0, 0, time.UTC))
+	if n := s.size(); n != 1 {
+		t.Errorf("expected 1 report after expiry, got %d", n)
+	}
+
+	var buf bytes.Buffer
+	if err := s.save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	s2 := newReportStore(0)
+	s2.load(&buf)
+	if n := s2.size(); n != 1 {
+		t.Errorf("expected 1 report after reload, got %d", n)
+	}
+}
+
+func TestQueryBuckets(t *testing.T) {
+	s := testStore()
+
+	q, err := parseQuery(url.Values{"field": {"os"}, "bucket": {"week"}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := q.run(s.reports(q.from, q.to))
+	if len(res.Buckets) != 2 {
+		t.Fatalf("expected two weekly buckets, got %d", len(res.Buckets))
+	}
+	first := res.Buckets[0]
+	if first.Reports != 2 || first.Values["linux"] != 1 || first.Values["windows"] != 1 {
+		t.Errorf("unexpected first bucket %+v", first)
+	}
+
+	q, err = parseQuery(url.Values{"field": {"numFolders"}, "where": {"os=linux"}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = q.run(s.reports(q.from, q.to))
+	if len(res.Buckets) != 3 {
+		t.Fatalf("expected three daily buckets, got %d", len(res.Buckets))
+	}
+	if st := res.Buckets[0].Stats; st == nil || st.Count != 1 || st.Sum != 5 {
+		t.Errorf("unexpected stats %+v", st)
+	}
+
+	q, err = parseQuery(url.Values{"field": {"folderUsesV3.pullOrder"}, "bucket": {"month"}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = q.run(s.reports(q.from, q.to))
+	if len(res.Buckets) != 1 || res.Buckets[0].Values["random"] != 9 {
+		t.Errorf("unexpected map aggregate %+v", res.Buckets)
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	cases := []url.Values{
+		{},
+		{"field": {"nonexistent"}},
+		{"field": {"os"}, "bucket": {"year"}},
+		{"field": {"os"}, "from": {"yesterday"}},
+		{"field": {"os"}, "where": {"os"}},
+	}
+	for _, c := range cases {
+		if _, err := parseQuery(c, true); err == nil {
+			t.Errorf("expected error for %v", c)
+		}
+	}
+}
+
+func TestBucketStart(t *testing.T) {
+	sun := time.Date(2025, 3, 9, 23, 0, 0, 0, time.UTC)
+	if got := bucketStart(sun, "week"); !got.Equal(time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("week of Sunday starts %v", got)
+	}
+	if got := bucketStart(sun, "month"); !got.Equal(time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("month starts %v", got)
+	}
+}
+
+func TestExport(t *testing.T) {
+	reports := testStore().reports(time.Time{}, time.Time{})
+	fields, _ := reportFields()
+	osCol := slices.IndexFunc(fields, func(f reportField) bool { return f.name == "os" })
+
+	var buf bytes.Buffer
+	if err := writeCSV(&buf, reports); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(reports)+1 {
+		t.Fatalf("expected %d CSV rows, got %d", len(reports)+1, len(rows))
+	}
+	if rows[0][osCol] != "os" || rows[1][osCol] != "linux" {
+		t.Errorf("unexpected CSV content %q %q", rows[0][osCol], rows[1][osCol])
+	}
+
+	buf.Reset()
+	if err := writeParquet(&buf, reports); err != nil {
+		t.Fatal(err)
+	}
+	f, err := parquet.OpenFile(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := f.NumRows(); n != int64(len(reports)) {
+		t.Errorf("expected %d Parquet rows, got %d", len(reports), n)
+	}
+	if cols := len(f.Schema().Columns()); cols != len(fields) {
+		t.Errorf("expected %d Parquet columns, got %d", len(fields), cols)
+	}
+}
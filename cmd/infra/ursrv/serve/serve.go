@@ -44,6 +44,9 @@ type CLI struct {
 	DumpFile        string        `env:"UR_DUMP_FILE" default:"reports.jsons.gz"`
 	DumpInterval    time.Duration `env:"UR_DUMP_INTERVAL" default:"5m"`
 
+	HistoryFile      string        `env:"UR_HISTORY_FILE" help:"File where the queryable report history is kept" default:"history.jsons.gz"`
+	HistoryRetention time.Duration `env:"UR_HISTORY_RETENTION" help:"How long to keep reports in the history (0 for forever)" default:"2160h"`
+
 	S3Endpoint    string `name:"s3-endpoint" env:"UR_S3_ENDPOINT"`
 	S3Region      string `name:"s3-region" env:"UR_S3_REGION"`
 	S3Bucket      string `name:"s3-bucket" env:"UR_S3_BUCKET"`
@@ -157,6 +160,15 @@ func (cli *CLI) Run() error {
 	srv := &server{
 		geo:     geo,
 		reports: xsync.NewMapOf[string, *contract.Report](),
+		history: newReportStore(cli.HistoryRetention),
+	}
+
+	if fd, err := os.Open(cli.HistoryFile); err == nil {
+		gr, err := gzip.NewReader(fd)
+		if err == nil {
+			srv.history.load(gr)
+		}
+		fd.Close()
 	}
 
 	if fd, err := os.Open(cli.DumpFile); err == nil {
@@ -172,17 +184,25 @@ func (cli *CLI) Run() error {
 			if err := cli.saveDumpFile(srv, blobs); err != nil {
 				slog.Error("Failed to write dump file", slogutil.Error(err))
 			}
+			srv.history.expire(time.Now())
+			if err := cli.saveHistoryFile(srv); err != nil {
+				slog.Error("Failed to write history file", slogutil.Error(err))
+			}
 		}
 	}()
 
 	// The internal metrics endpoint just serves metrics about what the
-	// server is doing.
+	// server is doing, and the query and export API over the report
+	// history.
 
 	http.Handle("/metrics", promhttp.Handler())
+	http.HandleFunc("/query", srv.handleQuery)
+	http.HandleFunc("/query/fields", srv.handleQueryFields)
+	http.HandleFunc("/export", srv.handleExport)
 
 	internalSrv := http.Server{
 		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: 5 * time.Minute,
 	}
 	go internalSrv.Serve(internalListener)
 
@@ -276,9 +296,33 @@ func (cli *CLI) saveDumpFile(srv *server, blobs blob.Store) error {
 	return nil
 }
 
+func (cli *CLI) saveHistoryFile(srv *server) error {
+	fd, err := os.Create(cli.HistoryFile + ".tmp")
+	if err != nil {
+		return fmt.Errorf("creating history file: %w", err)
+	}
+	gw := gzip.NewWriter(fd)
+	if err := srv.history.save(gw); err != nil {
+		fd.Close()
+		return fmt.Errorf("saving history file: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		fd.Close()
+		return fmt.Errorf("closing gzip writer: %w", err)
+	}
+	if err := fd.Close(); err != nil {
+		return fmt.Errorf("closing history file: %w", err)
+	}
+	if err := os.Rename(cli.HistoryFile+".tmp", cli.HistoryFile); err != nil {
+		return fmt.Errorf("renaming history file: %w", err)
+	}
+	return nil
+}
+
 type server struct {
 	geo     *geoip.Provider
 	reports *xsync.MapOf[string, *contract.Report]
+	history *reportStore
 }
 
 func (s *server) handlePing(w http.ResponseWriter, r *http.Request) {
@@ -390,6 +434,9 @@ func (s *server) addReport(rep *contract.Report) bool {
 	}
 
 	_, loaded := s.reports.LoadAndStore(rep.UniqueID, rep)
+	if s.history != nil {
+		s.history.add(rep)
+	}
 	return loaded
 }
 
@@ -0,0 +1,155 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package serve
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/ur/contract"
+)
+
+const dateFormat = "20060102"
+
+// reportStore keeps the history of received reports, at most one report
+// per device and day. Unlike the set of current reports used for the
+// Prometheus metrics it is retained for a configurable duration and can be
+// queried for any time range.
+type reportStore struct {
+	retention time.Duration
+
+	mut  sync.RWMutex
+	days map[string]map[string]*contract.Report // date -> unique ID -> report
+}
+
+func newReportStore(retention time.Duration) *reportStore {
+	return &reportStore{
+		retention: retention,
+		days:      make(map[string]map[string]*contract.Report),
+	}
+}
+
+// add stores the report under the date it was received, replacing any
+// earlier report from the same device on the same date.
+func (s *reportStore) add(rep *contract.Report) {
+	date := rep.Date
+	if date == "" {
+		date = rep.Received.UTC().Format(dateFormat)
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	day, ok := s.days[date]
+	if !ok {
+		day = make(map[string]*contract.Report)
+		s.days[date] = day
+	}
+	day[rep.UniqueID] = rep
+}
+
+// expire removes all days that are older than the retention period.
+func (s *reportStore) expire(now time.Time) {
+	if s.retention <= 0 {
+		return
+	}
+	cutoff := now.Add(-s.retention).UTC().Format(dateFormat)
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	for date := range s.days {
+		if date < cutoff {
+			delete(s.days, date)
+		}
+	}
+}
+
+// reports returns the stored reports received on or after from and before
+// to, in date order. A zero time means no limit in that direction.
+func (s *reportStore) reports(from, to time.Time) []*contract.Report {
+	var fromDate, toDate string
+	if !from.IsZero() {
+		fromDate = from.UTC().Format(dateFormat)
+	}
+	if !to.IsZero() {
+		toDate = to.UTC().Format(dateFormat)
+	}
+
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	dates := make([]string, 0, len(s.days))
+	for date := range s.days {
+		if fromDate != "" && date < fromDate {
+			continue
+		}
+		if toDate != "" && date >= toDate {
+			continue
+		}
+		dates = append(dates, date)
+	}
+	slices.Sort(dates)
+
+	var res []*contract.Report
+	for _, date := range dates {
+		day := s.days[date]
+		ids := make([]string, 0, len(day))
+		for id := range day {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		for _, id := range ids {
+			res = append(res, day[id])
+		}
+	}
+	return res
+}
+
+func (s *reportStore) size() int {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	var n int
+	for _, day := range s.days {
+		n += len(day)
+	}
+	return n
+}
+
+func (s *reportStore) save(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, rep := range s.reports(time.Time{}, time.Time{}) {
+		if err := enc.Encode(rep); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// load reads reports as written by save. The reports are expected to have
+// been through the server's post processing already.
+func (s *reportStore) load(r io.Reader) {
+	t0 := time.Now()
+	dec := json.NewDecoder(r)
+	for {
+		var rep contract.Report
+		if err := dec.Decode(&rep); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			slog.Error("Failed to load history record", slogutil.Error(err))
+			break
+		}
+		s.add(&rep)
+	}
+	slog.Info("Loaded report history", "count", s.size(), "d", time.Since(t0).String())
+}
@@ -0,0 +1,320 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/urfave/cli"
+	"sigs.k8s.io/yaml"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// A desiredConfig is the part of the configuration managed by "config
+// apply", in YAML or JSON with the same names as the REST API. Sections
+// that are left out are left alone. Options and defaults are merged into
+// the current ones, while the listed folders and devices replace the
+// existing ones, each merged into its current settings or, for new
+// entries, the defaults.
+type desiredConfig struct {
+	Folders  *[]map[string]any `json:"folders"`
+	Devices  *[]map[string]any `json:"devices"`
+	Options  map[string]any    `json:"options"`
+	Defaults map[string]any    `json:"defaults"`
+}
+
+func (h *configHandler) applyCommand() cli.Command {
+	return cli.Command{
+		Name:      "apply",
+		Usage:     "Apply a desired configuration from a file",
+		ArgsUsage: "-f FILE",
+		Description: "Shows the changes needed to make the running configuration match\n" +
+			"the file and applies them all at once. With --check, only reports\n" +
+			"the changes and fails if there are any.",
+		Flags: []cli.Flag{
+			cli.StringFlag{Name: "file, f", Usage: "Desired configuration, in YAML or JSON"},
+			cli.BoolFlag{Name: "check", Usage: "Report drift without applying, exiting non-zero if there is any"},
+		},
+		Action: h.configApply,
+	}
+}
+
+func (h *configHandler) configApply(c *cli.Context) error {
+	file := c.String("file")
+	if file == "" {
+		return errors.New("a desired configuration file must be given with -f")
+	}
+	bs, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	desired, err := parseDesiredConfig(bs)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	myID, err := getMyID(h.client)
+	if err != nil {
+		return err
+	}
+	target, err := desired.applyTo(h.original, myID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+
+	changes := config.Diff(h.original, target)
+	printPlan(changes)
+	if len(changes) == 0 {
+		return nil
+	}
+	if c.Bool("check") {
+		return fmt.Errorf("configuration has drifted from %s", file)
+	}
+
+	// Someone else may have changed the configuration since we read it,
+	// and putting the target would silently undo that.
+	latest, err := getConfig(h.client)
+	if err != nil {
+		return err
+	}
+	if len(config.Diff(h.original, latest)) > 0 {
+		return errors.New("the configuration was changed while planning, not applying; run apply again")
+	}
+	if _, err := h.client.PutJSON("config", target); err != nil {
+		return err
+	}
+	fmt.Println("Applied.")
+	return nil
+}
+
+func parseDesiredConfig(bs []byte) (desiredConfig, error) {
+	// JSON is valid YAML, so this handles both.
+	bs, err := yaml.YAMLToJSON(bs)
+	if err != nil {
+		return desiredConfig{}, err
+	}
+	var desired desiredConfig
+	dec := json.NewDecoder(bytes.NewReader(bs))
+	dec.DisallowUnknownFields()
+	dec.UseNumber()
+	if err := dec.Decode(&desired); err != nil {
+		return desiredConfig{}, err
+	}
+	return desired, nil
+}
+
+// applyTo returns the current configuration changed as desired, prepared
+// the same way the REST API does it.
+func (d desiredConfig) applyTo(current config.Configuration, myID protocol.DeviceID) (config.Configuration, error) {
+	// Everything is done on the JSON form, so that unset settings keep
+	// their current values rather than becoming zero.
+	var cur map[string]any
+	if err := roundTrip(current, &cur); err != nil {
+		return config.Configuration{}, err
+	}
+
+	if d.Options != nil {
+		if err := checkFields(reflect.TypeOf(config.OptionsConfiguration{}), d.Options, "options"); err != nil {
+			return config.Configuration{}, err
+		}
+		mergeMaps(cur["options"].(map[string]any), d.Options)
+	}
+	if d.Defaults != nil {
+		if err := checkFields(reflect.TypeOf(config.Defaults{}), d.Defaults, "defaults"); err != nil {
+			return config.Configuration{}, err
+		}
+		mergeMaps(cur["defaults"].(map[string]any), d.Defaults)
+	}
+	defaults := cur["defaults"].(map[string]any)
+
+	if d.Folders != nil {
+		folders, err := applyList("folders", "id", cur["folders"], *d.Folders, defaults["folder"], reflect.TypeOf(config.FolderConfiguration{}), nil)
+		if err != nil {
+			return config.Configuration{}, err
+		}
+		cur["folders"] = folders
+	}
+	if d.Devices != nil {
+		for i, dev := range *d.Devices {
+			// Device IDs may be written in any of the accepted forms.
+			if s, ok := dev["deviceID"].(string); ok {
+				id, err := protocol.DeviceIDFromString(s)
+				if err != nil {
+					return config.Configuration{}, fmt.Errorf("devices[%d]: %w", i, err)
+				}
+				dev["deviceID"] = id.String()
+			}
+		}
+		// The local device is kept, whether listed or not.
+		isLocal := func(dev map[string]any) bool {
+			return dev["deviceID"] == myID.String()
+		}
+		devices, err := applyList("devices", "deviceID", cur["devices"], *d.Devices, defaults["device"], reflect.TypeOf(config.DeviceConfiguration{}), isLocal)
+		if err != nil {
+			return config.Configuration{}, err
+		}
+		cur["devices"] = devices
+	}
+
+	bs, err := json.Marshal(cur)
+	if err != nil {
+		return config.Configuration{}, err
+	}
+	return config.ReadJSON(bytes.NewReader(bs), myID)
+}
+
+// applyList returns the desired entries of a list, each merged into the
+// existing entry with the same key or else the base entry, followed by
+// the existing entries to keep.
+func applyList(name, key string, existing any, desired []map[string]any, base any, typ reflect.Type, keep func(map[string]any) bool) ([]any, error) {
+	byKey := make(map[string]map[string]any)
+	existingList, _ := existing.([]any)
+	for _, e := range existingList {
+		m := e.(map[string]any)
+		byKey[m[key].(string)] = m
+	}
+
+	seen := make(map[string]bool)
+	result := make([]any, 0, len(desired))
+	for i, d := range desired {
+		path := fmt.Sprintf("%s[%d]", name, i)
+		k, _ := d[key].(string)
+		if k == "" {
+			return nil, fmt.Errorf("%s: missing %s", path, key)
+		}
+		if seen[k] {
+			return nil, fmt.Errorf("%s: duplicate %s %s", path, key, k)
+		}
+		seen[k] = true
+		if err := checkFields(typ, d, path); err != nil {
+			return nil, err
+		}
+		entry, ok := byKey[k]
+		if !ok {
+			if err := roundTrip(base, &entry); err != nil {
+				return nil, err
+			}
+		}
+		mergeMaps(entry, d)
+		result = append(result, entry)
+	}
+	for _, e := range existingList {
+		m := e.(map[string]any)
+		if keep != nil && keep(m) && !seen[m[key].(string)] {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// mergeMaps sets the values of src in dst, merging nested objects and
+// replacing everything else.
+func mergeMaps(dst, src map[string]any) {
+	for k, sv := range src {
+		if sm, ok := sv.(map[string]any); ok {
+			if dm, ok := dst[k].(map[string]any); ok {
+				mergeMaps(dm, sm)
+				continue
+			}
+		}
+		dst[k] = sv
+	}
+}
+
+// checkFields returns an error for the first setting in m that doesn't
+// exist in the given struct type, as that is most likely a typo that
+// would otherwise be silently ignored.
+func checkFields(typ reflect.Type, m map[string]any, path string) error {
+	fields := make(map[string]reflect.Type)
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	for name, v := range m {
+		ft, ok := fields[name]
+		if !ok {
+			return fmt.Errorf("unknown setting %s.%s", path, name)
+		}
+		if sub, ok := v.(map[string]any); ok && ft.Kind() == reflect.Struct {
+			if err := checkFields(ft, sub, path+"."+name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func printPlan(changes []config.Change) {
+	if len(changes) == 0 {
+		fmt.Println("No changes.")
+		return
+	}
+	for _, c := range changes {
+		switch {
+		case c.From == nil:
+			if _, ok := c.To.(map[string]any); ok {
+				fmt.Printf("+ %s\n", c.Path)
+			} else {
+				fmt.Printf("+ %s: %s\n", c.Path, planValue(c.To))
+			}
+		case c.To == nil:
+			fmt.Printf("- %s\n", c.Path)
+		default:
+			fmt.Printf("~ %s: %s -> %s\n", c.Path, planValue(c.From), planValue(c.To))
+		}
+	}
+	fmt.Printf("%d changes.\n", len(changes))
+}
+
+func planValue(v any) string {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(bs)
+}
+
+func getMyID(c APIClient) (protocol.DeviceID, error) {
+	response, err := c.Get("system/status")
+	if err != nil {
+		return protocol.EmptyDeviceID, err
+	}
+	bs, err := responseToBArray(response)
+	if err != nil {
+		return protocol.EmptyDeviceID, err
+	}
+	var status struct {
+		MyID protocol.DeviceID `json:"myID"`
+	}
+	if err := json.Unmarshal(bs, &status); err != nil {
+		return protocol.EmptyDeviceID, err
+	}
+	return status.MyID, nil
+}
+
+// roundTrip converts v to dst through JSON.
+func roundTrip(v, dst any) error {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(bs, dst)
+}
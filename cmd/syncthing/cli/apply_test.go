@@ -0,0 +1,306 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+var (
+	applyLocalID = protocol.DeviceID{1}
+	applyOtherID = protocol.DeviceID{2}
+)
+
+func applyTestConfig() config.Configuration {
+	cfg := config.New(applyLocalID)
+	cfg.Options.MaxSendKbps = 5
+	for _, id := range []string{"a", "b"} {
+		fcfg := cfg.Defaults.Folder.Copy()
+		fcfg.ID = id
+		fcfg.Label = strings.ToUpper(id)
+		fcfg.Path = "/" + id
+		fcfg.RescanIntervalS = 60
+		cfg.Folders = append(cfg.Folders, fcfg)
+	}
+	dev := cfg.Defaults.Device.Copy()
+	dev.DeviceID = applyOtherID
+	dev.Name = "other"
+	cfg.Devices = append(cfg.Devices, dev)
+	return cfg
+}
+
+func TestApplyTo(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name    string
+		desired string
+		err     string
+		check   func(t *testing.T, cfg config.Configuration)
+	}{
+		{
+			name:    "options are merged",
+			desired: "options:\n  maxRecvKbps: 10\n",
+			check: func(t *testing.T, cfg config.Configuration) {
+				if cfg.Options.MaxRecvKbps != 10 || cfg.Options.MaxSendKbps != 5 {
+					t.Errorf("unexpected options %+v", cfg.Options)
+				}
+				if len(cfg.Folders) != 2 || len(cfg.Devices) != 2 {
+					t.Error("sections left out were changed")
+				}
+			},
+		},
+		{
+			name:    "defaults are merged",
+			desired: "defaults:\n  folder:\n    rescanIntervalS: 30\n",
+			check: func(t *testing.T, cfg config.Configuration) {
+				if cfg.Defaults.Folder.RescanIntervalS != 30 || cfg.Defaults.Folder.Path != config.New(applyLocalID).Defaults.Folder.Path {
+					t.Errorf("unexpected default folder %+v", cfg.Defaults.Folder)
+				}
+			},
+		},
+		{
+			name:    "folders are replaced, each merged into its current settings",
+			desired: "folders:\n  - id: b\n    label: Bee\n",
+			check: func(t *testing.T, cfg config.Configuration) {
+				if len(cfg.Folders) != 1 {
+					t.Fatalf("expected one folder, got %d", len(cfg.Folders))
+				}
+				if f := cfg.Folders[0]; f.ID != "b" || f.Label != "Bee" || f.Path != "/b" || f.RescanIntervalS != 60 {
+					t.Errorf("unexpected folder %+v", f)
+				}
+			},
+		},
+		{
+			name:    "new folders start from the defaults",
+			desired: "defaults:\n  folder:\n    rescanIntervalS: 30\nfolders:\n  - id: a\n  - id: c\n    path: /c\n",
+			check: func(t *testing.T, cfg config.Configuration) {
+				if len(cfg.Folders) != 2 {
+					t.Fatalf("expected two folders, got %d", len(cfg.Folders))
+				}
+				if f := cfg.Folders[0]; f.ID != "a" || f.RescanIntervalS != 60 {
+					t.Errorf("unexpected folder %+v", f)
+				}
+				if f := cfg.Folders[1]; f.ID != "c" || f.Path != "/c" || f.RescanIntervalS != 30 {
+					t.Errorf("unexpected folder %+v", f)
+				}
+			},
+		},
+		{
+			name:    "all folders can be removed",
+			desired: "folders: []\n",
+			check: func(t *testing.T, cfg config.Configuration) {
+				if len(cfg.Folders) != 0 {
+					t.Errorf("expected no folders, got %d", len(cfg.Folders))
+				}
+			},
+		},
+		{
+			name:    "removing devices keeps the local one",
+			desired: "devices: []\n",
+			check: func(t *testing.T, cfg config.Configuration) {
+				if len(cfg.Devices) != 1 || cfg.Devices[0].DeviceID != applyLocalID {
+					t.Errorf("unexpected devices %v", cfg.Devices)
+				}
+			},
+		},
+		{
+			name:    "devices are matched by ID in any form",
+			desired: "devices:\n  - deviceID: " + strings.ToLower(strings.ReplaceAll(applyOtherID.String(), "-", "")) + "\n    name: renamed\n",
+			check: func(t *testing.T, cfg config.Configuration) {
+				dev, _, ok := cfg.Device(applyOtherID)
+				if !ok || dev.Name != "renamed" || len(cfg.Devices) != 2 {
+					t.Errorf("unexpected devices %v", cfg.Devices)
+				}
+			},
+		},
+		{
+			name:    "unknown option",
+			desired: "options:\n  maxRecvKpbs: 10\n",
+			err:     "unknown setting options.maxRecvKpbs",
+		},
+		{
+			name:    "unknown nested default",
+			desired: "defaults:\n  folder:\n    rescanInterval: 30\n",
+			err:     "unknown setting defaults.folder.rescanInterval",
+		},
+		{
+			name:    "unknown folder setting",
+			desired: "folders:\n  - id: a\n    lable: A\n",
+			err:     "unknown setting folders[0].lable",
+		},
+		{
+			name:    "folder without ID",
+			desired: "folders:\n  - label: A\n",
+			err:     "folders[0]: missing id",
+		},
+		{
+			name:    "duplicate folder",
+			desired: "folders:\n  - id: a\n  - id: a\n",
+			err:     "folders[1]: duplicate id a",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			desired, err := parseDesiredConfig([]byte(tc.desired))
+			if err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := desired.applyTo(applyTestConfig(), applyLocalID)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.check(t, cfg)
+		})
+	}
+}
+
+func TestParseDesiredConfigUnknownSection(t *testing.T) {
+	t.Parallel()
+
+	if _, err := parseDesiredConfig([]byte("gui:\n  theme: dark\n")); err == nil {
+		t.Error("expected an error for an unmanaged section")
+	}
+}
+
+// applyClient serves the configurations in turn for each read, and
+// records what is put.
+type applyClient struct {
+	configs []config.Configuration
+	reads   int
+	put     any
+}
+
+func (c *applyClient) Get(url string) (*http.Response, error) {
+	switch url {
+	case "system/status":
+		return jsonResponse(map[string]any{"myID": applyLocalID})
+	case "system/config":
+		cfg := c.configs[min(c.reads, len(c.configs)-1)]
+		c.reads++
+		return jsonResponse(cfg)
+	}
+	return nil, errors.New("unexpected GET " + url)
+}
+
+func (c *applyClient) PutJSON(_ string, o interface{}) (*http.Response, error) {
+	c.put = o
+	return jsonResponse(o)
+}
+
+func (*applyClient) Post(url, _ string) (*http.Response, error) {
+	return nil, errors.New("unexpected POST " + url)
+}
+
+func (*applyClient) PostJSON(url string, _ interface{}) (*http.Response, error) {
+	return nil, errors.New("unexpected POST " + url)
+}
+
+func (*applyClient) Delete(url string) (*http.Response, error) {
+	return nil, errors.New("unexpected DELETE " + url)
+}
+
+func jsonResponse(v any) (*http.Response, error) {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(bs))}, nil
+}
+
+func TestConfigApply(t *testing.T) {
+	t.Parallel()
+
+	changed := applyTestConfig()
+	changed.Options.MaxRecvKbps = 99
+
+	cases := []struct {
+		name    string
+		desired string
+		check   bool
+		configs []config.Configuration // as read in turn
+		err     string
+		put     bool
+	}{
+		{name: "check without drift", desired: "options:\n  maxSendKbps: 5\n", check: true},
+		{name: "check with drift", desired: "options:\n  maxSendKbps: 10\n", check: true, err: "drifted"},
+		{name: "apply", desired: "options:\n  maxSendKbps: 10\n", put: true},
+		{name: "nothing to apply", desired: "options:\n  maxSendKbps: 5\n"},
+		{
+			name:    "changed while planning",
+			desired: "options:\n  maxSendKbps: 10\n",
+			configs: []config.Configuration{applyTestConfig(), changed},
+			err:     "changed while planning",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			file := filepath.Join(t.TempDir(), "desired.yaml")
+			if err := os.WriteFile(file, []byte(tc.desired), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			client := &applyClient{configs: tc.configs}
+			if client.configs == nil {
+				client.configs = []config.Configuration{applyTestConfig()}
+			}
+			h := &configHandler{client: client}
+			var err error
+			if h.cfg, err = getConfig(client); err != nil {
+				t.Fatal(err)
+			}
+			h.original = h.cfg.Copy()
+
+			app := cli.NewApp()
+			app.Commands = []cli.Command{h.applyCommand()}
+			args := []string{"config", "apply", "-f", file}
+			if tc.check {
+				args = append(args, "--check")
+			}
+			err = app.Run(args)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("expected error %q, got %v", tc.err, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if (client.put != nil) != tc.put {
+				t.Fatalf("unexpected put %v", client.put)
+			}
+			if tc.put {
+				if cfg := client.put.(config.Configuration); cfg.Options.MaxSendKbps != 10 {
+					t.Errorf("unexpected options put %+v", cfg.Options)
+				}
+			}
+		})
+	}
+}
@@ -28,6 +28,8 @@ type APIClient interface {
 	Get(url string) (*http.Response, error)
 	Post(url, body string) (*http.Response, error)
 	PutJSON(url string, o interface{}) (*http.Response, error)
+	PostJSON(url string, o interface{}) (*http.Response, error)
+	Delete(url string) (*http.Response, error)
 }
 
 type apiClient struct {
@@ -154,6 +156,14 @@ func (c *apiClient) PutJSON(url string, o interface{}) (*http.Response, error) {
 	return c.RequestJSON(url, "PUT", o)
 }
 
+func (c *apiClient) PostJSON(url string, o interface{}) (*http.Response, error) {
+	return c.RequestJSON(url, "POST", o)
+}
+
+func (c *apiClient) Delete(url string) (*http.Response, error) {
+	return c.RequestString(url, "DELETE", "")
+}
+
 var errNotFound = errors.New("invalid endpoint or API call")
 
 func checkResponse(response *http.Response) error {
@@ -0,0 +1,58 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"net/url"
+	"strconv"
+)
+
+type historyCommand struct {
+	List     historyListCommand     `cmd:"" help:"List the recorded configuration versions"`
+	Diff     historyDiffCommand     `cmd:"" help:"Show the changes between two configuration versions"`
+	Rollback historyRollbackCommand `cmd:"" help:"Roll back the configuration to an earlier version"`
+}
+
+type historyListCommand struct{}
+
+func (*historyListCommand) Run(ctx Context) error {
+	return indexDumpOutput("config/history", ctx.clientFactory)
+}
+
+type historyDiffCommand struct {
+	From int `arg:"" help:"Version to compare from"`
+	To   int `arg:"" optional:"" help:"Version to compare to (default the current configuration)"`
+}
+
+func (c *historyDiffCommand) Run(ctx Context) error {
+	qs := url.Values{"from": {strconv.Itoa(c.From)}}
+	if c.To > 0 {
+		qs.Set("to", strconv.Itoa(c.To))
+	}
+	client, err := ctx.clientFactory.getClient()
+	if err != nil {
+		return err
+	}
+	response, err := client.Get("config/history/diff?" + qs.Encode())
+	if err != nil {
+		return err
+	}
+	return prettyPrintResponse(response)
+}
+
+type historyRollbackCommand struct {
+	Version int `arg:"" help:"Version to roll back to"`
+}
+
+func (c *historyRollbackCommand) Run(ctx Context) error {
+	client, err := ctx.clientFactory.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.Post("config/history/rollback?version="+strconv.Itoa(c.Version), "")
+	return err
+}
@@ -25,6 +25,8 @@ type CLI struct {
 	Debug      debugCommand     `cmd:"" help:"Debug command group"`
 	Operations operationCommand `cmd:"" help:"Operation command group"`
 	Errors     errorsCommand    `cmd:"" help:"Error command group"`
+	Tokens     tokensCommand    `cmd:"" help:"API token command group"`
+	History    historyCommand   `cmd:"" help:"Configuration history command group"`
 	Config     configCommand    `cmd:"" help:"Configuration modification command group" passthrough:""`
 	Stdin      stdinCommand     `cmd:"" name:"-" help:"Read commands from stdin"`
 }
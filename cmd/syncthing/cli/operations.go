@@ -11,7 +11,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"path/filepath"
+	"strconv"
 
 	"github.com/alecthomas/kong"
 	"github.com/syncthing/syncthing/lib/config"
@@ -26,12 +28,24 @@ type defaultIgnoresCommand struct {
 	Path string `arg:""`
 }
 
+type massChangeCommand struct {
+	FolderID string `arg:""`
+	Action   string `arg:"" optional:"" enum:"show,approve,revert" default:"show" help:"show, approve or revert (default show)"`
+}
+
+type verifyCommand struct {
+	FolderID  string `arg:""`
+	SpotCheck int    `help:"Number of identical files per device to request a block of and check, at most 100"`
+}
+
 type operationCommand struct {
 	Restart        struct{}              `cmd:"" help:"Restart syncthing"`
 	Shutdown       struct{}              `cmd:"" help:"Shutdown syncthing"`
 	Upgrade        struct{}              `cmd:"" help:"Upgrade syncthing (if a newer version is available)"`
 	FolderOverride folderOverrideCommand `cmd:"" help:"Override changes on folder (remote for sendonly, local for receiveonly). WARNING: Destructive - deletes/changes your data"`
 	DefaultIgnores defaultIgnoresCommand `cmd:"" help:"Set the default ignores (config) from a file"`
+	MassChange     massChangeCommand     `cmd:"" help:"Show, approve or revert changes held on a folder for exceeding its mass change limit or looking like ransomware"`
+	Verify         verifyCommand         `cmd:"" help:"Compare a folder with what the other devices have, listing divergent, missing and extra files"`
 }
 
 func (*operationCommand) Run(ctx Context, kongCtx *kong.Context) error {
@@ -107,3 +121,20 @@ func (d *defaultIgnoresCommand) Run(ctx Context) error {
 	_, err = client.PutJSON("config/defaults/ignores", config.Ignores{Lines: lines})
 	return err
 }
+
+func (m *massChangeCommand) Run(ctx Context) error {
+	qs := url.Values{"folder": {m.FolderID}}
+	if m.Action == "show" {
+		return indexDumpOutput("db/masschange?"+qs.Encode(), ctx.clientFactory)
+	}
+	qs.Set("action", m.Action)
+	return emptyPost("db/masschange?"+qs.Encode(), ctx.clientFactory)
+}
+
+func (v *verifyCommand) Run(ctx Context) error {
+	qs := url.Values{"folder": {v.FolderID}}
+	if v.SpotCheck > 0 {
+		qs.Set("spotcheck", strconv.Itoa(v.SpotCheck))
+	}
+	return indexDumpOutput("folder/verify?"+qs.Encode(), ctx.clientFactory)
+}
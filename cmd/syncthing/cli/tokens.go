@@ -0,0 +1,64 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package cli
+
+import (
+	"net/url"
+	"time"
+)
+
+type tokensCommand struct {
+	List   tokenListCommand   `cmd:"" help:"List API tokens"`
+	Create tokenCreateCommand `cmd:"" help:"Create an API token and print it; the token can't be shown again"`
+	Revoke tokenRevokeCommand `cmd:"" help:"Revoke an API token"`
+}
+
+type tokenListCommand struct{}
+
+func (*tokenListCommand) Run(ctx Context) error {
+	return indexDumpOutput("config/gui/tokens", ctx.clientFactory)
+}
+
+type tokenCreateCommand struct {
+	Name    string        `arg:"" help:"Name of the token"`
+	Scope   string        `enum:"admin,operator,read,events" default:"read" help:"Scope of the token (admin, operator, read, events)"`
+	Folder  []string      `help:"Limit the token to the given folder ID (may be repeated)"`
+	Expires time.Duration `help:"Expire the token after the given duration, e.g. 720h (default never)"`
+}
+
+func (c *tokenCreateCommand) Run(ctx Context) error {
+	client, err := ctx.clientFactory.getClient()
+	if err != nil {
+		return err
+	}
+	req := map[string]any{
+		"name":    c.Name,
+		"scope":   c.Scope,
+		"folders": c.Folder,
+	}
+	if c.Expires > 0 {
+		req["expires"] = time.Now().Add(c.Expires)
+	}
+	response, err := client.PostJSON("config/gui/tokens", req)
+	if err != nil {
+		return err
+	}
+	return prettyPrintResponse(response)
+}
+
+type tokenRevokeCommand struct {
+	Name string `arg:"" help:"Name of the token"`
+}
+
+func (c *tokenRevokeCommand) Run(ctx Context) error {
+	client, err := ctx.clientFactory.getClient()
+	if err != nil {
+		return err
+	}
+	_, err = client.Delete("config/gui/tokens/" + url.PathEscape(c.Name))
+	return err
+}
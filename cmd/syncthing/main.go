@@ -186,6 +186,7 @@ type serveCmd struct {
 	// Internal options, not shown to users
 	InternalRestarting   bool `env:"STRESTART" hidden:"1"`
 	InternalInnerProcess bool `env:"STMONITORED" hidden:"1"`
+	InternalUpgradeCheck bool `env:"STUPGRADECHECK" hidden:"1"`
 }
 
 func defaultVars() kong.Vars {
@@ -357,13 +358,22 @@ func (e *errNoUpgrade) Error() string {
 	return fmt.Sprintf("no upgrade available (current %q >= latest %q).", e.current, e.latest)
 }
 
-func checkUpgrade() (upgrade.Release, error) {
+// upgradeChannel returns the upgrade channel of the current configuration.
+func upgradeChannel() (upgrade.Channel, error) {
 	cfg, err := loadOrDefaultConfig()
 	if err != nil {
-		return upgrade.Release{}, err
+		return upgrade.Channel{}, err
 	}
 	opts := cfg.Options()
-	release, err := upgrade.LatestRelease(opts.ReleasesURL, build.Version, opts.UpgradeToPreReleases)
+	return upgrade.NewChannel(opts.ReleaseChannel, opts.ReleasesURL, opts.UpgradeToPreReleases, opts.ReleaseSigningKeys), nil
+}
+
+func checkUpgrade() (upgrade.Release, error) {
+	ch, err := upgradeChannel()
+	if err != nil {
+		return upgrade.Release{}, err
+	}
+	release, err := upgrade.LatestRelease(ch, build.Version)
 	if err != nil {
 		return upgrade.Release{}, err
 	}
@@ -524,13 +534,14 @@ func (c *serveCmd) syncthingMain() {
 	if autoUpgradePossible && cfgWrapper.Options().AutoUpgradeEnabled() {
 		// try to do upgrade directly and log the error if relevant.
 		miscDB := db.NewMiscDB(sdb)
-		release, err := initialAutoUpgradeCheck(miscDB)
+		release, err := initialAutoUpgradeCheck(cfgWrapper, miscDB)
 		if err == nil {
-			err = upgrade.To(release)
+			opts := cfgWrapper.Options()
+			err = upgrade.To(release, upgrade.NewChannel(opts.ReleaseChannel, opts.ReleasesURL, opts.UpgradeToPreReleases, opts.ReleaseSigningKeys))
 		}
 		if err != nil {
 			var noUpgradeErr *errNoUpgrade
-			if errors.As(err, &noUpgradeErr) || errors.Is(err, errTooEarlyUpgradeCheck) || errors.Is(err, errTooEarlyUpgrade) {
+			if errors.As(err, &noUpgradeErr) || errors.Is(err, errTooEarlyUpgradeCheck) || errors.Is(err, errTooEarlyUpgrade) || isRolloutHold(err) {
 				slog.Debug("Initial automatic upgrade", slogutil.Error(err))
 			} else {
 				slog.Info("Initial automatic upgrade", slogutil.Error(err))
@@ -565,7 +576,7 @@ func (c *serveCmd) syncthingMain() {
 			auditFile = c.AuditFile
 		}
 
-		appOpts.AuditWriter = auditWriter(auditFile)
+		appOpts.AuditWriter = auditWriter(auditFile, cfgWrapper.Options())
 	}
 
 	app, err := syncthing.New(cfgWrapper, sdb, evLogger, cert, appOpts)
@@ -596,6 +607,13 @@ func (c *serveCmd) syncthingMain() {
 		os.Exit(svcutil.ExitError.AsInt())
 	}
 
+	if c.InternalInnerProcess {
+		// Startup is complete once Start returns.
+		if err := markStartupComplete(); err != nil {
+			slog.Warn("Failed to record startup for the monitor process", slogutil.Error(err))
+		}
+	}
+
 	cleanConfigDirectory()
 
 	if cfgWrapper.Options().StartBrowser && !c.NoBrowser && !c.InternalRestarting {
@@ -654,7 +672,7 @@ func loadOrDefaultConfig() (config.Wrapper, error) {
 	return cfg, err
 }
 
-func auditWriter(auditFile string) io.Writer {
+func auditWriter(auditFile string, opts config.OptionsConfiguration) io.Writer {
 	var fd io.Writer
 	var err error
 	var auditDest string
@@ -674,7 +692,14 @@ func auditWriter(auditFile string) io.Writer {
 		} else {
 			auditFlags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
 		}
-		fd, err = os.OpenFile(auditFile, auditFlags, 0o600)
+		open := func(name string) (io.WriteCloser, error) {
+			return os.OpenFile(name, auditFlags, 0o600)
+		}
+		if opts.AuditMaxSizeKiB > 0 || opts.AuditMaxAgeH > 0 {
+			fd, err = newRotatedFile(auditFile, open, int64(opts.AuditMaxSizeKiB)*1024, time.Duration(opts.AuditMaxAgeH)*time.Hour, opts.AuditMaxFiles)
+		} else {
+			fd, err = open(auditFile)
+		}
 		if err != nil {
 			slog.Error("Failed to open audit file", slogutil.Error(err))
 			os.Exit(svcutil.ExitError.AsInt())
@@ -721,7 +746,8 @@ func autoUpgrade(cfg config.Wrapper, app *syncthing.App, evLogger events.Logger)
 		}
 
 		checkInterval := time.Duration(opts.AutoUpgradeIntervalH) * time.Hour
-		rel, err := upgrade.LatestRelease(opts.ReleasesURL, build.Version, opts.UpgradeToPreReleases)
+		ch := upgrade.NewChannel(opts.ReleaseChannel, opts.ReleasesURL, opts.UpgradeToPreReleases, opts.ReleaseSigningKeys)
+		rel, err := upgrade.LatestRelease(ch, build.Version)
 		if errors.Is(err, upgrade.ErrUpgradeUnsupported) {
 			sub.Unsubscribe()
 			return
@@ -740,8 +766,14 @@ func autoUpgrade(cfg config.Wrapper, app *syncthing.App, evLogger events.Logger)
 			continue
 		}
 
+		if err := autoUpgradeAllowed(rel, cfg.MyID(), opts, rolledBackVersion(), time.Now()); err != nil {
+			slog.Debug("Automatic upgrade held back", slog.String("latest", rel.Tag), slogutil.Error(err))
+			timer.Reset(checkInterval)
+			continue
+		}
+
 		slog.Info("Automatic upgrade", "current", build.Version, "latest", rel.Tag)
-		err = upgrade.To(rel)
+		err = upgrade.To(rel, ch)
 		if err != nil {
 			slog.Error("Automatic upgrade failed", slogutil.Error(err))
 			timer.Reset(checkInterval)
@@ -755,7 +787,7 @@ func autoUpgrade(cfg config.Wrapper, app *syncthing.App, evLogger events.Logger)
 	}
 }
 
-func initialAutoUpgradeCheck(misc *db.Typed) (upgrade.Release, error) {
+func initialAutoUpgradeCheck(cfg config.Wrapper, misc *db.Typed) (upgrade.Release, error) {
 	if last, ok, err := misc.Time(upgradeCheckKey); err == nil && ok && time.Since(last) < upgradeCheckInterval {
 		return upgrade.Release{}, errTooEarlyUpgradeCheck
 	}
@@ -767,6 +799,9 @@ func initialAutoUpgradeCheck(misc *db.Typed) (upgrade.Release, error) {
 	if upgrade.CompareVersions(release.Tag, build.Version) == upgrade.MajorNewer {
 		return upgrade.Release{}, errors.New("higher major version")
 	}
+	if err := autoUpgradeAllowed(release, cfg.MyID(), cfg.Options(), rolledBackVersion(), time.Now()); err != nil {
+		return upgrade.Release{}, err
+	}
 
 	if lastVersion, ok, err := misc.String(upgradeVersionKey); err == nil && ok && lastVersion == release.Tag {
 		// Only check time if we try to upgrade to the same release.
@@ -889,8 +924,14 @@ func (u upgradeCmd) Run() error {
 		return nil
 	}
 
+	ch, err := upgradeChannel()
+	if err != nil {
+		slog.Error("Failed to load configuration", slogutil.Error(err))
+		os.Exit(svcutil.ExitError.AsInt())
+	}
+
 	if u.From != "" {
-		err := upgrade.ToURL(u.From)
+		err := upgrade.ToURL(u.From, ch)
 		if err != nil {
 			slog.Error("Failed to upgrade", slogutil.Error(err))
 			os.Exit(svcutil.ExitError.AsInt())
@@ -914,7 +955,7 @@ func (u upgradeCmd) Run() error {
 		case locked:
 			err = upgradeViaRest()
 		default:
-			err = upgrade.To(release)
+			err = upgrade.To(release, ch)
 		}
 	}
 	if err != nil {
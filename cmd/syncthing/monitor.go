@@ -60,7 +60,7 @@ func (c *serveCmd) monitorMain() {
 			return newAutoclosedFile(name, logFileAutoCloseDelay, logFileMaxOpenTime)
 		}
 		if c.LogMaxSize > 0 {
-			fileDst, err = newRotatedFile(logFile, open, int64(c.LogMaxSize), c.LogMaxFiles)
+			fileDst, err = newRotatedFile(logFile, open, int64(c.LogMaxSize), 0, c.LogMaxFiles)
 		} else {
 			fileDst, err = open(logFile)
 		}
@@ -97,6 +97,14 @@ func (c *serveCmd) monitorMain() {
 	sigHup := syscall.Signal(1)
 	signal.Notify(restartSign, sigHup)
 
+	var healthCheck *upgradeHealthCheck
+	if c.InternalUpgradeCheck {
+		// We were restarted after an upgrade. Make sure the new version
+		// starts up properly, or go back to the previous one.
+		os.Unsetenv("STUPGRADECHECK")
+		healthCheck = newMonitorHealthCheck(binary)
+	}
+
 	childEnv := childEnv()
 	first := true
 	for {
@@ -156,20 +164,44 @@ func (c *serveCmd) monitorMain() {
 			exit <- cmd.Wait()
 		}()
 
+		var healthDeadline <-chan time.Time
+		if healthCheck != nil {
+			healthDeadline = time.After(time.Until(healthCheck.deadline))
+		}
+
 		stopped := false
-		select {
-		case s := <-stopSign:
-			slog.Info("Received signal; exiting", "signal", s)
-			cmd.Process.Signal(sigTerm)
-			err = <-exit
-			stopped = true
-
-		case s := <-restartSign:
-			slog.Info("Received signal; restarting", "signal", s)
-			cmd.Process.Signal(sigHup)
-			err = <-exit
-
-		case err = <-exit:
+	wait:
+		for {
+			select {
+			case s := <-stopSign:
+				slog.Info("Received signal; exiting", "signal", s)
+				cmd.Process.Signal(sigTerm)
+				err = <-exit
+				stopped = true
+
+			case s := <-restartSign:
+				slog.Info("Received signal; restarting", "signal", s)
+				cmd.Process.Signal(sigHup)
+				err = <-exit
+
+			case <-healthDeadline:
+				if healthCheck.passed() {
+					slog.Info("Upgrade health check passed", "version", healthCheck.version)
+					healthCheck = nil
+					healthDeadline = nil
+					continue
+				}
+				slog.Error("Upgraded Syncthing did not complete startup in time; rolling back", "version", healthCheck.version)
+				cmd.Process.Signal(sigTerm)
+				<-exit
+				rollbackUpgrade(healthCheck, binary, args)
+				// We failed to roll back, so carry on with the new binary.
+				healthCheck = nil
+				err = errors.New("upgrade health check failed")
+
+			case err = <-exit:
+			}
+			break wait
 		}
 
 		if err == nil {
@@ -183,9 +215,19 @@ func (c *serveCmd) monitorMain() {
 			if stopped || c.NoRestart {
 				os.Exit(exitCode)
 			}
+			if healthCheck != nil && exitCode != svcutil.ExitRestart.AsInt() && exitCode != svcutil.ExitUpgrade.AsInt() {
+				// The upgraded binary crashed or exited before passing the
+				// health check.
+				slog.Error("Upgraded Syncthing exited during the health check; rolling back", "version", healthCheck.version, slogutil.Error(err))
+				rollbackUpgrade(healthCheck, binary, args)
+				healthCheck = nil
+			}
 			if exitCode == svcutil.ExitUpgrade.AsInt() {
 				// Restart the monitor process to release the .old
-				// binary as part of the upgrade process.
+				// binary as part of the upgrade process. The new
+				// monitor checks that the upgraded binary starts up
+				// properly.
+				os.Setenv("STUPGRADECHECK", "yes")
 				slog.Info("Restarting monitor...")
 				if err = restartMonitor(binary, args); err != nil {
 					slog.Error("Failed to restart monitor", slogutil.Error(err))
@@ -210,6 +252,36 @@ func (c *serveCmd) monitorMain() {
 	}
 }
 
+// newMonitorHealthCheck returns the health check to perform after an
+// upgrade, or nil if it's disabled in the config.
+func newMonitorHealthCheck(binary string) *upgradeHealthCheck {
+	cfg, err := loadOrDefaultConfig()
+	if err != nil {
+		return nil
+	}
+	timeout := time.Duration(cfg.Options().AutoUpgradeHealthCheckS) * time.Second
+	if timeout <= 0 {
+		return nil
+	}
+	slog.Info("Checking health of upgraded Syncthing", "version", build.Version, "timeout", timeout)
+	return newUpgradeHealthCheck(binary, timeout)
+}
+
+// rollbackUpgrade restores the previous binary and restarts the monitor
+// using it. It only returns if we fail to roll back, in which case we
+// carry on with the new binary.
+func rollbackUpgrade(healthCheck *upgradeHealthCheck, binary string, args []string) {
+	if err := healthCheck.rollback(); err != nil {
+		slog.Error("Failed to roll back upgrade", slogutil.Error(err))
+		return
+	}
+	slog.Info("Rolled back upgrade; restarting monitor...", "failedVersion", healthCheck.version)
+	if err := restartMonitor(binary, args); err != nil {
+		slog.Error("Failed to restart monitor", slogutil.Error(err))
+	}
+	os.Exit(svcutil.ExitUpgrade.AsInt())
+}
+
 func getBinary(args0 string) (string, error) {
 	e, err := os.Executable()
 	if err == nil {
@@ -327,19 +399,22 @@ func restartMonitorWindows(binary string, args []string) error {
 }
 
 // rotatedFile keeps a set of rotating logs. There will be the base file plus up
-// to maxFiles rotated ones, each ~ maxSize bytes large.
+// to maxFiles rotated ones, each ~ maxSize bytes large and at most maxAge
+// old. A zero maxSize or maxAge means no limit.
 type rotatedFile struct {
 	name        string
 	create      createFn
 	maxSize     int64 // bytes
+	maxAge      time.Duration
 	maxFiles    int
 	currentFile io.WriteCloser
 	currentSize int64
+	opened      time.Time
 }
 
 type createFn func(name string) (io.WriteCloser, error)
 
-func newRotatedFile(name string, create createFn, maxSize int64, maxFiles int) (*rotatedFile, error) {
+func newRotatedFile(name string, create createFn, maxSize int64, maxAge time.Duration, maxFiles int) (*rotatedFile, error) {
 	var size int64
 	if info, err := os.Lstat(name); err != nil {
 		if !os.IsNotExist(err) {
@@ -357,16 +432,20 @@ func newRotatedFile(name string, create createFn, maxSize int64, maxFiles int) (
 		name:        name,
 		create:      create,
 		maxSize:     maxSize,
+		maxAge:      maxAge,
 		maxFiles:    maxFiles,
 		currentFile: writer,
 		currentSize: size,
+		opened:      time.Now(),
 	}, nil
 }
 
 func (r *rotatedFile) Write(bs []byte) (int, error) {
-	// Check if we're about to exceed the max size, and if so close this
-	// file so we'll start on a new one.
-	if r.currentSize+int64(len(bs)) > r.maxSize {
+	// Check if we're about to exceed the max size or age, and if so close
+	// this file so we'll start on a new one.
+	tooLarge := r.maxSize > 0 && r.currentSize+int64(len(bs)) > r.maxSize
+	tooOld := r.maxAge > 0 && time.Since(r.opened) > r.maxAge
+	if tooLarge || tooOld {
 		r.currentFile.Close()
 		r.currentSize = 0
 		r.rotate()
@@ -375,6 +454,7 @@ func (r *rotatedFile) Write(bs []byte) (int, error) {
 			return 0, err
 		}
 		r.currentFile = f
+		r.opened = time.Now()
 	}
 
 	n, err := r.currentFile.Write(bs)
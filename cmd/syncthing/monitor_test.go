@@ -35,7 +35,7 @@ func TestRotatedFile(t *testing.T) {
 	maxSize := int64(len(testData) + len(testData)/2)
 
 	// We allow the log file plus two rotated copies.
-	rf, err := newRotatedFile(logName, open, maxSize, 2)
+	rf, err := newRotatedFile(logName, open, maxSize, 0, 2)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -84,6 +84,47 @@ func TestRotatedFile(t *testing.T) {
 	checkNotExist(t, numberedFile(logName, 2)) // exceeds maxFiles so deleted
 }
 
+func TestRotatedFileAge(t *testing.T) {
+	dir := t.TempDir()
+
+	open := func(name string) (io.WriteCloser, error) {
+		f, err := os.Create(name)
+		t.Cleanup(func() {
+			if f != nil {
+				_ = f.Close()
+			}
+		})
+
+		return f, err
+	}
+
+	logName := filepath.Join(dir, "audit.log")
+	testData := []byte("12345678\n")
+
+	// No size limit, but a day of age.
+	rf, err := newRotatedFile(logName, open, 0, 24*time.Hour, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes go to the same file while it's young.
+	for i := 0; i < 3; i++ {
+		if _, err := rf.Write(testData); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkSize(t, logName, 3*len(testData))
+	checkNotExist(t, numberedFile(logName, 0))
+
+	// Once it's old, it's rotated.
+	rf.opened = time.Now().Add(-25 * time.Hour)
+	if _, err := rf.Write(testData); err != nil {
+		t.Fatal(err)
+	}
+	checkSize(t, logName, len(testData))
+	checkSize(t, numberedFile(logName, 0), 3*len(testData))
+}
+
 func TestNumberedFile(t *testing.T) {
 	// Mostly just illustrates where the number ends up and makes sure it
 	// doesn't crash without an extension.
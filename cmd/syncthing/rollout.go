@@ -0,0 +1,139 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/build"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/locations"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/upgrade"
+)
+
+var (
+	errNotInRollout = errors.New("this device is not yet part of the rollout of the release")
+	errRolledBack   = errors.New("the release was previously rolled back on this device")
+)
+
+type errReleaseTooNew struct {
+	tag string
+	age time.Duration
+}
+
+func (e *errReleaseTooNew) Error() string {
+	return fmt.Sprintf("release %s is too new for automatic upgrade (published %v ago)", e.tag, e.age.Truncate(time.Minute))
+}
+
+// isRolloutHold returns true for the errors that are expected when the
+// rollout settings hold back an upgrade.
+func isRolloutHold(err error) bool {
+	var tooNew *errReleaseTooNew
+	return errors.Is(err, errNotInRollout) || errors.Is(err, errRolledBack) || errors.As(err, &tooNew)
+}
+
+// autoUpgradeAllowed returns an error if the rollout settings hold back the
+// automatic upgrade of this device to the given release. A release is held
+// back when it was rolled back on this device before, when it's younger
+// than the minimum release age, and when the device isn't within the
+// rollout percentage for the release.
+func autoUpgradeAllowed(rel upgrade.Release, myID protocol.DeviceID, opts config.OptionsConfiguration, rolledBack string, now time.Time) error {
+	if rel.Tag == rolledBack {
+		return errRolledBack
+	}
+	if minAge := time.Duration(opts.AutoUpgradeMinReleaseAgeH) * time.Hour; minAge > 0 && !rel.PublishedAt.IsZero() {
+		// Releases without a publication time can't be held back on age.
+		if age := now.Sub(rel.PublishedAt); age < minAge {
+			return &errReleaseTooNew{tag: rel.Tag, age: age}
+		}
+	}
+	if rolloutBucket(myID, rel.Tag) >= opts.AutoUpgradeRolloutPct {
+		return errNotInRollout
+	}
+	return nil
+}
+
+// rolloutBucket returns a number between 0 and 99 for the device and
+// release. A release rolled out to N percent of devices is installed on the
+// devices with a bucket lower than N. Including the release in the hash
+// means it's not always the same devices that go first.
+func rolloutBucket(id protocol.DeviceID, tag string) int {
+	h := sha256.New()
+	h.Write(id[:])
+	h.Write([]byte(tag))
+	return int(binary.BigEndian.Uint64(h.Sum(nil)) % 100)
+}
+
+// rolledBackVersion returns the version that was last rolled back on this
+// device, if any.
+func rolledBackVersion() string {
+	bs, err := os.ReadFile(locations.Get(locations.UpgradeRollback))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(bs))
+}
+
+// markStartupComplete lets the monitor process know that we've started up
+// properly, for the health check after an upgrade.
+func markStartupComplete() error {
+	return os.WriteFile(locations.Get(locations.UpgradeHealth), []byte(build.Version+"\n"), 0o644)
+}
+
+// An upgradeHealthCheck is performed by the monitor process after an
+// upgrade. The upgraded binary must reach startup complete within the
+// deadline, without crashing, otherwise we roll back to the previous
+// binary.
+type upgradeHealthCheck struct {
+	binary   string // the upgraded binary, with the previous one next to it as binary.old
+	version  string // the upgraded version
+	deadline time.Time
+}
+
+func newUpgradeHealthCheck(binary string, timeout time.Duration) *upgradeHealthCheck {
+	// Forget about any earlier startups.
+	_ = os.Remove(locations.Get(locations.UpgradeHealth))
+	return &upgradeHealthCheck{
+		binary:   binary,
+		version:  build.Version,
+		deadline: time.Now().Add(timeout),
+	}
+}
+
+// passed returns true when the upgraded binary has reached startup
+// complete.
+func (h *upgradeHealthCheck) passed() bool {
+	bs, err := os.ReadFile(locations.Get(locations.UpgradeHealth))
+	return err == nil && strings.TrimSpace(string(bs)) == h.version
+}
+
+// rollback moves the previous binary back into place and remembers the
+// version that failed, so that we don't automatically upgrade to it again.
+// The failed binary is kept as binary.failed.
+func (h *upgradeHealthCheck) rollback() error {
+	old := h.binary + ".old"
+	if _, err := os.Stat(old); err != nil {
+		return fmt.Errorf("no previous binary: %w", err)
+	}
+	failed := h.binary + ".failed"
+	_ = os.Remove(failed)
+	if err := os.Rename(h.binary, failed); err != nil {
+		return err
+	}
+	if err := os.Rename(old, h.binary); err != nil {
+		_ = os.Rename(failed, h.binary)
+		return err
+	}
+	return os.WriteFile(locations.Get(locations.UpgradeRollback), []byte(h.version+"\n"), 0o644)
+}
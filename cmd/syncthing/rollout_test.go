@@ -0,0 +1,107 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/build"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/locations"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/upgrade"
+)
+
+func TestAutoUpgradeAllowed(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	rel := upgrade.Release{Tag: "v2.0.1", PublishedAt: now.Add(-10 * time.Hour)}
+	opts := config.OptionsConfiguration{AutoUpgradeRolloutPct: 100}
+
+	if err := autoUpgradeAllowed(rel, protocol.LocalDeviceID, opts, "", now); err != nil {
+		t.Error("unexpected hold:", err)
+	}
+	if err := autoUpgradeAllowed(rel, protocol.LocalDeviceID, opts, "v2.0.1", now); !errors.Is(err, errRolledBack) {
+		t.Error("expected rolled back release to be held, got", err)
+	}
+
+	opts.AutoUpgradeMinReleaseAgeH = 24
+	if err := autoUpgradeAllowed(rel, protocol.LocalDeviceID, opts, "", now); !isRolloutHold(err) {
+		t.Error("expected new release to be held, got", err)
+	}
+	if err := autoUpgradeAllowed(rel, protocol.LocalDeviceID, opts, "", now.Add(14*time.Hour)); err != nil {
+		t.Error("unexpected hold of old enough release:", err)
+	}
+
+	// About half of the devices are in a 50% rollout, none in a 0% one.
+	opts.AutoUpgradeMinReleaseAgeH = 0
+	opts.AutoUpgradeRolloutPct = 50
+	in := 0
+	for i := range 1000 {
+		var id protocol.DeviceID
+		id[0], id[1] = byte(i), byte(i>>8)
+		if err := autoUpgradeAllowed(rel, id, opts, "", now); err == nil {
+			in++
+		} else if !errors.Is(err, errNotInRollout) {
+			t.Fatal(err)
+		}
+	}
+	if in < 400 || in > 600 {
+		t.Errorf("expected about half of the devices in the rollout, got %d of 1000", in)
+	}
+	opts.AutoUpgradeRolloutPct = 0
+	if err := autoUpgradeAllowed(rel, protocol.LocalDeviceID, opts, "", now); !errors.Is(err, errNotInRollout) {
+		t.Error("expected device to be outside the rollout, got", err)
+	}
+}
+
+func TestUpgradeHealthCheckRollback(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := locations.SetBaseDir(locations.DataBaseDir, tmpDir); err != nil {
+		t.Fatal(err)
+	}
+
+	binary := filepath.Join(tmpDir, "syncthing")
+	if err := os.WriteFile(binary, []byte("new"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binary+".old", []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	check := newUpgradeHealthCheck(binary, time.Minute)
+	if check.passed() {
+		t.Fatal("check should not pass before startup")
+	}
+	if err := markStartupComplete(); err != nil {
+		t.Fatal(err)
+	}
+	if !check.passed() {
+		t.Fatal("check should pass after startup")
+	}
+
+	if err := check.rollback(); err != nil {
+		t.Fatal(err)
+	}
+	if bs, _ := os.ReadFile(binary); string(bs) != "old" {
+		t.Errorf("previous binary not restored, got %q", bs)
+	}
+	if bs, _ := os.ReadFile(binary + ".failed"); string(bs) != "new" {
+		t.Errorf("failed binary not kept, got %q", bs)
+	}
+	if v := rolledBackVersion(); v != build.Version {
+		t.Errorf("rolled back version %q, expected %q", v, build.Version)
+	}
+
+	// There's nothing more to roll back to.
+	if err := check.rollback(); err == nil {
+		t.Error("expected second rollback to fail")
+	}
+}
@@ -580,7 +580,7 @@ angular.module('syncthing.core')
             // This function should match IsAuthEnabled() in guiconfiguration.go
             var guiCfg = $scope.config && $scope.config.gui;
             if (guiCfg) {
-                return guiCfg.authMode === 'ldap' || (guiCfg.user && guiCfg.password);
+                return guiCfg.authMode === 'ldap' || guiCfg.authMode === 'oidc' || (guiCfg.user && guiCfg.password) || (guiCfg.accounts && guiCfg.accounts.length > 0) || (guiCfg.clientCertMode && guiCfg.clientCertMode !== 'off');
             }
             return false;
         };
@@ -602,7 +602,7 @@ angular.module('syncthing.core')
                 && !$scope.isAuthEnabled()
                 && !guiCfg.insecureAdminAccess;
 
-            if ((guiCfg.user && guiCfg.password) || guiCfg.authMode === 'ldap') {
+            if ((guiCfg.user && guiCfg.password) || guiCfg.authMode === 'ldap' || guiCfg.authMode === 'oidc') {
                 $scope.dismissNotification('authenticationUserAndPassword');
             }
         }
@@ -0,0 +1,510 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: controlproto/control.proto
+
+package controlprotoconnect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	controlproto "github.com/syncthing/syncthing/internal/gen/controlproto"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// ControlServiceName is the fully-qualified name of the ControlService service.
+	ControlServiceName = "controlproto.ControlService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// ControlServiceListFoldersProcedure is the fully-qualified name of the ControlService's
+	// ListFolders RPC.
+	ControlServiceListFoldersProcedure = "/controlproto.ControlService/ListFolders"
+	// ControlServiceGetFolderProcedure is the fully-qualified name of the ControlService's GetFolder
+	// RPC.
+	ControlServiceGetFolderProcedure = "/controlproto.ControlService/GetFolder"
+	// ControlServicePutFolderProcedure is the fully-qualified name of the ControlService's PutFolder
+	// RPC.
+	ControlServicePutFolderProcedure = "/controlproto.ControlService/PutFolder"
+	// ControlServiceDeleteFolderProcedure is the fully-qualified name of the ControlService's
+	// DeleteFolder RPC.
+	ControlServiceDeleteFolderProcedure = "/controlproto.ControlService/DeleteFolder"
+	// ControlServiceGetFolderStatusProcedure is the fully-qualified name of the ControlService's
+	// GetFolderStatus RPC.
+	ControlServiceGetFolderStatusProcedure = "/controlproto.ControlService/GetFolderStatus"
+	// ControlServiceListDevicesProcedure is the fully-qualified name of the ControlService's
+	// ListDevices RPC.
+	ControlServiceListDevicesProcedure = "/controlproto.ControlService/ListDevices"
+	// ControlServiceGetDeviceProcedure is the fully-qualified name of the ControlService's GetDevice
+	// RPC.
+	ControlServiceGetDeviceProcedure = "/controlproto.ControlService/GetDevice"
+	// ControlServicePutDeviceProcedure is the fully-qualified name of the ControlService's PutDevice
+	// RPC.
+	ControlServicePutDeviceProcedure = "/controlproto.ControlService/PutDevice"
+	// ControlServiceDeleteDeviceProcedure is the fully-qualified name of the ControlService's
+	// DeleteDevice RPC.
+	ControlServiceDeleteDeviceProcedure = "/controlproto.ControlService/DeleteDevice"
+	// ControlServiceGetDeviceStatusProcedure is the fully-qualified name of the ControlService's
+	// GetDeviceStatus RPC.
+	ControlServiceGetDeviceStatusProcedure = "/controlproto.ControlService/GetDeviceStatus"
+	// ControlServiceScanProcedure is the fully-qualified name of the ControlService's Scan RPC.
+	ControlServiceScanProcedure = "/controlproto.ControlService/Scan"
+	// ControlServiceBrowseProcedure is the fully-qualified name of the ControlService's Browse RPC.
+	ControlServiceBrowseProcedure = "/controlproto.ControlService/Browse"
+	// ControlServiceNeedProcedure is the fully-qualified name of the ControlService's Need RPC.
+	ControlServiceNeedProcedure = "/controlproto.ControlService/Need"
+	// ControlServiceStreamEventsProcedure is the fully-qualified name of the ControlService's
+	// StreamEvents RPC.
+	ControlServiceStreamEventsProcedure = "/controlproto.ControlService/StreamEvents"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	controlServiceServiceDescriptor               = controlproto.File_controlproto_control_proto.Services().ByName("ControlService")
+	controlServiceListFoldersMethodDescriptor     = controlServiceServiceDescriptor.Methods().ByName("ListFolders")
+	controlServiceGetFolderMethodDescriptor       = controlServiceServiceDescriptor.Methods().ByName("GetFolder")
+	controlServicePutFolderMethodDescriptor       = controlServiceServiceDescriptor.Methods().ByName("PutFolder")
+	controlServiceDeleteFolderMethodDescriptor    = controlServiceServiceDescriptor.Methods().ByName("DeleteFolder")
+	controlServiceGetFolderStatusMethodDescriptor = controlServiceServiceDescriptor.Methods().ByName("GetFolderStatus")
+	controlServiceListDevicesMethodDescriptor     = controlServiceServiceDescriptor.Methods().ByName("ListDevices")
+	controlServiceGetDeviceMethodDescriptor       = controlServiceServiceDescriptor.Methods().ByName("GetDevice")
+	controlServicePutDeviceMethodDescriptor       = controlServiceServiceDescriptor.Methods().ByName("PutDevice")
+	controlServiceDeleteDeviceMethodDescriptor    = controlServiceServiceDescriptor.Methods().ByName("DeleteDevice")
+	controlServiceGetDeviceStatusMethodDescriptor = controlServiceServiceDescriptor.Methods().ByName("GetDeviceStatus")
+	controlServiceScanMethodDescriptor            = controlServiceServiceDescriptor.Methods().ByName("Scan")
+	controlServiceBrowseMethodDescriptor          = controlServiceServiceDescriptor.Methods().ByName("Browse")
+	controlServiceNeedMethodDescriptor            = controlServiceServiceDescriptor.Methods().ByName("Need")
+	controlServiceStreamEventsMethodDescriptor    = controlServiceServiceDescriptor.Methods().ByName("StreamEvents")
+)
+
+// ControlServiceClient is a client for the controlproto.ControlService service.
+type ControlServiceClient interface {
+	ListFolders(context.Context, *connect.Request[controlproto.ListFoldersRequest]) (*connect.Response[controlproto.ListFoldersResponse], error)
+	GetFolder(context.Context, *connect.Request[controlproto.GetFolderRequest]) (*connect.Response[controlproto.FolderConfig], error)
+	// PutFolder creates or updates a folder. Settings not in FolderConfig
+	// keep their current or default values.
+	PutFolder(context.Context, *connect.Request[controlproto.PutFolderRequest]) (*connect.Response[controlproto.FolderConfig], error)
+	DeleteFolder(context.Context, *connect.Request[controlproto.DeleteFolderRequest]) (*connect.Response[controlproto.DeleteFolderResponse], error)
+	GetFolderStatus(context.Context, *connect.Request[controlproto.GetFolderStatusRequest]) (*connect.Response[controlproto.FolderStatus], error)
+	ListDevices(context.Context, *connect.Request[controlproto.ListDevicesRequest]) (*connect.Response[controlproto.ListDevicesResponse], error)
+	GetDevice(context.Context, *connect.Request[controlproto.GetDeviceRequest]) (*connect.Response[controlproto.DeviceConfig], error)
+	// PutDevice creates or updates a device, like PutFolder.
+	PutDevice(context.Context, *connect.Request[controlproto.PutDeviceRequest]) (*connect.Response[controlproto.DeviceConfig], error)
+	DeleteDevice(context.Context, *connect.Request[controlproto.DeleteDeviceRequest]) (*connect.Response[controlproto.DeleteDeviceResponse], error)
+	GetDeviceStatus(context.Context, *connect.Request[controlproto.GetDeviceStatusRequest]) (*connect.Response[controlproto.DeviceStatus], error)
+	Scan(context.Context, *connect.Request[controlproto.ScanRequest]) (*connect.Response[controlproto.ScanResponse], error)
+	Browse(context.Context, *connect.Request[controlproto.BrowseRequest]) (*connect.Response[controlproto.BrowseResponse], error)
+	Need(context.Context, *connect.Request[controlproto.NeedRequest]) (*connect.Response[controlproto.NeedResponse], error)
+	// StreamEvents streams events as they happen, starting after the given
+	// event ID, with a gap marker when events were lost in between.
+	StreamEvents(context.Context, *connect.Request[controlproto.StreamEventsRequest]) (*connect.ServerStreamForClient[controlproto.StreamEventsResponse], error)
+}
+
+// NewControlServiceClient constructs a client for the controlproto.ControlService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewControlServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) ControlServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &controlServiceClient{
+		listFolders: connect.NewClient[controlproto.ListFoldersRequest, controlproto.ListFoldersResponse](
+			httpClient,
+			baseURL+ControlServiceListFoldersProcedure,
+			connect.WithSchema(controlServiceListFoldersMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getFolder: connect.NewClient[controlproto.GetFolderRequest, controlproto.FolderConfig](
+			httpClient,
+			baseURL+ControlServiceGetFolderProcedure,
+			connect.WithSchema(controlServiceGetFolderMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		putFolder: connect.NewClient[controlproto.PutFolderRequest, controlproto.FolderConfig](
+			httpClient,
+			baseURL+ControlServicePutFolderProcedure,
+			connect.WithSchema(controlServicePutFolderMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		deleteFolder: connect.NewClient[controlproto.DeleteFolderRequest, controlproto.DeleteFolderResponse](
+			httpClient,
+			baseURL+ControlServiceDeleteFolderProcedure,
+			connect.WithSchema(controlServiceDeleteFolderMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getFolderStatus: connect.NewClient[controlproto.GetFolderStatusRequest, controlproto.FolderStatus](
+			httpClient,
+			baseURL+ControlServiceGetFolderStatusProcedure,
+			connect.WithSchema(controlServiceGetFolderStatusMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listDevices: connect.NewClient[controlproto.ListDevicesRequest, controlproto.ListDevicesResponse](
+			httpClient,
+			baseURL+ControlServiceListDevicesProcedure,
+			connect.WithSchema(controlServiceListDevicesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getDevice: connect.NewClient[controlproto.GetDeviceRequest, controlproto.DeviceConfig](
+			httpClient,
+			baseURL+ControlServiceGetDeviceProcedure,
+			connect.WithSchema(controlServiceGetDeviceMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		putDevice: connect.NewClient[controlproto.PutDeviceRequest, controlproto.DeviceConfig](
+			httpClient,
+			baseURL+ControlServicePutDeviceProcedure,
+			connect.WithSchema(controlServicePutDeviceMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		deleteDevice: connect.NewClient[controlproto.DeleteDeviceRequest, controlproto.DeleteDeviceResponse](
+			httpClient,
+			baseURL+ControlServiceDeleteDeviceProcedure,
+			connect.WithSchema(controlServiceDeleteDeviceMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getDeviceStatus: connect.NewClient[controlproto.GetDeviceStatusRequest, controlproto.DeviceStatus](
+			httpClient,
+			baseURL+ControlServiceGetDeviceStatusProcedure,
+			connect.WithSchema(controlServiceGetDeviceStatusMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		scan: connect.NewClient[controlproto.ScanRequest, controlproto.ScanResponse](
+			httpClient,
+			baseURL+ControlServiceScanProcedure,
+			connect.WithSchema(controlServiceScanMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		browse: connect.NewClient[controlproto.BrowseRequest, controlproto.BrowseResponse](
+			httpClient,
+			baseURL+ControlServiceBrowseProcedure,
+			connect.WithSchema(controlServiceBrowseMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		need: connect.NewClient[controlproto.NeedRequest, controlproto.NeedResponse](
+			httpClient,
+			baseURL+ControlServiceNeedProcedure,
+			connect.WithSchema(controlServiceNeedMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		streamEvents: connect.NewClient[controlproto.StreamEventsRequest, controlproto.StreamEventsResponse](
+			httpClient,
+			baseURL+ControlServiceStreamEventsProcedure,
+			connect.WithSchema(controlServiceStreamEventsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// controlServiceClient implements ControlServiceClient.
+type controlServiceClient struct {
+	listFolders     *connect.Client[controlproto.ListFoldersRequest, controlproto.ListFoldersResponse]
+	getFolder       *connect.Client[controlproto.GetFolderRequest, controlproto.FolderConfig]
+	putFolder       *connect.Client[controlproto.PutFolderRequest, controlproto.FolderConfig]
+	deleteFolder    *connect.Client[controlproto.DeleteFolderRequest, controlproto.DeleteFolderResponse]
+	getFolderStatus *connect.Client[controlproto.GetFolderStatusRequest, controlproto.FolderStatus]
+	listDevices     *connect.Client[controlproto.ListDevicesRequest, controlproto.ListDevicesResponse]
+	getDevice       *connect.Client[controlproto.GetDeviceRequest, controlproto.DeviceConfig]
+	putDevice       *connect.Client[controlproto.PutDeviceRequest, controlproto.DeviceConfig]
+	deleteDevice    *connect.Client[controlproto.DeleteDeviceRequest, controlproto.DeleteDeviceResponse]
+	getDeviceStatus *connect.Client[controlproto.GetDeviceStatusRequest, controlproto.DeviceStatus]
+	scan            *connect.Client[controlproto.ScanRequest, controlproto.ScanResponse]
+	browse          *connect.Client[controlproto.BrowseRequest, controlproto.BrowseResponse]
+	need            *connect.Client[controlproto.NeedRequest, controlproto.NeedResponse]
+	streamEvents    *connect.Client[controlproto.StreamEventsRequest, controlproto.StreamEventsResponse]
+}
+
+// ListFolders calls controlproto.ControlService.ListFolders.
+func (c *controlServiceClient) ListFolders(ctx context.Context, req *connect.Request[controlproto.ListFoldersRequest]) (*connect.Response[controlproto.ListFoldersResponse], error) {
+	return c.listFolders.CallUnary(ctx, req)
+}
+
+// GetFolder calls controlproto.ControlService.GetFolder.
+func (c *controlServiceClient) GetFolder(ctx context.Context, req *connect.Request[controlproto.GetFolderRequest]) (*connect.Response[controlproto.FolderConfig], error) {
+	return c.getFolder.CallUnary(ctx, req)
+}
+
+// PutFolder calls controlproto.ControlService.PutFolder.
+func (c *controlServiceClient) PutFolder(ctx context.Context, req *connect.Request[controlproto.PutFolderRequest]) (*connect.Response[controlproto.FolderConfig], error) {
+	return c.putFolder.CallUnary(ctx, req)
+}
+
+// DeleteFolder calls controlproto.ControlService.DeleteFolder.
+func (c *controlServiceClient) DeleteFolder(ctx context.Context, req *connect.Request[controlproto.DeleteFolderRequest]) (*connect.Response[controlproto.DeleteFolderResponse], error) {
+	return c.deleteFolder.CallUnary(ctx, req)
+}
+
+// GetFolderStatus calls controlproto.ControlService.GetFolderStatus.
+func (c *controlServiceClient) GetFolderStatus(ctx context.Context, req *connect.Request[controlproto.GetFolderStatusRequest]) (*connect.Response[controlproto.FolderStatus], error) {
+	return c.getFolderStatus.CallUnary(ctx, req)
+}
+
+// ListDevices calls controlproto.ControlService.ListDevices.
+func (c *controlServiceClient) ListDevices(ctx context.Context, req *connect.Request[controlproto.ListDevicesRequest]) (*connect.Response[controlproto.ListDevicesResponse], error) {
+	return c.listDevices.CallUnary(ctx, req)
+}
+
+// GetDevice calls controlproto.ControlService.GetDevice.
+func (c *controlServiceClient) GetDevice(ctx context.Context, req *connect.Request[controlproto.GetDeviceRequest]) (*connect.Response[controlproto.DeviceConfig], error) {
+	return c.getDevice.CallUnary(ctx, req)
+}
+
+// PutDevice calls controlproto.ControlService.PutDevice.
+func (c *controlServiceClient) PutDevice(ctx context.Context, req *connect.Request[controlproto.PutDeviceRequest]) (*connect.Response[controlproto.DeviceConfig], error) {
+	return c.putDevice.CallUnary(ctx, req)
+}
+
+// DeleteDevice calls controlproto.ControlService.DeleteDevice.
+func (c *controlServiceClient) DeleteDevice(ctx context.Context, req *connect.Request[controlproto.DeleteDeviceRequest]) (*connect.Response[controlproto.DeleteDeviceResponse], error) {
+	return c.deleteDevice.CallUnary(ctx, req)
+}
+
+// GetDeviceStatus calls controlproto.ControlService.GetDeviceStatus.
+func (c *controlServiceClient) GetDeviceStatus(ctx context.Context, req *connect.Request[controlproto.GetDeviceStatusRequest]) (*connect.Response[controlproto.DeviceStatus], error) {
+	return c.getDeviceStatus.CallUnary(ctx, req)
+}
+
+// Scan calls controlproto.ControlService.Scan.
+func (c *controlServiceClient) Scan(ctx context.Context, req *connect.Request[controlproto.ScanRequest]) (*connect.Response[controlproto.ScanResponse], error) {
+	return c.scan.CallUnary(ctx, req)
+}
+
+// Browse calls controlproto.ControlService.Browse.
+func (c *controlServiceClient) Browse(ctx context.Context, req *connect.Request[controlproto.BrowseRequest]) (*connect.Response[controlproto.BrowseResponse], error) {
+	return c.browse.CallUnary(ctx, req)
+}
+
+// Need calls controlproto.ControlService.Need.
+func (c *controlServiceClient) Need(ctx context.Context, req *connect.Request[controlproto.NeedRequest]) (*connect.Response[controlproto.NeedResponse], error) {
+	return c.need.CallUnary(ctx, req)
+}
+
+// StreamEvents calls controlproto.ControlService.StreamEvents.
+func (c *controlServiceClient) StreamEvents(ctx context.Context, req *connect.Request[controlproto.StreamEventsRequest]) (*connect.ServerStreamForClient[controlproto.StreamEventsResponse], error) {
+	return c.streamEvents.CallServerStream(ctx, req)
+}
+
+// ControlServiceHandler is an implementation of the controlproto.ControlService service.
+type ControlServiceHandler interface {
+	ListFolders(context.Context, *connect.Request[controlproto.ListFoldersRequest]) (*connect.Response[controlproto.ListFoldersResponse], error)
+	GetFolder(context.Context, *connect.Request[controlproto.GetFolderRequest]) (*connect.Response[controlproto.FolderConfig], error)
+	// PutFolder creates or updates a folder. Settings not in FolderConfig
+	// keep their current or default values.
+	PutFolder(context.Context, *connect.Request[controlproto.PutFolderRequest]) (*connect.Response[controlproto.FolderConfig], error)
+	DeleteFolder(context.Context, *connect.Request[controlproto.DeleteFolderRequest]) (*connect.Response[controlproto.DeleteFolderResponse], error)
+	GetFolderStatus(context.Context, *connect.Request[controlproto.GetFolderStatusRequest]) (*connect.Response[controlproto.FolderStatus], error)
+	ListDevices(context.Context, *connect.Request[controlproto.ListDevicesRequest]) (*connect.Response[controlproto.ListDevicesResponse], error)
+	GetDevice(context.Context, *connect.Request[controlproto.GetDeviceRequest]) (*connect.Response[controlproto.DeviceConfig], error)
+	// PutDevice creates or updates a device, like PutFolder.
+	PutDevice(context.Context, *connect.Request[controlproto.PutDeviceRequest]) (*connect.Response[controlproto.DeviceConfig], error)
+	DeleteDevice(context.Context, *connect.Request[controlproto.DeleteDeviceRequest]) (*connect.Response[controlproto.DeleteDeviceResponse], error)
+	GetDeviceStatus(context.Context, *connect.Request[controlproto.GetDeviceStatusRequest]) (*connect.Response[controlproto.DeviceStatus], error)
+	Scan(context.Context, *connect.Request[controlproto.ScanRequest]) (*connect.Response[controlproto.ScanResponse], error)
+	Browse(context.Context, *connect.Request[controlproto.BrowseRequest]) (*connect.Response[controlproto.BrowseResponse], error)
+	Need(context.Context, *connect.Request[controlproto.NeedRequest]) (*connect.Response[controlproto.NeedResponse], error)
+	// StreamEvents streams events as they happen, starting after the given
+	// event ID, with a gap marker when events were lost in between.
+	StreamEvents(context.Context, *connect.Request[controlproto.StreamEventsRequest], *connect.ServerStream[controlproto.StreamEventsResponse]) error
+}
+
+// NewControlServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewControlServiceHandler(svc ControlServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	controlServiceListFoldersHandler := connect.NewUnaryHandler(
+		ControlServiceListFoldersProcedure,
+		svc.ListFolders,
+		connect.WithSchema(controlServiceListFoldersMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceGetFolderHandler := connect.NewUnaryHandler(
+		ControlServiceGetFolderProcedure,
+		svc.GetFolder,
+		connect.WithSchema(controlServiceGetFolderMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServicePutFolderHandler := connect.NewUnaryHandler(
+		ControlServicePutFolderProcedure,
+		svc.PutFolder,
+		connect.WithSchema(controlServicePutFolderMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceDeleteFolderHandler := connect.NewUnaryHandler(
+		ControlServiceDeleteFolderProcedure,
+		svc.DeleteFolder,
+		connect.WithSchema(controlServiceDeleteFolderMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceGetFolderStatusHandler := connect.NewUnaryHandler(
+		ControlServiceGetFolderStatusProcedure,
+		svc.GetFolderStatus,
+		connect.WithSchema(controlServiceGetFolderStatusMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceListDevicesHandler := connect.NewUnaryHandler(
+		ControlServiceListDevicesProcedure,
+		svc.ListDevices,
+		connect.WithSchema(controlServiceListDevicesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceGetDeviceHandler := connect.NewUnaryHandler(
+		ControlServiceGetDeviceProcedure,
+		svc.GetDevice,
+		connect.WithSchema(controlServiceGetDeviceMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServicePutDeviceHandler := connect.NewUnaryHandler(
+		ControlServicePutDeviceProcedure,
+		svc.PutDevice,
+		connect.WithSchema(controlServicePutDeviceMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceDeleteDeviceHandler := connect.NewUnaryHandler(
+		ControlServiceDeleteDeviceProcedure,
+		svc.DeleteDevice,
+		connect.WithSchema(controlServiceDeleteDeviceMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceGetDeviceStatusHandler := connect.NewUnaryHandler(
+		ControlServiceGetDeviceStatusProcedure,
+		svc.GetDeviceStatus,
+		connect.WithSchema(controlServiceGetDeviceStatusMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceScanHandler := connect.NewUnaryHandler(
+		ControlServiceScanProcedure,
+		svc.Scan,
+		connect.WithSchema(controlServiceScanMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceBrowseHandler := connect.NewUnaryHandler(
+		ControlServiceBrowseProcedure,
+		svc.Browse,
+		connect.WithSchema(controlServiceBrowseMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceNeedHandler := connect.NewUnaryHandler(
+		ControlServiceNeedProcedure,
+		svc.Need,
+		connect.WithSchema(controlServiceNeedMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	controlServiceStreamEventsHandler := connect.NewServerStreamHandler(
+		ControlServiceStreamEventsProcedure,
+		svc.StreamEvents,
+		connect.WithSchema(controlServiceStreamEventsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/controlproto.ControlService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case ControlServiceListFoldersProcedure:
+			controlServiceListFoldersHandler.ServeHTTP(w, r)
+		case ControlServiceGetFolderProcedure:
+			controlServiceGetFolderHandler.ServeHTTP(w, r)
+		case ControlServicePutFolderProcedure:
+			controlServicePutFolderHandler.ServeHTTP(w, r)
+		case ControlServiceDeleteFolderProcedure:
+			controlServiceDeleteFolderHandler.ServeHTTP(w, r)
+		case ControlServiceGetFolderStatusProcedure:
+			controlServiceGetFolderStatusHandler.ServeHTTP(w, r)
+		case ControlServiceListDevicesProcedure:
+			controlServiceListDevicesHandler.ServeHTTP(w, r)
+		case ControlServiceGetDeviceProcedure:
+			controlServiceGetDeviceHandler.ServeHTTP(w, r)
+		case ControlServicePutDeviceProcedure:
+			controlServicePutDeviceHandler.ServeHTTP(w, r)
+		case ControlServiceDeleteDeviceProcedure:
+			controlServiceDeleteDeviceHandler.ServeHTTP(w, r)
+		case ControlServiceGetDeviceStatusProcedure:
+			controlServiceGetDeviceStatusHandler.ServeHTTP(w, r)
+		case ControlServiceScanProcedure:
+			controlServiceScanHandler.ServeHTTP(w, r)
+		case ControlServiceBrowseProcedure:
+			controlServiceBrowseHandler.ServeHTTP(w, r)
+		case ControlServiceNeedProcedure:
+			controlServiceNeedHandler.ServeHTTP(w, r)
+		case ControlServiceStreamEventsProcedure:
+			controlServiceStreamEventsHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedControlServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedControlServiceHandler struct{}
+
+func (UnimplementedControlServiceHandler) ListFolders(context.Context, *connect.Request[controlproto.ListFoldersRequest]) (*connect.Response[controlproto.ListFoldersResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.ListFolders is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) GetFolder(context.Context, *connect.Request[controlproto.GetFolderRequest]) (*connect.Response[controlproto.FolderConfig], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.GetFolder is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) PutFolder(context.Context, *connect.Request[controlproto.PutFolderRequest]) (*connect.Response[controlproto.FolderConfig], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.PutFolder is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) DeleteFolder(context.Context, *connect.Request[controlproto.DeleteFolderRequest]) (*connect.Response[controlproto.DeleteFolderResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.DeleteFolder is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) GetFolderStatus(context.Context, *connect.Request[controlproto.GetFolderStatusRequest]) (*connect.Response[controlproto.FolderStatus], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.GetFolderStatus is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) ListDevices(context.Context, *connect.Request[controlproto.ListDevicesRequest]) (*connect.Response[controlproto.ListDevicesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.ListDevices is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) GetDevice(context.Context, *connect.Request[controlproto.GetDeviceRequest]) (*connect.Response[controlproto.DeviceConfig], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.GetDevice is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) PutDevice(context.Context, *connect.Request[controlproto.PutDeviceRequest]) (*connect.Response[controlproto.DeviceConfig], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.PutDevice is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) DeleteDevice(context.Context, *connect.Request[controlproto.DeleteDeviceRequest]) (*connect.Response[controlproto.DeleteDeviceResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.DeleteDevice is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) GetDeviceStatus(context.Context, *connect.Request[controlproto.GetDeviceStatusRequest]) (*connect.Response[controlproto.DeviceStatus], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.GetDeviceStatus is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) Scan(context.Context, *connect.Request[controlproto.ScanRequest]) (*connect.Response[controlproto.ScanResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.Scan is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) Browse(context.Context, *connect.Request[controlproto.BrowseRequest]) (*connect.Response[controlproto.BrowseResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.Browse is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) Need(context.Context, *connect.Request[controlproto.NeedRequest]) (*connect.Response[controlproto.NeedResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.Need is not implemented"))
+}
+
+func (UnimplementedControlServiceHandler) StreamEvents(context.Context, *connect.Request[controlproto.StreamEventsRequest], *connect.ServerStream[controlproto.StreamEventsResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("controlproto.ControlService.StreamEvents is not implemented"))
+}
@@ -34,7 +34,6 @@ import (
 	"unicode"
 
 	"github.com/calmh/incontainer"
-	"github.com/julienschmidt/httprouter"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/thejerf/suture/v4"
 	"github.com/vitrun/qart/qr"
@@ -46,6 +45,7 @@ import (
 	"github.com/syncthing/syncthing/internal/slogutil"
 	"github.com/syncthing/syncthing/lib/build"
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/confighistory"
 	"github.com/syncthing/syncthing/lib/connections"
 	"github.com/syncthing/syncthing/lib/discover"
 	"github.com/syncthing/syncthing/lib/events"
@@ -54,10 +54,12 @@ import (
 	"github.com/syncthing/syncthing/lib/model"
 	"github.com/syncthing/syncthing/lib/protocol"
 	"github.com/syncthing/syncthing/lib/rand"
+	"github.com/syncthing/syncthing/lib/structutil"
 	"github.com/syncthing/syncthing/lib/svcutil"
 	"github.com/syncthing/syncthing/lib/tlsutil"
 	"github.com/syncthing/syncthing/lib/upgrade"
 	"github.com/syncthing/syncthing/lib/ur"
+	"github.com/syncthing/syncthing/lib/webhooks"
 )
 
 const (
@@ -68,6 +70,8 @@ const (
 	EventSubBufferSize    = 1000
 	defaultEventTimeout   = time.Minute
 	httpsCertLifetimeDays = 820
+	// Spot checks hash files on disk, which any viewer may request.
+	maxSpotChecks = 100
 )
 
 type service struct {
@@ -84,6 +88,8 @@ type service struct {
 	connectionsService   connections.Service
 	fss                  model.FolderSummaryService
 	urService            *ur.Service
+	webhooks             *webhooks.Service
+	configHistory        *confighistory.History
 	noUpgrade            bool
 	tlsDefaultCommonName string
 	configChanged        chan struct{} // signals intentional listener close due to config change
@@ -93,7 +99,9 @@ type service struct {
 	listenerAddr         net.Addr
 	exitChan             chan *svcutil.FatalErr
 	miscDB               *db.Typed
+	apiTokens            *apiTokenManager
 	shutdownTimeout      time.Duration
+	eventStreamKeepalive time.Duration
 
 	guiErrors slogutil.Recorder
 	systemLog slogutil.Recorder
@@ -107,7 +115,7 @@ type Service interface {
 	WaitForStart() error
 }
 
-func New(id protocol.DeviceID, cfg config.Wrapper, assetDir, tlsDefaultCommonName string, m model.Model, defaultSub, diskSub events.BufferedSubscription, evLogger events.Logger, discoverer discover.Manager, connectionsService connections.Service, urService *ur.Service, fss model.FolderSummaryService, errors, systemLog slogutil.Recorder, noUpgrade bool, miscDB *db.Typed) Service {
+func New(id protocol.DeviceID, cfg config.Wrapper, assetDir, tlsDefaultCommonName string, m model.Model, defaultSub, diskSub events.BufferedSubscription, evLogger events.Logger, discoverer discover.Manager, connectionsService connections.Service, urService *ur.Service, webhooksService *webhooks.Service, configHistory *confighistory.History, fss model.FolderSummaryService, errors, systemLog slogutil.Recorder, noUpgrade bool, miscDB *db.Typed) Service {
 	return &service{
 		id:      id,
 		cfg:     cfg,
@@ -122,6 +130,8 @@ func New(id protocol.DeviceID, cfg config.Wrapper, assetDir, tlsDefaultCommonNam
 		connectionsService:   connectionsService,
 		fss:                  fss,
 		urService:            urService,
+		webhooks:             webhooksService,
+		configHistory:        configHistory,
 		guiErrors:            errors,
 		systemLog:            systemLog,
 		noUpgrade:            noUpgrade,
@@ -130,7 +140,9 @@ func New(id protocol.DeviceID, cfg config.Wrapper, assetDir, tlsDefaultCommonNam
 		startedOnce:          make(chan struct{}),
 		exitChan:             make(chan *svcutil.FatalErr, 1),
 		miscDB:               miscDB,
+		apiTokens:            newAPITokenManager(miscDB),
 		shutdownTimeout:      100 * time.Millisecond,
+		eventStreamKeepalive: 15 * time.Second,
 	}
 }
 
@@ -174,6 +186,11 @@ func (s *service) getListener(guiCfg config.GUIConfiguration) (net.Listener, err
 	}
 	tlsCfg := tlsutil.SecureDefaultWithTLS12()
 	tlsCfg.Certificates = []tls.Certificate{cert}
+	if guiCfg.ClientCertMode != config.ClientCertModeOff {
+		if err := setClientCAs(tlsCfg, guiCfg); err != nil {
+			return nil, fmt.Errorf("client certificates: %w", err)
+		}
+	}
 
 	if guiCfg.Network() == "unix" {
 		// When listening on a UNIX socket we should unlink before bind,
@@ -247,72 +264,86 @@ func (s *service) Serve(ctx context.Context) error {
 	s.cfg.Subscribe(s)
 	defer s.cfg.Unsubscribe(s)
 
-	restMux := httprouter.New()
+	// Every route registered on the REST mux is subject to the access
+	// policy for the authenticated user, see policyFor.
+	restMux := newAuthzRouter()
 
 	// The GET handlers
-	restMux.HandlerFunc(http.MethodGet, "/rest/cluster/pending/devices", s.getPendingDevices) // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/cluster/pending/folders", s.getPendingFolders) // [device]
-	restMux.HandlerFunc(http.MethodGet, "/rest/db/completion", s.getDBCompletion)             // [device] [folder]
-	restMux.HandlerFunc(http.MethodGet, "/rest/db/file", s.getDBFile)                         // folder file
-	restMux.HandlerFunc(http.MethodGet, "/rest/db/ignores", s.getDBIgnores)                   // folder
-	restMux.HandlerFunc(http.MethodGet, "/rest/db/need", s.getDBNeed)                         // folder [perpage] [page]
-	restMux.HandlerFunc(http.MethodGet, "/rest/db/remoteneed", s.getDBRemoteNeed)             // device folder [perpage] [page]
-	restMux.HandlerFunc(http.MethodGet, "/rest/db/localchanged", s.getDBLocalChanged)         // folder [perpage] [page]
-	restMux.HandlerFunc(http.MethodGet, "/rest/db/status", s.getDBStatus)                     // folder
-	restMux.HandlerFunc(http.MethodGet, "/rest/db/browse", s.getDBBrowse)                     // folder [prefix] [dirsonly] [levels]
-	restMux.HandlerFunc(http.MethodGet, "/rest/folder/versions", s.getFolderVersions)         // folder
-	restMux.HandlerFunc(http.MethodGet, "/rest/folder/errors", s.getFolderErrors)             // folder [perpage] [page]
-	restMux.HandlerFunc(http.MethodGet, "/rest/folder/pullerrors", s.getFolderErrors)         // folder (deprecated)
-	restMux.HandlerFunc(http.MethodGet, "/rest/events", s.getIndexEvents)                     // [since] [limit] [timeout] [events]
-	restMux.HandlerFunc(http.MethodGet, "/rest/events/disk", s.getDiskEvents)                 // [since] [limit] [timeout]
-	restMux.HandlerFunc(http.MethodGet, "/rest/noauth/health", s.getHealth)                   // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/stats/device", s.getDeviceStats)               // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/stats/folder", s.getFolderStats)               // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/svc/deviceid", s.getDeviceID)                  // id
-	restMux.HandlerFunc(http.MethodGet, "/rest/svc/lang", s.getLang)                          // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/svc/report", s.getReport)                      // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/svc/random/string", s.getRandomString)         // [length]
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/browse", s.getSystemBrowse)             // current
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/connections", s.getSystemConnections)   // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/discovery", s.getSystemDiscovery)       // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/error", s.getSystemError)               // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/paths", s.getSystemPaths)               // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/ping", s.restPing)                      // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/status", s.getSystemStatus)             // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/upgrade", s.getSystemUpgrade)           // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/version", s.getSystemVersion)           // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/loglevels", s.getSystemDebug)           // -
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/log", s.getSystemLog)                   // [since]
-	restMux.HandlerFunc(http.MethodGet, "/rest/system/log.txt", s.getSystemLogTxt)            // [since]
+	restMux.HandlerFunc(http.MethodGet, "/rest/cluster/pending/devices", s.getPendingDevices)  // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/cluster/pending/folders", s.getPendingFolders)  // [device]
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/completion", s.getDBCompletion)              // [device] [folder]
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/file", s.getDBFile)                          // folder file
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/ignores", s.getDBIgnores)                    // folder
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/sharedignores", s.getDBSharedIgnores)        // folder
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/need", s.getDBNeed)                          // folder [perpage] [page]
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/remoteneed", s.getDBRemoteNeed)              // device folder [perpage] [page]
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/localchanged", s.getDBLocalChanged)          // folder [perpage] [page]
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/masschange", s.getDBMassChange)              // folder
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/status", s.getDBStatus)                      // folder
+	restMux.HandlerFunc(http.MethodGet, "/rest/db/browse", s.getDBBrowse)                      // folder [prefix] [dirsonly] [levels]
+	restMux.HandlerFunc(http.MethodGet, "/rest/folder/versions", s.getFolderVersions)          // folder
+	restMux.HandlerFunc(http.MethodGet, "/rest/folder/errors", s.getFolderErrors)              // folder [perpage] [page]
+	restMux.HandlerFunc(http.MethodGet, "/rest/folder/pullerrors", s.getFolderErrors)          // folder (deprecated)
+	restMux.HandlerFunc(http.MethodGet, "/rest/folder/verify", s.getFolderVerify)              // folder [spotcheck]
+	restMux.HandlerFunc(http.MethodGet, "/rest/folder/seed", s.getFolderSeed)                  // folder
+	restMux.HandlerFunc(http.MethodGet, "/rest/events", s.getIndexEvents)                      // [since] [limit] [timeout] [events]
+	restMux.HandlerFunc(http.MethodGet, "/rest/events/disk", s.getDiskEvents)                  // [since] [limit] [timeout]
+	restMux.HandlerFunc(http.MethodGet, "/rest/events/stream", s.getEventStream)               // [since] [events] [folder] [device]
+	restMux.HandlerFunc(http.MethodGet, "/rest/noauth/health", s.getHealth)                    // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/stats/device", s.getDeviceStats)                // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/stats/folder", s.getFolderStats)                // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/svc/deviceid", s.getDeviceID)                   // id
+	restMux.HandlerFunc(http.MethodGet, "/rest/svc/lang", s.getLang)                           // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/svc/report", s.getReport)                       // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/svc/random/string", s.getRandomString)          // [length]
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/browse", s.getSystemBrowse)              // current
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/connections", s.getSystemConnections)    // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/discovery", s.getSystemDiscovery)        // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/error", s.getSystemError)                // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/paths", s.getSystemPaths)                // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/ping", s.restPing)                       // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/status", s.getSystemStatus)              // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/upgrade", s.getSystemUpgrade)            // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/version", s.getSystemVersion)            // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/loglevels", s.getSystemDebug)            // -
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/log", s.getSystemLog)                    // [since]
+	restMux.HandlerFunc(http.MethodGet, "/rest/system/log.txt", s.getSystemLogTxt)             // [since]
+	restMux.HandlerFunc(http.MethodGet, "/rest/webhooks/deadletters", s.getWebhookDeadLetters) // -
 
 	// The POST handlers
-	restMux.HandlerFunc(http.MethodPost, "/rest/db/prio", s.postDBPrio)                          // folder file
-	restMux.HandlerFunc(http.MethodPost, "/rest/db/ignores", s.postDBIgnores)                    // folder
-	restMux.HandlerFunc(http.MethodPost, "/rest/db/override", s.postDBOverride)                  // folder
-	restMux.HandlerFunc(http.MethodPost, "/rest/db/revert", s.postDBRevert)                      // folder
-	restMux.HandlerFunc(http.MethodPost, "/rest/db/scan", s.postDBScan)                          // folder [sub...] [delay]
-	restMux.HandlerFunc(http.MethodPost, "/rest/folder/versions", s.postFolderVersionsRestore)   // folder <body>
-	restMux.HandlerFunc(http.MethodPost, "/rest/system/error", s.postSystemError)                // <body>
-	restMux.HandlerFunc(http.MethodPost, "/rest/system/error/clear", s.postSystemErrorClear)     // -
-	restMux.HandlerFunc(http.MethodPost, "/rest/system/ping", s.restPing)                        // -
-	restMux.HandlerFunc(http.MethodPost, "/rest/system/reset", s.postSystemReset)                // [folder]
-	restMux.HandlerFunc(http.MethodPost, "/rest/system/restart", s.postSystemRestart)            // -
-	restMux.HandlerFunc(http.MethodPost, "/rest/system/shutdown", s.postSystemShutdown)          // -
-	restMux.HandlerFunc(http.MethodPost, "/rest/system/upgrade", s.postSystemUpgrade)            // -
-	restMux.HandlerFunc(http.MethodPost, "/rest/system/pause", s.makeDevicePauseHandler(true))   // [device]
-	restMux.HandlerFunc(http.MethodPost, "/rest/system/resume", s.makeDevicePauseHandler(false)) // [device]
-	restMux.HandlerFunc(http.MethodPost, "/rest/system/loglevels", s.postSystemDebug)            // [enable] [disable]
+	restMux.HandlerFunc(http.MethodPost, "/rest/db/prio", s.postDBPrio)                                     // folder file
+	restMux.HandlerFunc(http.MethodPost, "/rest/db/ignores", s.postDBIgnores)                               // folder
+	restMux.HandlerFunc(http.MethodPost, "/rest/db/sharedignores", s.postDBSharedIgnores)                   // folder
+	restMux.HandlerFunc(http.MethodPost, "/rest/db/masschange", s.postDBMassChange)                         // folder action
+	restMux.HandlerFunc(http.MethodPost, "/rest/db/override", s.postDBOverride)                             // folder
+	restMux.HandlerFunc(http.MethodPost, "/rest/db/revert", s.postDBRevert)                                 // folder
+	restMux.HandlerFunc(http.MethodPost, "/rest/db/scan", s.postDBScan)                                     // folder [sub...] [delay]
+	restMux.HandlerFunc(http.MethodPost, "/rest/folder/versions", s.postFolderVersionsRestore)              // folder <body>
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/error", s.postSystemError)                           // <body>
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/error/clear", s.postSystemErrorClear)                // -
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/ping", s.restPing)                                   // -
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/reset", s.postSystemReset)                           // [folder]
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/restart", s.postSystemRestart)                       // -
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/shutdown", s.postSystemShutdown)                     // -
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/upgrade", s.postSystemUpgrade)                       // -
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/pause", s.makeDevicePauseHandler(true))              // [device]
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/resume", s.makeDevicePauseHandler(false))            // [device]
+	restMux.HandlerFunc(http.MethodPost, "/rest/system/loglevels", s.postSystemDebug)                       // [enable] [disable]
+	restMux.HandlerFunc(http.MethodPost, "/rest/webhooks/deadletters/retry", s.postWebhookDeadLettersRetry) // -
 
 	// The DELETE handlers
-	restMux.HandlerFunc(http.MethodDelete, "/rest/cluster/pending/devices", s.deletePendingDevices) // device
-	restMux.HandlerFunc(http.MethodDelete, "/rest/cluster/pending/folders", s.deletePendingFolders) // folder [device]
+	restMux.HandlerFunc(http.MethodDelete, "/rest/cluster/pending/devices", s.deletePendingDevices)  // device
+	restMux.HandlerFunc(http.MethodDelete, "/rest/cluster/pending/folders", s.deletePendingFolders)  // folder [device]
+	restMux.HandlerFunc(http.MethodDelete, "/rest/webhooks/deadletters", s.deleteWebhookDeadLetters) // -
 
 	// Config endpoints
 
 	configBuilder := &configMuxBuilder{
-		Router: restMux,
-		id:     s.id,
-		cfg:    s.cfg,
+		authzRouter: restMux,
+		id:          s.id,
+		cfg:         s.cfg,
+		apiTokens:   s.apiTokens,
+		history:     s.configHistory,
 	}
 
 	configBuilder.registerConfig("/rest/config")
@@ -327,7 +358,10 @@ func (s *service) Serve(ctx context.Context) error {
 	configBuilder.registerDefaultIgnores("/rest/config/defaults/ignores")
 	configBuilder.registerOptions("/rest/config/options")
 	configBuilder.registerLDAP("/rest/config/ldap")
+	configBuilder.registerOIDC("/rest/config/oidc")
 	configBuilder.registerGUI("/rest/config/gui")
+	configBuilder.registerAPITokens("/rest/config/gui/tokens")
+	configBuilder.registerHistory("/rest/config/history")
 
 	// Deprecated config endpoints
 	configBuilder.registerConfigDeprecated("/rest/system/config") // POST instead of PUT
@@ -341,8 +375,11 @@ func (s *service) Serve(ctx context.Context) error {
 	debugMux.HandleFunc("/rest/debug/file", s.getDebugFile)
 	restMux.Handler(http.MethodGet, "/rest/debug/*method", debugMux)
 
+	// The typed control API, as Connect, gRPC and gRPC-Web
+	s.registerRPC(restMux)
+
 	// A handler that disables caching
-	noCacheRestMux := noCacheMiddleware(restMux)
+	noCacheRestMux := noCacheMiddleware(auditMiddleware(s.evLogger, s.configHistory, restMux))
 
 	// The main routing handler
 	mux := http.NewServeMux()
@@ -363,7 +400,7 @@ func (s *service) Serve(ctx context.Context) error {
 
 	// Wrap everything in CSRF protection. The /rest prefix should be
 	// protected, other requests will grant cookies.
-	var handler http.Handler = newCsrfManager(s.id.Short().String(), "/rest", guiCfg, mux, s.miscDB)
+	var handler http.Handler = newCsrfManager(s.id.Short().String(), "/rest", apiKeyValidators{guiCfg, s.apiTokens}, guiCfg, mux, s.miscDB)
 
 	// Add our version and ID as a header to responses
 	handler = withDetailsMiddleware(s.id, handler)
@@ -371,13 +408,19 @@ func (s *service) Serve(ctx context.Context) error {
 	// Wrap everything in basic auth, if user/password is set.
 	if guiCfg.IsAuthEnabled() {
 		tokenCookieManager := newTokenCookieManager(s.id.Short().String(), guiCfg, s.evLogger, s.miscDB)
-		authMW := newBasicAuthAndSessionMiddleware(tokenCookieManager, guiCfg, s.cfg.LDAP(), handler, s.evLogger)
+		authMW := newBasicAuthAndSessionMiddleware(tokenCookieManager, s.apiTokens, guiCfg, s.cfg.LDAP(), s.cfg.OIDC(), handler, s.evLogger)
 		handler = authMW
 
 		restMux.Handler(http.MethodPost, "/rest/noauth/auth/password", http.HandlerFunc(authMW.passwordAuthHandler))
 
 		// Logout is a no-op without a valid session cookie, so /noauth/ is fine here
 		restMux.Handler(http.MethodPost, "/rest/noauth/auth/logout", http.HandlerFunc(authMW.handleLogout))
+
+		if guiCfg.AuthMode == config.AuthModeOIDC {
+			oidc := newOIDCHandler(s.cfg.OIDC(), tokenCookieManager, s.evLogger)
+			restMux.HandlerFunc(http.MethodGet, oidcLoginPath, oidc.handleLogin)
+			restMux.HandlerFunc(http.MethodGet, oidcCallbackPath, oidc.handleCallback)
+		}
 	}
 
 	// Redirect to HTTPS if we are supposed to
@@ -486,8 +529,8 @@ func (*service) VerifyConfiguration(_, to config.Configuration) error {
 }
 
 func (s *service) CommitConfiguration(from, to config.Configuration) bool {
-	if to.GUI == from.GUI {
-		// No GUI changes, we're done here.
+	if !authConfigChanged(from, to) {
+		// No GUI or authentication changes, we're done here.
 		return true
 	}
 
@@ -501,6 +544,21 @@ func (s *service) CommitConfiguration(from, to config.Configuration) bool {
 	return true
 }
 
+// authConfigChanged returns true if the GUI, LDAP or OpenID Connect
+// settings differ, not counting the difference between nil and empty lists.
+func authConfigChanged(from, to config.Configuration) bool {
+	fromGUI, toGUI := from.GUI.Copy(), to.GUI.Copy()
+	fromLDAP, toLDAP := from.LDAP.Copy(), to.LDAP.Copy()
+	fromOIDC, toOIDC := from.OIDC.Copy(), to.OIDC.Copy()
+	structutil.FillNil(&fromGUI)
+	structutil.FillNil(&toGUI)
+	structutil.FillNil(&fromLDAP)
+	structutil.FillNil(&toLDAP)
+	structutil.FillNil(&fromOIDC)
+	structutil.FillNil(&toOIDC)
+	return !reflect.DeepEqual(fromGUI, toGUI) || !reflect.DeepEqual(fromLDAP, toLDAP) || !reflect.DeepEqual(fromOIDC, toOIDC)
+}
+
 func (s *service) fatal(err *svcutil.FatalErr) {
 	// s.exitChan is 1-buffered and whoever is first gets handled.
 	select {
@@ -687,6 +745,28 @@ func (s *service) deletePendingFolders(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (s *service) getWebhookDeadLetters(w http.ResponseWriter, _ *http.Request) {
+	letters := []webhooks.DeadLetter{}
+	if s.webhooks != nil {
+		letters = append(letters, s.webhooks.DeadLetters()...)
+	}
+	sendJSON(w, letters)
+}
+
+func (s *service) postWebhookDeadLettersRetry(w http.ResponseWriter, _ *http.Request) {
+	n := 0
+	if s.webhooks != nil {
+		n = s.webhooks.RetryDeadLetters()
+	}
+	sendJSON(w, map[string]int{"requeued": n})
+}
+
+func (s *service) deleteWebhookDeadLetters(_ http.ResponseWriter, _ *http.Request) {
+	if s.webhooks != nil {
+		s.webhooks.ClearDeadLetters()
+	}
+}
+
 func (*service) restPing(w http.ResponseWriter, _ *http.Request) {
 	sendJSON(w, map[string]string{"ping": "pong"})
 }
@@ -812,6 +892,43 @@ func (s *service) postDBRevert(_ http.ResponseWriter, r *http.Request) {
 	go s.model.Revert(folder)
 }
 
+func (s *service) getDBMassChange(w http.ResponseWriter, r *http.Request) {
+	folder := r.URL.Query().Get("folder")
+	mc, ok, err := s.model.MassChange(folder)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if !ok {
+		http.Error(w, model.ErrNoMassChange.Error(), http.StatusNotFound)
+		return
+	}
+	sendJSON(w, mc)
+}
+
+func (s *service) postDBMassChange(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	folder := qs.Get("folder")
+	var revert bool
+	switch action := qs.Get("action"); action {
+	case "approve":
+	case "revert":
+		revert = true
+	default:
+		http.Error(w, fmt.Sprintf("unknown action %q, must be approve or revert", action), http.StatusBadRequest)
+		return
+	}
+	switch err := s.model.ConfirmMassChange(folder, revert); {
+	case err == nil:
+	case errors.Is(err, model.ErrNoMassChange):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case isFolderNotFound(err):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func getPagingParams(qs url.Values) (int, int) {
 	page, err := strconv.Atoi(qs.Get("page"))
 	if err != nil || page < 1 {
@@ -906,12 +1023,18 @@ func (s *service) getDeviceStats(w http.ResponseWriter, _ *http.Request) {
 	sendJSON(w, stats)
 }
 
-func (s *service) getFolderStats(w http.ResponseWriter, _ *http.Request) {
+func (s *service) getFolderStats(w http.ResponseWriter, r *http.Request) {
 	stats, err := s.model.FolderStatistics()
 	if err != nil {
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
+	p := principalFrom(r)
+	for folder := range stats {
+		if !p.canAccessFolder(folder) {
+			delete(stats, folder)
+		}
+	}
 	sendJSON(w, stats)
 }
 
@@ -1335,6 +1458,32 @@ func (s *service) postDBIgnores(w http.ResponseWriter, r *http.Request) {
 	s.getDBIgnores(w, r)
 }
 
+func (s *service) getDBSharedIgnores(w http.ResponseWriter, r *http.Request) {
+	shared, err := s.model.SharedIgnores(r.URL.Query().Get("folder"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	sendJSON(w, shared)
+}
+
+func (s *service) postDBSharedIgnores(w http.ResponseWriter, r *http.Request) {
+	var data struct {
+		Lines []string `json:"lines"`
+	}
+	if err := unmarshalTo(r.Body, &data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.model.SetSharedIgnores(r.URL.Query().Get("folder"), data.Lines); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.getDBSharedIgnores(w, r)
+}
+
 func (s *service) getIndexEvents(w http.ResponseWriter, r *http.Request) {
 	mask := s.getEventMask(r.URL.Query().Get("events"))
 	sub := s.getEventSub(mask)
@@ -1368,6 +1517,7 @@ func (*service) getEvents(w http.ResponseWriter, r *http.Request, eventSub event
 
 	// If there are no events available return an empty slice, as this gets serialized as `[]`
 	evs := eventSub.Since(since, []events.Event{}, timeout)
+	evs = filterEvents(principalFrom(r), evs)
 	if 0 < limit && limit < len(evs) {
 		evs = evs[len(evs)-limit:]
 	}
@@ -1406,7 +1556,7 @@ func (s *service) getSystemUpgrade(w http.ResponseWriter, _ *http.Request) {
 		return
 	}
 	opts := s.cfg.Options()
-	rel, err := upgrade.LatestRelease(opts.ReleasesURL, build.Version, opts.UpgradeToPreReleases)
+	rel, err := upgrade.LatestRelease(upgrade.NewChannel(opts.ReleaseChannel, opts.ReleasesURL, opts.UpgradeToPreReleases, opts.ReleaseSigningKeys), build.Version)
 	if err != nil {
 		httpError(w, err)
 		return
@@ -1470,14 +1620,15 @@ func (*service) getLang(w http.ResponseWriter, r *http.Request) {
 
 func (s *service) postSystemUpgrade(w http.ResponseWriter, _ *http.Request) {
 	opts := s.cfg.Options()
-	rel, err := upgrade.LatestRelease(opts.ReleasesURL, build.Version, opts.UpgradeToPreReleases)
+	ch := upgrade.NewChannel(opts.ReleaseChannel, opts.ReleasesURL, opts.UpgradeToPreReleases, opts.ReleaseSigningKeys)
+	rel, err := upgrade.LatestRelease(ch, build.Version)
 	if err != nil {
 		httpError(w, err)
 		return
 	}
 
 	if upgrade.CompareVersions(rel.Tag, build.Version) > upgrade.Equal {
-		err = upgrade.To(rel)
+		err = upgrade.To(rel, ch)
 		if err != nil {
 			slog.Error("Failed to upgrade", slogutil.Error(err))
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -1499,7 +1650,7 @@ func (s *service) makeDevicePauseHandler(paused bool) http.HandlerFunc {
 
 		var msg string
 		var status int
-		_, err := s.cfg.Modify(func(cfg *config.Configuration) {
+		_, err := auditedConfig(r.Context(), s.cfg).Modify(func(cfg *config.Configuration) {
 			if deviceStr == "" {
 				for i := range cfg.Devices {
 					cfg.Devices[i].Paused = paused
@@ -1646,6 +1797,39 @@ func (s *service) getFolderErrors(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (s *service) getFolderSeed(w http.ResponseWriter, r *http.Request) {
+	summary, err := s.model.SeedSummary(r.URL.Query().Get("folder"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	sendJSON(w, summary)
+}
+
+func (s *service) getFolderVerify(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	folder := qs.Get("folder")
+	var spotChecks int
+	if v := qs.Get("spotcheck"); v != "" {
+		var err error
+		if spotChecks, err = strconv.Atoi(v); err != nil || spotChecks < 0 || spotChecks > maxSpotChecks {
+			http.Error(w, fmt.Sprintf("spotcheck must be a number of files between 0 and %d", maxSpotChecks), http.StatusBadRequest)
+			return
+		}
+	}
+
+	report, err := s.model.VerifyFolder(r.Context(), folder, spotChecks)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if isFolderNotFound(err) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+	sendJSON(w, report)
+}
+
 func (*service) getSystemBrowse(w http.ResponseWriter, r *http.Request) {
 	qs := r.URL.Query()
 	current := qs.Get("current")
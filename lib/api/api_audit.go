@@ -0,0 +1,192 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/syncthing/syncthing/internal/gen/controlproto/controlprotoconnect"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/confighistory"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// Requests that don't change anything despite their method.
+var unauditedRoutes = []string{
+	"POST /rest/system/ping",
+	"POST " + rpcPrefix + controlprotoconnect.ControlServiceListFoldersProcedure,
+	"POST " + rpcPrefix + controlprotoconnect.ControlServiceGetFolderProcedure,
+	"POST " + rpcPrefix + controlprotoconnect.ControlServiceGetFolderStatusProcedure,
+	"POST " + rpcPrefix + controlprotoconnect.ControlServiceListDevicesProcedure,
+	"POST " + rpcPrefix + controlprotoconnect.ControlServiceGetDeviceProcedure,
+	"POST " + rpcPrefix + controlprotoconnect.ControlServiceGetDeviceStatusProcedure,
+	"POST " + rpcPrefix + controlprotoconnect.ControlServiceBrowseProcedure,
+	"POST " + rpcPrefix + controlprotoconnect.ControlServiceNeedProcedure,
+	"POST " + rpcPrefix + controlprotoconnect.ControlServiceStreamEventsProcedure,
+}
+
+// auditMiddleware emits an APIRequest event for every request that may
+// change something, recording who made it, from where, and the
+// configuration changes it committed. Changed configurations are also
+// recorded in the history, if there is one. Only changes made through
+// auditedConfig are attributed to the request; changes made meanwhile by
+// anything else aren't.
+func auditMiddleware(evLogger events.Logger, history *confighistory.History, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isAudited(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		rec := new(auditRecord)
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), auditRecordKey{}, rec)))
+
+		username := principalFrom(r).name
+		remoteAddress, proxy := remoteAddress(r)
+		evData := map[string]any{
+			"username":      username,
+			"remoteAddress": remoteAddress,
+			"method":        r.Method,
+			"endpoint":      r.URL.Path,
+			"status":        sw.status,
+		}
+		if proxy != "" {
+			evData["proxy"] = proxy
+		}
+		if r.URL.RawQuery != "" {
+			evData["query"] = r.URL.RawQuery
+		}
+		var changes []config.Change
+		for _, c := range rec.commits() {
+			if len(config.Diff(c.from, c.to)) == 0 {
+				continue
+			}
+			changes = append(changes, config.Diff(redactSecrets(c.from), redactSecrets(c.to))...)
+			if history != nil {
+				history.Record(c.to, username, confighistory.SourceAPI)
+			}
+		}
+		if len(changes) > 0 {
+			evData["changes"] = changes
+		}
+		evLogger.Log(events.APIRequest, evData)
+	})
+}
+
+type auditRecordKey struct{}
+
+// An auditRecord holds the configuration commits made on behalf of a
+// request.
+type auditRecord struct {
+	mut  sync.Mutex
+	list []auditCommit
+}
+
+type auditCommit struct {
+	from, to config.Configuration
+}
+
+func (a *auditRecord) add(from, to config.Configuration) {
+	a.mut.Lock()
+	a.list = append(a.list, auditCommit{from: from, to: to})
+	a.mut.Unlock()
+}
+
+func (a *auditRecord) commits() []auditCommit {
+	a.mut.Lock()
+	defer a.mut.Unlock()
+	return slices.Clone(a.list)
+}
+
+// auditedConfig returns the wrapper to modify the configuration through on
+// behalf of the request of ctx, so that the changes end up in its audit
+// event.
+func auditedConfig(ctx context.Context, cfg config.Wrapper) config.Wrapper {
+	rec, ok := ctx.Value(auditRecordKey{}).(*auditRecord)
+	if !ok {
+		return cfg
+	}
+	return &auditedWrapper{Wrapper: cfg, rec: rec}
+}
+
+// An auditedWrapper records the configuration before and after each of
+// its modifications, as seen by the modify function, i.e. without the
+// changes of other modifications.
+type auditedWrapper struct {
+	config.Wrapper
+	rec *auditRecord
+}
+
+func (w *auditedWrapper) Modify(fn config.ModifyFunction) (config.Waiter, error) {
+	var from, to config.Configuration
+	waiter, err := w.Wrapper.Modify(func(cfg *config.Configuration) {
+		from = cfg.Copy()
+		fn(cfg)
+		to = cfg.Copy()
+	})
+	if err != nil {
+		// Rejected, nothing was committed.
+		return waiter, err
+	}
+	w.rec.add(from, to)
+	return waiter, nil
+}
+
+func (w *auditedWrapper) RemoveFolder(id string) (config.Waiter, error) {
+	return w.Modify(func(cfg *config.Configuration) {
+		if _, i, ok := cfg.Folder(id); ok {
+			cfg.Folders = append(cfg.Folders[:i], cfg.Folders[i+1:]...)
+		}
+	})
+}
+
+func (w *auditedWrapper) RemoveDevice(id protocol.DeviceID) (config.Waiter, error) {
+	return w.Modify(func(cfg *config.Configuration) {
+		if _, i, ok := cfg.Device(id); ok {
+			cfg.Devices = append(cfg.Devices[:i], cfg.Devices[i+1:]...)
+		}
+	})
+}
+
+func isAudited(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if strings.HasPrefix(r.URL.Path, "/rest/noauth/") {
+		// Logins are audited as LoginAttempt events.
+		return false
+	}
+	return !slices.Contains(unauditedRoutes, r.Method+" "+r.URL.Path)
+}
+
+// A statusWriter records the status of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	ldap "github.com/go-ldap/ldap/v3"
+	"github.com/syncthing/syncthing/internal/gen/apiproto"
 	"github.com/syncthing/syncthing/internal/slogutil"
 	"github.com/syncthing/syncthing/lib/config"
 	"github.com/syncthing/syncthing/lib/events"
@@ -127,43 +128,75 @@ func isNoAuthPath(path string, metricsWithoutAuth bool) bool {
 
 type basicAuthAndSessionMiddleware struct {
 	tokenCookieManager *tokenCookieManager
+	apiTokens          *apiTokenManager
 	guiCfg             config.GUIConfiguration
 	ldapCfg            config.LDAPConfiguration
+	oidcCfg            config.OIDCConfiguration
 	next               http.Handler
 	evLogger           events.Logger
 }
 
-func newBasicAuthAndSessionMiddleware(tokenCookieManager *tokenCookieManager, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, next http.Handler, evLogger events.Logger) *basicAuthAndSessionMiddleware {
+func newBasicAuthAndSessionMiddleware(tokenCookieManager *tokenCookieManager, apiTokens *apiTokenManager, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, oidcCfg config.OIDCConfiguration, next http.Handler, evLogger events.Logger) *basicAuthAndSessionMiddleware {
 	return &basicAuthAndSessionMiddleware{
 		tokenCookieManager: tokenCookieManager,
+		apiTokens:          apiTokens,
 		guiCfg:             guiCfg,
 		ldapCfg:            ldapCfg,
+		oidcCfg:            oidcCfg,
 		next:               next,
 		evLogger:           evLogger,
 	}
 }
 
 func (m *basicAuthAndSessionMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	certName, hasCert := clientCertName(r)
+	if !hasCert && m.guiCfg.ClientCertMode == config.ClientCertModeRequired {
+		// Plain HTTP, where the listener can't require a certificate.
+		forbidden(w)
+		return
+	}
+
 	if hasValidAPIKeyHeader(r, m.guiCfg) {
-		m.next.ServeHTTP(w, r)
+		m.next.ServeHTTP(w, withPrincipal(r, adminPrincipal))
 		return
 	}
 
-	if m.tokenCookieManager.hasValidSession(r) {
-		m.next.ServeHTTP(w, r)
+	if p, ok := m.apiTokens.authenticate(r, m.evLogger); ok {
+		m.next.ServeHTTP(w, withPrincipal(r, p))
 		return
 	}
 
+	if p, ok := certPrincipal(certName, m.guiCfg); ok {
+		m.next.ServeHTTP(w, withPrincipal(r, p))
+		return
+	}
+
+	if owner, ok := m.tokenCookieManager.sessionOwner(r); ok {
+		if p, ok := resolvePrincipal(owner, m.guiCfg, m.ldapCfg, m.oidcCfg); ok {
+			m.next.ServeHTTP(w, withPrincipal(r, p))
+			return
+		}
+	}
+
 	// Fall back to Basic auth if provided
-	if username, ok := attemptBasicAuth(r, m.guiCfg, m.ldapCfg, m.evLogger); ok {
-		m.tokenCookieManager.createSession(username, false, w, r)
-		m.next.ServeHTTP(w, r)
+	if owner, ok := attemptBasicAuth(r, m.guiCfg, m.ldapCfg, m.evLogger); ok {
+		if p, ok := resolvePrincipal(owner, m.guiCfg, m.ldapCfg, m.oidcCfg); ok {
+			m.tokenCookieManager.createSession(owner, false, w, r)
+			m.next.ServeHTTP(w, withPrincipal(r, p))
+			return
+		}
+	}
+
+	// With single sign-on there is no login form; send the browser to the
+	// issuer instead.
+	if m.guiCfg.AuthMode == config.AuthModeOIDC && r.Method == http.MethodGet && (r.URL.Path == "/" || r.URL.Path == "/index.html") {
+		http.Redirect(w, r, oidcLoginPath, http.StatusSeeOther)
 		return
 	}
 
 	// Exception for static assets and REST calls that don't require authentication.
 	if isNoAuthPath(r.URL.Path, m.guiCfg.MetricsWithoutAuth) {
-		m.next.ServeHTTP(w, r)
+		m.next.ServeHTTP(w, withPrincipal(r, anonymousPrincipal))
 		return
 	}
 
@@ -189,8 +222,8 @@ func (m *basicAuthAndSessionMiddleware) passwordAuthHandler(w http.ResponseWrite
 		return
 	}
 
-	if auth(req.Username, req.Password, m.guiCfg, m.ldapCfg) {
-		m.tokenCookieManager.createSession(req.Username, req.StayLoggedIn, w, r)
+	if owner, ok := auth(req.Username, req.Password, m.guiCfg, m.ldapCfg); ok {
+		m.tokenCookieManager.createSession(owner, req.StayLoggedIn, w, r)
 		w.WriteHeader(http.StatusNoContent)
 		return
 	}
@@ -200,27 +233,27 @@ func (m *basicAuthAndSessionMiddleware) passwordAuthHandler(w http.ResponseWrite
 	forbidden(w)
 }
 
-func attemptBasicAuth(r *http.Request, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, evLogger events.Logger) (string, bool) {
+func attemptBasicAuth(r *http.Request, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, evLogger events.Logger) (*apiproto.TokenOwner, bool) {
 	username, password, ok := r.BasicAuth()
 	if !ok {
-		return "", false
+		return nil, false
 	}
 
 	slog.Debug("Sessionless HTTP request with authentication; this is expensive.")
 
-	if auth(username, password, guiCfg, ldapCfg) {
-		return username, true
+	if owner, ok := auth(username, password, guiCfg, ldapCfg); ok {
+		return owner, true
 	}
 
 	usernameFromIso := string(iso88591ToUTF8([]byte(username)))
 	passwordFromIso := string(iso88591ToUTF8([]byte(password)))
-	if auth(usernameFromIso, passwordFromIso, guiCfg, ldapCfg) {
-		return usernameFromIso, true
+	if owner, ok := auth(usernameFromIso, passwordFromIso, guiCfg, ldapCfg); ok {
+		return owner, true
 	}
 
 	emitLoginAttempt(false, username, r, evLogger)
 	antiBruteForceSleep()
-	return "", false
+	return nil, false
 }
 
 func (m *basicAuthAndSessionMiddleware) handleLogout(w http.ResponseWriter, r *http.Request) {
@@ -228,19 +261,43 @@ func (m *basicAuthAndSessionMiddleware) handleLogout(w http.ResponseWriter, r *h
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func auth(username string, password string, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration) bool {
-	if guiCfg.AuthMode == config.AuthModeLDAP {
-		return authLDAP(username, password, ldapCfg)
-	} else {
-		return authStatic(username, password, guiCfg)
+// auth returns the owner of the session to create when the credentials are
+// valid and grant access.
+func auth(username string, password string, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration) (*apiproto.TokenOwner, bool) {
+	owner := &apiproto.TokenOwner{Name: username}
+	switch guiCfg.AuthMode {
+	case config.AuthModeOIDC:
+		// Users log in at the issuer; there are no passwords.
+		return nil, false
+	case config.AuthModeLDAP:
+		groups, ok := authLDAP(username, password, ldapCfg)
+		if !ok {
+			return nil, false
+		}
+		owner.Groups = groups
+	default:
+		if !authStatic(username, password, guiCfg) {
+			return nil, false
+		}
 	}
+	if _, ok := resolvePrincipal(owner, guiCfg, ldapCfg, config.OIDCConfiguration{}); !ok {
+		slog.Warn("User has no access to the GUI", slog.String("username", username))
+		return nil, false
+	}
+	return owner, true
 }
 
 func authStatic(username string, password string, guiCfg config.GUIConfiguration) bool {
-	return guiCfg.CompareHashedPassword(password) == nil && username == guiCfg.User
+	if username == guiCfg.User {
+		return guiCfg.CompareHashedPassword(password) == nil
+	}
+	acc, ok := guiCfg.Account(username)
+	return ok && acc.CompareHashedPassword(password) == nil
 }
 
-func authLDAP(username string, password string, cfg config.LDAPConfiguration) bool {
+// authLDAP returns the groups of the user, as listed in the group attribute
+// of the search result, when the credentials are valid.
+func authLDAP(username string, password string, cfg config.LDAPConfiguration) ([]string, bool) {
 	address := cfg.Address
 	hostname, _, err := net.SplitHostPort(address)
 	if err != nil {
@@ -258,14 +315,14 @@ func authLDAP(username string, password string, cfg config.LDAPConfiguration) bo
 
 	if err != nil {
 		slog.Error("Failed to dial LDAP server", slogutil.Error(err))
-		return false
+		return nil, false
 	}
 
 	if cfg.Transport == config.LDAPTransportStartTLS {
 		err = connection.StartTLS(&tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify})
 		if err != nil {
 			slog.Error("Failed to handshake start TLS With LDAP server", slogutil.Error(err))
-			return false
+			return nil, false
 		}
 	}
 
@@ -275,17 +332,17 @@ func authLDAP(username string, password string, cfg config.LDAPConfiguration) bo
 	err = connection.Bind(bindDN, password)
 	if err != nil {
 		slog.Error("Failed to bind with LDAP server", slogutil.Error(err))
-		return false
+		return nil, false
 	}
 
 	if cfg.SearchFilter == "" && cfg.SearchBaseDN == "" {
-		// We're done here.
-		return true
+		// We're done here. Without a search there are no groups.
+		return nil, true
 	}
 
 	if cfg.SearchFilter == "" || cfg.SearchBaseDN == "" {
 		slog.Error("Bad LDAP configuration: both searchFilter and searchBaseDN must be set, or neither")
-		return false
+		return nil, false
 	}
 
 	// If a search filter and search base is set we do an LDAP search for
@@ -295,19 +352,19 @@ func authLDAP(username string, password string, cfg config.LDAPConfiguration) bo
 	searchString := formatOptionalPercentS(cfg.SearchFilter, escapeForLDAPFilter(username))
 	const sizeLimit = 2  // we search for up to two users -- we only want to match one, so getting any number >1 is a failure.
 	const timeLimit = 60 // Search for up to a minute...
-	searchReq := ldap.NewSearchRequest(cfg.SearchBaseDN, ldap.ScopeWholeSubtree, ldap.DerefFindingBaseObj, sizeLimit, timeLimit, false, searchString, nil, nil)
+	searchReq := ldap.NewSearchRequest(cfg.SearchBaseDN, ldap.ScopeWholeSubtree, ldap.DerefFindingBaseObj, sizeLimit, timeLimit, false, searchString, []string{cfg.MemberAttribute()}, nil)
 
 	res, err := connection.Search(searchReq)
 	if err != nil {
 		slog.Warn("Failed LDAP search", slogutil.Error(err))
-		return false
+		return nil, false
 	}
 	if len(res.Entries) != 1 {
 		slog.Warn("Incorrect number of LDAP search results (expected one)", slog.Int("results", len(res.Entries)))
-		return false
+		return nil, false
 	}
 
-	return true
+	return res.Entries[0].GetAttributeValues(cfg.MemberAttribute()), true
 }
 
 // escapeForLDAPFilter escapes a value that will be used in a filter clause
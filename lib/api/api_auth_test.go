@@ -7,12 +7,30 @@
 package api
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/syncthing/syncthing/internal/db"
 	"github.com/syncthing/syncthing/internal/db/sqlite"
+	"github.com/syncthing/syncthing/internal/gen/apiproto"
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
 )
 
 var guiCfg config.GUIConfiguration
@@ -192,3 +210,307 @@ func TestTokenManager(t *testing.T) {
 		t.Errorf("token %q should be invalid", t3)
 	}
 }
+
+func TestStaticAuthAccounts(t *testing.T) {
+	t.Parallel()
+
+	cfg := guiCfg.Copy()
+	cfg.Accounts = []config.GUIAccount{{Name: "helpdesk", Role: config.GUIRoleOperator}}
+	if err := cfg.Accounts[0].SetPassword("secret"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !authStatic("helpdesk", "secret", cfg) {
+		t.Error("account should pass auth")
+	}
+	if authStatic("helpdesk", "pass", cfg) {
+		t.Error("account with wrong password should fail auth")
+	}
+	if !authStatic("user", "pass", cfg) {
+		t.Error("main user should still pass auth")
+	}
+}
+
+func TestResolvePrincipal(t *testing.T) {
+	t.Parallel()
+
+	cfg := guiCfg.Copy()
+	cfg.Accounts = []config.GUIAccount{
+		{Name: "viewer", Role: config.GUIRoleViewer, Folders: []string{"a"}},
+		{Name: "admin", Role: config.GUIRoleAdmin, Folders: []string{"a"}},
+	}
+
+	cases := []struct {
+		owner   *apiproto.TokenOwner
+		ok      bool
+		role    config.GUIRole
+		folders []string
+	}{
+		{nil, true, config.GUIRoleAdmin, nil}, // legacy session
+		{&apiproto.TokenOwner{Name: "user"}, true, config.GUIRoleAdmin, nil},
+		{&apiproto.TokenOwner{Name: "viewer"}, true, config.GUIRoleViewer, []string{"a"}},
+		{&apiproto.TokenOwner{Name: "admin"}, true, config.GUIRoleAdmin, nil}, // admins aren't scoped
+		{&apiproto.TokenOwner{Name: "removed"}, false, 0, nil},
+	}
+	for _, tc := range cases {
+		p, ok := resolvePrincipal(tc.owner, cfg, config.LDAPConfiguration{}, config.OIDCConfiguration{})
+		if ok != tc.ok {
+			t.Errorf("%v: ok %v != expected %v", tc.owner, ok, tc.ok)
+			continue
+		}
+		if ok && (p.role != tc.role || !slices.Equal(p.folders, tc.folders)) {
+			t.Errorf("%v: got %v %v, expected %v %v", tc.owner, p.role, p.folders, tc.role, tc.folders)
+		}
+	}
+}
+
+func TestLDAPPrincipal(t *testing.T) {
+	t.Parallel()
+
+	groupRoles := []config.LDAPGroupRole{
+		{Group: "cn=helpdesk,dc=example,dc=com", Role: config.GUIRoleOperator, Folders: []string{"a"}},
+		{Group: "cn=support,dc=example,dc=com", Role: config.GUIRoleOperator, Folders: []string{"b"}},
+		{Group: "cn=staff,dc=example,dc=com", Role: config.GUIRoleViewer},
+		{Group: "cn=admins,dc=example,dc=com", Role: config.GUIRoleAdmin},
+	}
+
+	cases := []struct {
+		groups  []string
+		ok      bool
+		role    config.GUIRole
+		folders []string
+	}{
+		{nil, false, 0, nil},
+		{[]string{"cn=other,dc=example,dc=com"}, false, 0, nil},
+		{[]string{"cn=staff,dc=example,dc=com"}, true, config.GUIRoleViewer, nil},
+		{[]string{"CN=Helpdesk,DC=example,DC=com"}, true, config.GUIRoleOperator, []string{"a"}},
+		{[]string{"cn=helpdesk,dc=example,dc=com", "cn=support,dc=example,dc=com", "cn=staff,dc=example,dc=com"}, true, config.GUIRoleOperator, []string{"a", "b"}},
+		{[]string{"cn=helpdesk,dc=example,dc=com", "cn=admins,dc=example,dc=com"}, true, config.GUIRoleAdmin, nil},
+	}
+	for _, tc := range cases {
+		p, ok := ldapPrincipal("someone", tc.groups, groupRoles)
+		if ok != tc.ok {
+			t.Errorf("%v: ok %v != expected %v", tc.groups, ok, tc.ok)
+			continue
+		}
+		if ok && (p.role != tc.role || !slices.Equal(p.folders, tc.folders)) {
+			t.Errorf("%v: got %v %v, expected %v %v", tc.groups, p.role, p.folders, tc.role, tc.folders)
+		}
+	}
+
+	// Without group mappings, all LDAP users are administrators.
+	cfg := config.GUIConfiguration{AuthMode: config.AuthModeLDAP}
+	if p, ok := resolvePrincipal(&apiproto.TokenOwner{Name: "someone"}, cfg, config.LDAPConfiguration{}, config.OIDCConfiguration{}); !ok || !p.isAdmin() {
+		t.Error("LDAP user without group mappings should be admin")
+	}
+}
+
+func TestPolicyFor(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		method, path string
+		public       bool
+		role         config.GUIRole
+		folder       bool
+	}{
+		{"GET", "/rest/noauth/health", true, 0, false},
+		{"GET", "/rest/svc/lang", true, 0, false},
+		{"GET", "/rest/system/status", false, config.GUIRoleViewer, false},
+		{"GET", "/rest/db/status", false, config.GUIRoleViewer, true},
+		{"GET", "/rest/config/folders/:id", false, config.GUIRoleViewer, true},
+		{"GET", "/rest/config/gui", false, config.GUIRoleAdmin, false},
+		{"GET", "/rest/system/log.txt", false, config.GUIRoleAdmin, false},
+		{"GET", "/rest/debug/*method", false, config.GUIRoleAdmin, false},
+		{"POST", "/rest/system/ping", false, config.GUIRoleViewer, false},
+		{"POST", "/rest/db/scan", false, config.GUIRoleOperator, true},
+		{"POST", "/rest/system/pause", false, config.GUIRoleOperator, false},
+		{"POST", "/rest/db/ignores", false, config.GUIRoleAdmin, true},
+		{"PUT", "/rest/config/folders/:id", false, config.GUIRoleAdmin, true},
+		{"POST", "/rest/system/restart", false, config.GUIRoleAdmin, false},
+		{"DELETE", "/rest/cluster/pending/devices", false, config.GUIRoleAdmin, false},
+	}
+	for _, tc := range cases {
+		pol := policyFor(tc.method, tc.path)
+		if pol.public != tc.public || (!pol.public && pol.role != tc.role) || pol.folder != tc.folder {
+			t.Errorf("%s %s: got %+v", tc.method, tc.path, pol)
+		}
+	}
+}
+
+func TestAPITokenManager(t *testing.T) {
+	t.Parallel()
+
+	mdb, err := sqlite.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		mdb.Close()
+	})
+	kdb := db.NewMiscDB(mdb)
+	clock := &mockClock{now: time.Now()}
+
+	tm := newAPITokenManager(kdb)
+	tm.timeNow = clock.Now
+
+	monitoring, _, err := tm.Create("monitoring", apiTokenScopeEvents, []string{"a"}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	shortLived, _, err := tm.Create("short", apiTokenScopeRead, nil, clock.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := tm.Create("monitoring", apiTokenScopeRead, nil, time.Time{}); !errors.Is(err, errTokenExists) {
+		t.Error("expected error for duplicate name, got", err)
+	}
+	if _, _, err := tm.Create("bad", "root", nil, time.Time{}); !errors.Is(err, errTokenBadScope) {
+		t.Error("expected error for bad scope, got", err)
+	}
+
+	if !tm.IsValidAPIKey(monitoring) || !tm.IsValidAPIKey(shortLived) {
+		t.Fatal("tokens should be valid")
+	}
+	if tm.IsValidAPIKey(apiTokenPrefix + "nope") {
+		t.Error("unknown token should not be valid")
+	}
+
+	// Using a token records it and gives the scoped principal.
+	req := httptest.NewRequest(http.MethodGet, "/rest/events", nil)
+	req.Header.Set("Authorization", "Bearer "+monitoring)
+	p, ok := tm.authenticate(req, events.NoopLogger)
+	if !ok {
+		t.Fatal("token should authenticate")
+	}
+	if !p.eventsOnly || p.role != config.GUIRoleViewer || !slices.Equal(p.folders, []string{"a"}) {
+		t.Errorf("unexpected principal %+v", p)
+	}
+	list := tm.List()
+	if len(list) != 2 || list[0].Name != "monitoring" || list[0].LastUsed.IsZero() || list[0].LastAddress == "" {
+		t.Errorf("unexpected token list %+v", list)
+	}
+
+	// The short lived token expires.
+	clock.wind(2 * time.Hour)
+	if tm.IsValidAPIKey(shortLived) {
+		t.Error("expired token should not be valid")
+	}
+	if list := tm.List(); len(list) != 1 {
+		t.Errorf("expired token should be removed, got %+v", list)
+	}
+
+	if err := tm.Delete("monitoring"); err != nil {
+		t.Fatal(err)
+	}
+	if tm.IsValidAPIKey(monitoring) {
+		t.Error("revoked token should not be valid")
+	}
+}
+
+// newTestClientCert returns the file of a new CA, and a client certificate
+// issued by it for the given name.
+func newTestClientCert(t *testing.T, name string) (string, tls.Certificate) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(crand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, _ := x509.ParseCertificate(caDER)
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientTmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(crand.Reader, clientTmpl, caCert, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return caFile, tls.Certificate{Certificate: [][]byte{clientDER}, PrivateKey: clientKey}
+}
+
+func TestClientCertificates(t *testing.T) {
+	t.Parallel()
+
+	caFile, clientCert := newTestClientCert(t, "automation")
+	cfg := config.GUIConfiguration{
+		ClientCertMode: config.ClientCertModeRequired,
+		ClientCAFile:   caFile,
+		Accounts:       []config.GUIAccount{{Name: "automation", Role: config.GUIRoleOperator, Folders: []string{"default"}}},
+	}
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, _ := clientCertName(r)
+		p, ok := certPrincipal(name, cfg)
+		if !ok {
+			forbidden(w)
+			return
+		}
+		fmt.Fprintf(w, "%s %v %v", p.name, p.role, p.folders)
+	}))
+	srv.TLS = &tls.Config{}
+	if err := setClientCAs(srv.TLS, cfg); err != nil {
+		t.Fatal(err)
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	// With the certificate, the client is the account named in it.
+	client := srv.Client()
+	client.Transport.(*http.Transport).TLSClientConfig.Certificates = []tls.Certificate{clientCert}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bs, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(bs) != "automation operator [default]" {
+		t.Errorf("unexpected response %q", bs)
+	}
+
+	// Without one, the handshake fails.
+	client = srv.Client()
+	client.Transport.(*http.Transport).TLSClientConfig.Certificates = nil
+	client.Transport.(*http.Transport).DisableKeepAlives = true
+	if _, err := client.Get(srv.URL); err == nil {
+		t.Error("request without client certificate should fail")
+	}
+
+	// Names without an account have no access.
+	if _, ok := certPrincipal("someone", cfg); ok {
+		t.Error("unknown certificate name should have no access")
+	}
+
+	// A file without certificates is an error.
+	if err := os.WriteFile(caFile, []byte("nothing here"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := setClientCAs(&tls.Config{}, cfg); !errors.Is(err, errNoClientCAs) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
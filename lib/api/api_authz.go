@@ -0,0 +1,430 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/syncthing/syncthing/internal/gen/apiproto"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+// guiRoleNone is the role of unauthenticated requests, which may only
+// access the public routes.
+const guiRoleNone config.GUIRole = -1
+
+// A principal is the authenticated user of a request.
+type principal struct {
+	name    string
+	role    config.GUIRole
+	folders []string // nil means all folders
+	// eventsOnly limits the principal to the event and ping routes.
+	eventsOnly bool
+}
+
+var (
+	adminPrincipal     = &principal{role: config.GUIRoleAdmin}
+	anonymousPrincipal = &principal{role: guiRoleNone}
+)
+
+func (p *principal) isAdmin() bool {
+	return p.role >= config.GUIRoleAdmin
+}
+
+func (p *principal) canAccessFolder(folder string) bool {
+	return p.folders == nil || slices.Contains(p.folders, folder)
+}
+
+type principalKey struct{}
+
+func withPrincipal(r *http.Request, p *principal) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, p))
+}
+
+// principalFrom returns the principal of the request. Requests without
+// one come in when authentication is disabled, and have full access.
+func principalFrom(r *http.Request) *principal {
+	return principalFromContext(r.Context())
+}
+
+func principalFromContext(ctx context.Context) *principal {
+	if p, ok := ctx.Value(principalKey{}).(*principal); ok {
+		return p
+	}
+	return adminPrincipal
+}
+
+// resolvePrincipal returns the principal for the given token owner
+// according to the current configuration, or false if the owner no longer
+// has access. A nil owner is a session from before owners were recorded,
+// which belongs to the single configured user.
+func resolvePrincipal(owner *apiproto.TokenOwner, guiCfg config.GUIConfiguration, ldapCfg config.LDAPConfiguration, oidcCfg config.OIDCConfiguration) (*principal, bool) {
+	switch guiCfg.AuthMode {
+	case config.AuthModeOIDC:
+		return oidcPrincipal(owner, oidcCfg)
+	case config.AuthModeLDAP:
+		if len(ldapCfg.GroupRoles) == 0 {
+			// Without group mappings all LDAP users are administrators,
+			// as before.
+			name := ""
+			if owner != nil {
+				name = owner.GetName()
+			}
+			return &principal{name: name, role: config.GUIRoleAdmin}, true
+		}
+		if owner == nil {
+			return nil, false
+		}
+		return ldapPrincipal(owner.GetName(), owner.GetGroups(), ldapCfg.GroupRoles)
+	}
+
+	if owner == nil || owner.GetName() == guiCfg.User {
+		if guiCfg.User == "" || guiCfg.Password == "" {
+			return nil, false
+		}
+		return &principal{name: guiCfg.User, role: config.GUIRoleAdmin}, true
+	}
+	acc, ok := guiCfg.Account(owner.GetName())
+	if !ok {
+		return nil, false
+	}
+	return rolePrincipal(acc.Name, acc.Role, acc.Folders), true
+}
+
+// ldapPrincipal maps the groups of an LDAP user to a principal. Users in
+// none of the mapped groups have no access.
+func ldapPrincipal(name string, groups []string, groupRoles []config.LDAPGroupRole) (*principal, bool) {
+	mappings := make([]roleMapping, len(groupRoles))
+	for i, gr := range groupRoles {
+		mappings[i] = roleMapping{member: gr.Group, role: gr.Role, folders: gr.Folders}
+	}
+	return mappedPrincipal(name, groups, mappings, strings.EqualFold)
+}
+
+// A roleMapping grants a role on some folders to the members of a group.
+type roleMapping struct {
+	member  string
+	role    config.GUIRole
+	folders []string
+}
+
+// mappedPrincipal returns the principal for a user with the given
+// memberships. The user gets the highest role granted by any of the
+// mappings they match, scoped to the folders of the mappings granting that
+// role. Users matching no mapping have no access.
+func mappedPrincipal(name string, memberships []string, mappings []roleMapping, equal func(a, b string) bool) (*principal, bool) {
+	var matched []roleMapping
+	for _, m := range mappings {
+		if slices.ContainsFunc(memberships, func(g string) bool { return equal(g, m.member) }) {
+			matched = append(matched, m)
+		}
+	}
+	if len(matched) == 0 {
+		return nil, false
+	}
+
+	role := config.GUIRoleViewer
+	for _, m := range matched {
+		role = max(role, m.role)
+	}
+	folders := []string{}
+	for _, m := range matched {
+		if m.role != role {
+			continue
+		}
+		if len(m.folders) == 0 {
+			folders = nil
+			break
+		}
+		for _, f := range m.folders {
+			if !slices.Contains(folders, f) {
+				folders = append(folders, f)
+			}
+		}
+	}
+	return rolePrincipal(name, role, folders), true
+}
+
+func rolePrincipal(name string, role config.GUIRole, folders []string) *principal {
+	if role >= config.GUIRoleAdmin || len(folders) == 0 {
+		// Administrators can't be meaningfully limited to folders, as
+		// they can change the configuration.
+		return &principal{name: name, role: role}
+	}
+	return &principal{name: name, role: role, folders: slices.Clone(folders)}
+}
+
+// A routePolicy describes who may access a route.
+type routePolicy struct {
+	public bool
+	role   config.GUIRole
+	// folder is set for routes operating on a single folder, given by the
+	// "folder" query parameter or the "id" path parameter.
+	folder bool
+	// events is set for the routes available to events only principals.
+	events bool
+	// unscoped is set for routes affecting more than folders, which
+	// principals limited to some folders may not use.
+	unscoped bool
+}
+
+// Non-GET routes that operators may use. All other non-GET routes require
+// an administrator.
+var operatorRoutes = []string{
+	"POST /rest/db/prio",
+	"POST /rest/db/scan",
+	"POST /rest/system/error/clear",
+	"POST /rest/system/pause",
+	"POST /rest/system/resume",
+}
+
+// GET routes that only administrators may use, by prefix. All other GET
+// routes are available to viewers.
+var adminReadPrefixes = []string{
+	"/rest/config/gui",
+	"/rest/config/history",
+	"/rest/config/ldap",
+	"/rest/config/oidc",
+	"/rest/debug/",
+	"/rest/system/browse",
+	"/rest/system/log", // also log.txt
+	"/rest/webhooks/",
+}
+
+// Routes available to principals limited to events.
+var eventRoutes = []string{
+	"GET /rest/events",
+	"GET /rest/events/disk",
+	"GET /rest/events/stream",
+	"GET /rest/system/ping",
+	"POST /rest/system/ping",
+}
+
+// Operator routes that act on devices, not limited to any folder.
+var unscopedRoutes = []string{
+	"POST /rest/system/pause",
+	"POST /rest/system/resume",
+}
+
+// Routes operating on a single folder, by prefix.
+var folderPrefixes = []string{
+	"/rest/cluster/pending/folders",
+	"/rest/config/folders/:id",
+	"/rest/db/",
+	"/rest/folder/",
+	"/rest/system/reset",
+}
+
+// policyFor returns the access policy for a route. Anything not explicitly
+// allowed requires an administrator.
+func policyFor(method, path string) routePolicy {
+	if strings.HasPrefix(path, "/rest/noauth/") || path == "/rest/svc/lang" {
+		return routePolicy{public: true}
+	}
+
+	if strings.HasPrefix(path, rpcPrefix+"/") {
+		// The control service checks each call itself.
+		return routePolicy{role: config.GUIRoleViewer, events: true}
+	}
+
+	pol := routePolicy{role: config.GUIRoleAdmin}
+	switch {
+	case method == http.MethodGet:
+		if !hasAnyPrefix(path, adminReadPrefixes) {
+			pol.role = config.GUIRoleViewer
+		}
+	case method == http.MethodPost && path == "/rest/system/ping":
+		pol.role = config.GUIRoleViewer
+	case slices.Contains(operatorRoutes, method+" "+path):
+		pol.role = config.GUIRoleOperator
+	}
+	pol.folder = hasAnyPrefix(path, folderPrefixes)
+	pol.events = slices.Contains(eventRoutes, method+" "+path)
+	pol.unscoped = slices.Contains(unscopedRoutes, method+" "+path)
+	return pol
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	return slices.ContainsFunc(prefixes, func(prefix string) bool {
+		return strings.HasPrefix(s, prefix)
+	})
+}
+
+// allowed returns the HTTP status to fail the request with, or zero if
+// the principal may use the route.
+func (pol routePolicy) allowed(p *principal, r *http.Request, params httprouter.Params) int {
+	if pol.public {
+		return 0
+	}
+	if p.role == guiRoleNone {
+		return http.StatusUnauthorized
+	}
+	if p.role < pol.role || (p.eventsOnly && !pol.events) {
+		return http.StatusForbidden
+	}
+	if pol.unscoped && p.folders != nil {
+		return http.StatusForbidden
+	}
+	if pol.folder && p.folders != nil {
+		folder := params.ByName("id")
+		if folder == "" {
+			folder = r.URL.Query().Get("folder")
+		}
+		if folder == "" || !p.canAccessFolder(folder) {
+			return http.StatusForbidden
+		}
+	}
+	return 0
+}
+
+// An authzRouter is an httprouter.Router that enforces the access policy
+// of each route registered with it.
+type authzRouter struct {
+	*httprouter.Router
+}
+
+func newAuthzRouter() *authzRouter {
+	return &authzRouter{Router: httprouter.New()}
+}
+
+func (a *authzRouter) Handle(method, path string, handle httprouter.Handle) {
+	pol := policyFor(method, path)
+	a.Router.Handle(method, path, func(w http.ResponseWriter, r *http.Request, params httprouter.Params) {
+		if status := pol.allowed(principalFrom(r), r, params); status != 0 {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		handle(w, r, params)
+	})
+}
+
+func (a *authzRouter) Handler(method, path string, handler http.Handler) {
+	pol := policyFor(method, path)
+	a.Router.Handler(method, path, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if status := pol.allowed(principalFrom(r), r, httprouter.ParamsFromContext(r.Context())); status != 0 {
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+}
+
+func (a *authzRouter) HandlerFunc(method, path string, handler http.HandlerFunc) {
+	a.Handler(method, path, handler)
+}
+
+// redactConfig removes secrets from the configuration, and the folders the
+// principal may not see, unless the principal is an administrator.
+func redactConfig(p *principal, cfg config.Configuration) config.Configuration {
+	if p.isAdmin() {
+		return cfg
+	}
+	cfg = redactSecrets(cfg)
+	cfg.Folders = filterFolders(p, cfg.Folders)
+	return cfg
+}
+
+// redactSecrets removes the passwords, keys and other secrets from the
+// configuration.
+func redactSecrets(cfg config.Configuration) config.Configuration {
+	cfg.GUI = cfg.GUI.Copy()
+	cfg.GUI.Password = ""
+	cfg.GUI.APIKey = ""
+	cfg.OIDC.ClientSecret = ""
+	cfg.Webhooks = slices.Clone(cfg.Webhooks)
+	for i := range cfg.Webhooks {
+		cfg.Webhooks[i].Secret = ""
+	}
+	for i := range cfg.GUI.Accounts {
+		cfg.GUI.Accounts[i].Password = ""
+	}
+	cfg.Folders = slices.Clone(cfg.Folders)
+	for i := range cfg.Folders {
+		cfg.Folders[i] = redactFolder(cfg.Folders[i])
+	}
+	cfg.Defaults.Folder = redactFolder(cfg.Defaults.Folder)
+	return cfg
+}
+
+// redactFolder removes the passwords that protect the folder's data on
+// untrusted devices.
+func redactFolder(f config.FolderConfiguration) config.FolderConfiguration {
+	f = f.Copy()
+	for i := range f.Devices {
+		f.Devices[i].EncryptionPassword = ""
+	}
+	return f
+}
+
+// redactFolders removes the folders the principal may not see, and the
+// secrets of the others, unless the principal is an administrator.
+func redactFolders(p *principal, folders []config.FolderConfiguration) []config.FolderConfiguration {
+	if p.isAdmin() {
+		return folders
+	}
+	folders = filterFolders(p, folders)
+	res := make([]config.FolderConfiguration, len(folders))
+	for i, f := range folders {
+		res[i] = redactFolder(f)
+	}
+	return res
+}
+
+func filterFolders(p *principal, folders []config.FolderConfiguration) []config.FolderConfiguration {
+	if p.folders == nil {
+		return folders
+	}
+	var res []config.FolderConfiguration
+	for _, f := range folders {
+		if p.canAccessFolder(f.ID) {
+			res = append(res, f)
+		}
+	}
+	return res
+}
+
+// filterEvents removes the events about folders the principal may not see
+// and the audit records of API requests, and redacts the configuration in
+// ConfigSaved events, for non-administrators.
+func filterEvents(p *principal, evs []events.Event) []events.Event {
+	if p.isAdmin() {
+		return evs
+	}
+	res := make([]events.Event, 0, len(evs))
+	for _, ev := range evs {
+		if ev.Type == events.APIRequest {
+			// Configuration changes may be about anything.
+			continue
+		}
+		if cfg, ok := ev.Data.(config.Configuration); ok {
+			ev.Data = redactConfig(p, cfg.Copy())
+		}
+		if folder, ok := eventFolder(ev); ok && !p.canAccessFolder(folder) {
+			continue
+		}
+		res = append(res, ev)
+	}
+	return res
+}
+
+func eventFolder(ev events.Event) (string, bool) {
+	switch data := ev.Data.(type) {
+	case map[string]interface{}:
+		folder, ok := data["folder"].(string)
+		return folder, ok
+	case map[string]string:
+		folder, ok := data["folder"]
+		return folder, ok
+	}
+	return "", false
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/locations"
+)
+
+var errNoClientCAs = errors.New("no certificates found in client CA file")
+
+// setClientCAs makes the TLS config ask for client certificates and verify
+// them against the configured CA bundle. The bundle is read once, when the
+// listener is created.
+func setClientCAs(tlsCfg *tls.Config, guiCfg config.GUIConfiguration) error {
+	path := guiCfg.ClientCAFile
+	if path == "" {
+		return errors.New("no client CA file configured")
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(locations.GetBaseDir(locations.ConfigBaseDir), path)
+	}
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(bs) {
+		return fmt.Errorf("%w: %s", errNoClientCAs, path)
+	}
+
+	tlsCfg.ClientCAs = pool
+	if guiCfg.ClientCertMode == config.ClientCertModeRequired {
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return nil
+}
+
+// clientCertName returns the user name from the verified client certificate
+// of the request: the subject common name, or the first email address when
+// there is no common name.
+func clientCertName(r *http.Request) (string, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return "", false
+	}
+	cert := r.TLS.VerifiedChains[0][0]
+	if cert.Subject.CommonName != "" {
+		return cert.Subject.CommonName, true
+	}
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0], true
+	}
+	return "", false
+}
+
+// certPrincipal returns the principal for the user named in a client
+// certificate: the configured user is an administrator, and accounts have
+// their configured role. The certificate replaces the password, so other
+// names have no access.
+func certPrincipal(name string, guiCfg config.GUIConfiguration) (*principal, bool) {
+	if name == "" {
+		return nil, false
+	}
+	if name == guiCfg.User {
+		return &principal{name: name, role: config.GUIRoleAdmin}, true
+	}
+	acc, ok := guiCfg.Account(name)
+	if !ok {
+		return nil, false
+	}
+	return rolePrincipal(acc.Name, acc.Role, acc.Folders), true
+}
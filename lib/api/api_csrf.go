@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/lib/config"
 )
 
 const (
@@ -23,6 +24,7 @@ type csrfManager struct {
 	unique          string
 	prefix          string
 	apiKeyValidator apiKeyValidator
+	guiCfg          config.GUIConfiguration
 	next            http.Handler
 	tokens          *tokenManager
 }
@@ -34,11 +36,12 @@ type apiKeyValidator interface {
 // Check for CSRF token on /rest/ URLs. If a correct one is not given, reject
 // the request with 403. For / and /index.html, set a new CSRF cookie if none
 // is currently set.
-func newCsrfManager(unique string, prefix string, apiKeyValidator apiKeyValidator, next http.Handler, miscDB *db.Typed) *csrfManager {
+func newCsrfManager(unique string, prefix string, apiKeyValidator apiKeyValidator, guiCfg config.GUIConfiguration, next http.Handler, miscDB *db.Typed) *csrfManager {
 	m := &csrfManager{
 		unique:          unique,
 		prefix:          prefix,
 		apiKeyValidator: apiKeyValidator,
+		guiCfg:          guiCfg,
 		next:            next,
 		tokens:          newTokenManager("csrfTokens", miscDB, maxCSRFTokenLifetime, maxActiveCSRFTokens),
 	}
@@ -55,6 +58,15 @@ func (m *csrfManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Likewise requests authenticated by a client certificate, unless a
+	// browser may be sending it along on behalf of another site.
+	if name, ok := clientCertName(r); ok && !isBrowserCrossSite(r) {
+		if _, ok := certPrincipal(name, m.guiCfg); ok {
+			m.next.ServeHTTP(w, r)
+			return
+		}
+	}
+
 	if strings.HasPrefix(r.URL.Path, "/rest/debug") {
 		// Debugging functions are only available when explicitly
 		// enabled, and can be accessed without a CSRF token
@@ -95,6 +107,21 @@ func (m *csrfManager) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	m.next.ServeHTTP(w, r)
 }
 
+// isBrowserCrossSite returns whether the request may have been made by a
+// browser on behalf of another site. Browsers send client certificates by
+// themselves, so only requests that the browser says are its own, or that
+// carry nothing a browser would send, are taken as made on purpose.
+func isBrowserCrossSite(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none":
+		return false
+	case "":
+		return len(r.Cookies()) > 0 || r.Header.Get("Origin") != "" || r.Header.Get("Referer") != ""
+	default:
+		return true
+	}
+}
+
 func hasValidAPIKeyHeader(r *http.Request, validator apiKeyValidator) bool {
 	if key := r.Header.Get("X-API-Key"); validator.IsValidAPIKey(key) {
 		return true
@@ -105,3 +132,15 @@ func hasValidAPIKeyHeader(r *http.Request, validator apiKeyValidator) bool {
 	}
 	return false
 }
+
+// apiKeyFromRequest returns the API key given in the X-API-Key header or as
+// a bearer token, if any.
+func apiKeyFromRequest(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(strings.ToLower(auth), "bearer ") {
+		return auth[len("bearer "):]
+	}
+	return ""
+}
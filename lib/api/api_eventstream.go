@@ -0,0 +1,199 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+// getEventStream streams events as server-sent events, filtered by the
+// "events" mask and optional "folder" and "device" parameters. Each event
+// has its ID as the SSE id, so clients can resume with the Last-Event-ID
+// header or the "since" parameter. When events between the requested ID
+// and the oldest one still buffered were lost, a "gap" event with the
+// range of missing IDs comes first. When the requested ID is newer than
+// any we have, as the IDs start over when Syncthing restarts, a "reset"
+// event with the newest ID comes first and the stream continues from
+// there. Quiet streams get a keepalive comment now and then.
+func (s *service) getEventStream(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	sub := s.getEventSub(s.getEventMask(qs.Get("events")))
+
+	since := -1
+	if id, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil {
+		since = id
+	} else if id, err := strconv.Atoi(qs.Get("since")); err == nil {
+		since = id
+	}
+
+	// The stream outlives the server's read timeout.
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	_ = s.streamEvents(r.Context(), principalFrom(r), sub, since, qs.Get("folder"), qs.Get("device"), &sseOutput{w: w, rc: rc})
+}
+
+// An eventStreamOutput receives what streamEvents produces.
+type eventStreamOutput interface {
+	// gap is called when the events from and to, inclusive, were lost.
+	gap(from, to int) error
+	// reset is called when the requested event is newer than any we have,
+	// with the ID the stream continues after.
+	reset(last int) error
+	event(ev events.Event) error
+	// flush is called after each batch of events with the ID of the last
+	// one, filtered or not, and with quiet set when there were none.
+	flush(last int, quiet bool) error
+}
+
+// streamEvents passes the events of the subscription after since (or the
+// events from now on, if since is negative) to the output, until the
+// context is cancelled or the output fails. Only the events the principal
+// may see and that are about the given folder and device, when set, are
+// passed on.
+func (s *service) streamEvents(ctx context.Context, p *principal, sub events.BufferedSubscription, since int, folder, device string, out eventStreamOutput) error {
+	newest := 0
+	for _, ev := range sub.Since(0, nil, 0) {
+		newest = max(newest, ev.SubscriptionID)
+	}
+	if since < 0 {
+		// Start with the next event.
+		since = newest
+	} else if since > newest {
+		// The client saw events of an earlier run; waiting for the IDs
+		// to catch up would lose everything until then.
+		if err := out.reset(newest); err != nil {
+			return err
+		}
+		since = newest
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		evs := sub.Since(since, nil, s.eventStreamKeepalive)
+		if len(evs) == 0 {
+			if err := out.flush(since, true); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if first := evs[0].SubscriptionID; first > since+1 {
+			if err := out.gap(since+1, first-1); err != nil {
+				return err
+			}
+		}
+		since = evs[len(evs)-1].SubscriptionID
+
+		for _, ev := range filterEvents(p, evs) {
+			if !eventMatches(ev, folder, device) {
+				continue
+			}
+			if err := out.event(ev); err != nil {
+				return err
+			}
+		}
+		if err := out.flush(since, false); err != nil {
+			return err
+		}
+	}
+}
+
+type sseOutput struct {
+	w  io.Writer
+	rc *http.ResponseController
+}
+
+func (o *sseOutput) gap(from, to int) error {
+	bs, _ := json.Marshal(map[string]int{"from": from, "to": to})
+	_, err := fmt.Fprintf(o.w, "event: gap\ndata: %s\n\n", bs)
+	return err
+}
+
+func (o *sseOutput) reset(last int) error {
+	bs, _ := json.Marshal(map[string]int{"id": last})
+	if _, err := fmt.Fprintf(o.w, "event: reset\ndata: %s\nid: %d\n\n", bs, last); err != nil {
+		return err
+	}
+	return o.rc.Flush()
+}
+
+func (o *sseOutput) event(ev events.Event) error {
+	bs, err := json.Marshal(ev)
+	if err != nil {
+		return nil // skip it
+	}
+	_, err = fmt.Fprintf(o.w, "id: %d\ndata: %s\n\n", ev.SubscriptionID, bs)
+	return err
+}
+
+func (o *sseOutput) flush(last int, quiet bool) error {
+	var err error
+	if quiet {
+		_, err = fmt.Fprint(o.w, ": keepalive\n\n")
+	} else {
+		// Let a client resuming after a quiet filtered period skip what
+		// it didn't need.
+		_, err = fmt.Fprintf(o.w, "id: %d\n\n", last)
+	}
+	if err != nil {
+		return err
+	}
+	return o.rc.Flush()
+}
+
+// eventMatches returns true if the event is about the given folder and
+// device, when set.
+func eventMatches(ev events.Event, folder, device string) bool {
+	if folder != "" {
+		if f, ok := eventFolder(ev); !ok || f != folder {
+			return false
+		}
+	}
+	if device != "" {
+		if d, ok := eventDevice(ev); !ok || d != device {
+			return false
+		}
+	}
+	return true
+}
+
+func eventDevice(ev events.Event) (string, bool) {
+	switch data := ev.Data.(type) {
+	case map[string]interface{}:
+		if dev, ok := data["device"].(string); ok {
+			return dev, true
+		}
+		dev, ok := data["id"].(string) // device connection events
+		return dev, ok
+	case map[string]string:
+		if dev, ok := data["device"]; ok {
+			return dev, true
+		}
+		dev, ok := data["id"]
+		return dev, ok
+	}
+	return "", false
+}
@@ -0,0 +1,541 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/syncthing/syncthing/internal/gen/apiproto"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+const (
+	oidcLoginPath    = "/rest/noauth/auth/oidc/login"
+	oidcCallbackPath = "/rest/noauth/auth/oidc/callback"
+	oidcLoginTimeout = 10 * time.Minute
+	oidcHTTPTimeout  = 15 * time.Second
+	oidcMaxResponse  = 1 << 20
+)
+
+var errOIDCKeyNotFound = errors.New("signing key not found")
+
+// oidcDiscovery is the part of the issuer's OpenID provider metadata that
+// we need.
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// An oidcLogin is an authorization code flow in progress. It's kept in a
+// signed cookie in the user's browser until the issuer redirects back, so
+// that the callback can only complete a login started by the same browser.
+type oidcLogin struct {
+	State       string    `json:"state"`
+	Nonce       string    `json:"nonce"`
+	Verifier    string    `json:"verifier"` // PKCE code verifier
+	RedirectURL string    `json:"redirectURL"`
+	Persistent  bool      `json:"persistent"`
+	Expires     time.Time `json:"expires"`
+}
+
+// The oidcHandler implements the OpenID Connect authorization code flow,
+// creating a session for the user when it completes.
+type oidcHandler struct {
+	cfg      config.OIDCConfiguration
+	cookies  *tokenCookieManager
+	evLogger events.Logger
+	client   *http.Client
+	timeNow  func() time.Time // can be overridden for testing
+
+	loginKey []byte // signs the login cookies
+
+	mut       sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]any // key ID -> public key
+}
+
+func newOIDCHandler(cfg config.OIDCConfiguration, cookies *tokenCookieManager, evLogger events.Logger) *oidcHandler {
+	return &oidcHandler{
+		cfg:      cfg,
+		cookies:  cookies,
+		evLogger: evLogger,
+		client:   &http.Client{Timeout: oidcHTTPTimeout},
+		timeNow:  time.Now,
+		loginKey: []byte(rand.String(randomTokenLength)),
+	}
+}
+
+// handleLogin redirects to the issuer to authenticate the user.
+func (h *oidcHandler) handleLogin(w http.ResponseWriter, r *http.Request) {
+	disco, err := h.getDiscovery(r.Context())
+	if err != nil {
+		slog.Error("Failed to get OpenID Connect provider metadata", slogutil.URI(h.cfg.IssuerURL()), slogutil.Error(err))
+		http.Error(w, "Single sign-on is unavailable", http.StatusBadGateway)
+		return
+	}
+
+	login := oidcLogin{
+		State:       rand.String(randomTokenLength),
+		Nonce:       rand.String(randomTokenLength),
+		Verifier:    rand.String(randomTokenLength),
+		RedirectURL: h.redirectURL(r),
+		Persistent:  r.URL.Query().Get("stayLoggedIn") == "true",
+		Expires:     h.timeNow().Add(oidcLoginTimeout),
+	}
+	if err := h.setLoginCookie(w, r, login); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	challenge := sha256.Sum256([]byte(login.Verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {h.cfg.ClientID},
+		"redirect_uri":          {login.RedirectURL},
+		"scope":                 {strings.Join(h.cfg.RequestScopes(), " ")},
+		"state":                 {login.State},
+		"nonce":                 {login.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(disco.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, disco.AuthorizationEndpoint+sep+q.Encode(), http.StatusSeeOther)
+}
+
+// handleCallback completes the flow when the issuer redirects the user
+// back to us, and creates the session.
+func (h *oidcHandler) handleCallback(w http.ResponseWriter, r *http.Request) {
+	qs := r.URL.Query()
+	login, ok := h.takeLoginCookie(w, r, qs.Get("state"))
+	if !ok {
+		http.Error(w, "Unknown or expired login, please try again", http.StatusBadRequest)
+		return
+	}
+	if e := qs.Get("error"); e != "" {
+		slog.Warn("OpenID Connect login failed at issuer", slog.String("error", e), slog.String("description", qs.Get("error_description")))
+		forbidden(w)
+		return
+	}
+
+	owner, err := h.exchange(r.Context(), qs.Get("code"), login)
+	if err != nil {
+		slog.Warn("OpenID Connect login failed", slogutil.Error(err))
+		emitLoginAttempt(false, "", r, h.evLogger)
+		forbidden(w)
+		return
+	}
+	if _, ok := oidcPrincipal(owner, h.cfg); !ok {
+		slog.Warn("User has no access to the GUI", slog.String("username", owner.GetName()))
+		emitLoginAttempt(false, owner.GetName(), r, h.evLogger)
+		forbidden(w)
+		return
+	}
+
+	h.cookies.createSession(owner, login.Persistent, w, r)
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// exchange redeems the authorization code for an ID token, verifies it
+// and returns the session owner described by its claims.
+func (h *oidcHandler) exchange(ctx context.Context, code string, login oidcLogin) (*apiproto.TokenOwner, error) {
+	disco, err := h.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {login.RedirectURL},
+		"code_verifier": {login.Verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, disco.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(h.cfg.ClientID), url.QueryEscape(h.cfg.ClientSecret))
+	var tokenResp struct {
+		IDToken string `json:"id_token"`
+	}
+	if err := h.doJSON(req, &tokenResp); err != nil {
+		return nil, fmt.Errorf("token request: %w", err)
+	}
+	if tokenResp.IDToken == "" {
+		return nil, errors.New("token response has no ID token")
+	}
+
+	claims, err := h.verify(ctx, tokenResp.IDToken, disco.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("verifying ID token: %w", err)
+	}
+	if nonce, _ := claims["nonce"].(string); nonce != login.Nonce {
+		return nil, errors.New("ID token nonce mismatch")
+	}
+	return h.owner(claims)
+}
+
+// verify checks the signature and standard claims of the ID token.
+func (h *oidcHandler) verify(ctx context.Context, idToken, issuer string) (jwt.MapClaims, error) {
+	parse := func() (jwt.MapClaims, error) {
+		claims := jwt.MapClaims{}
+		_, err := jwt.ParseWithClaims(idToken, claims, h.keyFunc,
+			jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}),
+			jwt.WithIssuer(issuer),
+			jwt.WithAudience(h.cfg.ClientID),
+			jwt.WithExpirationRequired(),
+			jwt.WithTimeFunc(h.timeNow),
+			jwt.WithLeeway(time.Minute),
+		)
+		return claims, err
+	}
+	claims, err := parse()
+	if errors.Is(err, errOIDCKeyNotFound) {
+		// The issuer may have rotated its keys.
+		if err := h.refreshKeys(ctx); err != nil {
+			return nil, err
+		}
+		claims, err = parse()
+	}
+	return claims, err
+}
+
+func (h *oidcHandler) keyFunc(tok *jwt.Token) (any, error) {
+	kid, _ := tok.Header["kid"].(string)
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	if key, ok := h.keys[kid]; ok {
+		return key, nil
+	}
+	if kid == "" && len(h.keys) == 1 {
+		for _, key := range h.keys {
+			return key, nil
+		}
+	}
+	return nil, errOIDCKeyNotFound
+}
+
+// owner returns the session owner for the claims. The owner's groups are
+// the claim values relevant for access and role mapping, as claim=value.
+func (h *oidcHandler) owner(claims jwt.MapClaims) (*apiproto.TokenOwner, error) {
+	name, _ := claims[h.cfg.UsernameClaimName()].(string)
+	if name == "" {
+		name, _ = claims["sub"].(string)
+	}
+	if name == "" {
+		return nil, errors.New("ID token has no user name")
+	}
+
+	owner := &apiproto.TokenOwner{Name: name}
+	seen := make(map[string]bool)
+	addClaim := func(claim string) {
+		if seen[claim] {
+			return
+		}
+		seen[claim] = true
+		for _, v := range claimValues(claims[claim]) {
+			owner.Groups = append(owner.Groups, claim+"="+v)
+		}
+	}
+	addClaim(h.cfg.GroupsClaimName())
+	for _, cr := range h.cfg.ClaimRoles {
+		addClaim(cr.Claim)
+	}
+	return owner, nil
+}
+
+// claimValues returns a claim value, or the values of a list claim, as
+// strings.
+func claimValues(v any) []string {
+	switch v := v.(type) {
+	case string:
+		return []string{v}
+	case bool:
+		return []string{strconv.FormatBool(v)}
+	case float64:
+		return []string{strconv.FormatFloat(v, 'f', -1, 64)}
+	case []any:
+		var res []string
+		for _, e := range v {
+			res = append(res, claimValues(e)...)
+		}
+		return res
+	}
+	return nil
+}
+
+// oidcPrincipal returns the principal for an OpenID Connect session owner,
+// or false if the user may not log in according to the configuration.
+func oidcPrincipal(owner *apiproto.TokenOwner, cfg config.OIDCConfiguration) (*principal, bool) {
+	if owner == nil {
+		return nil, false
+	}
+	name := owner.GetName()
+	if len(cfg.AllowedUsers) > 0 || len(cfg.AllowedGroups) > 0 {
+		allowed := slices.Contains(cfg.AllowedUsers, name) ||
+			slices.ContainsFunc(cfg.AllowedGroups, func(g string) bool {
+				return slices.Contains(owner.GetGroups(), cfg.GroupsClaimName()+"="+g)
+			})
+		if !allowed {
+			return nil, false
+		}
+	}
+	if len(cfg.ClaimRoles) == 0 {
+		return &principal{name: name, role: config.GUIRoleAdmin}, true
+	}
+	mappings := make([]roleMapping, len(cfg.ClaimRoles))
+	for i, cr := range cfg.ClaimRoles {
+		mappings[i] = roleMapping{member: cr.Claim + "=" + cr.Value, role: cr.Role, folders: cr.Folders}
+	}
+	return mappedPrincipal(name, owner.GetGroups(), mappings, func(a, b string) bool { return a == b })
+}
+
+func (h *oidcHandler) getDiscovery(ctx context.Context) (*oidcDiscovery, error) {
+	h.mut.Lock()
+	disco := h.discovery
+	h.mut.Unlock()
+	if disco != nil {
+		return disco, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.cfg.IssuerURL()+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	disco = new(oidcDiscovery)
+	if err := h.doJSON(req, disco); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(disco.Issuer, "/") != h.cfg.IssuerURL() {
+		return nil, fmt.Errorf("issuer mismatch in provider metadata: %q", disco.Issuer)
+	}
+	if disco.AuthorizationEndpoint == "" || disco.TokenEndpoint == "" || disco.JWKSURI == "" {
+		return nil, errors.New("incomplete provider metadata")
+	}
+
+	h.mut.Lock()
+	h.discovery = disco
+	h.mut.Unlock()
+	return disco, nil
+}
+
+func (h *oidcHandler) refreshKeys(ctx context.Context) error {
+	disco, err := h.getDiscovery(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, disco.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := h.doJSON(req, &set); err != nil {
+		return fmt.Errorf("fetching signing keys: %w", err)
+	}
+	keys := make(map[string]any, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			slog.Debug("Skipping unusable signing key", slog.String("kid", k.Kid), slogutil.Error(err))
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	h.mut.Lock()
+	h.keys = keys
+	h.mut.Unlock()
+	return nil
+}
+
+func (h *oidcHandler) doJSON(req *http.Request, into any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	bs, err := io.ReadAll(io.LimitReader(resp.Body, oidcMaxResponse))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(bs)))
+	}
+	return json.Unmarshal(bs, into)
+}
+
+// redirectURL returns the configured callback URL, or the one matching the
+// address the GUI is accessed on.
+func (h *oidcHandler) redirectURL(r *http.Request) string {
+	if h.cfg.RedirectURL != "" {
+		return h.cfg.RedirectURL
+	}
+	scheme := "http"
+	if r.TLS != nil || strings.EqualFold(r.Header.Get("X-Forwarded-Proto"), "https") {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + oidcCallbackPath
+}
+
+func (h *oidcHandler) loginCookieName() string {
+	return "oidc-" + h.cookies.cookieName
+}
+
+// setLoginCookie stores the login in a short lived cookie, only sent back
+// to the callback.
+func (h *oidcHandler) setLoginCookie(w http.ResponseWriter, r *http.Request, login oidcLogin) error {
+	bs, err := json.Marshal(login)
+	if err != nil {
+		return err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(bs)
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.loginCookieName(),
+		Value:    payload + "." + base64.RawURLEncoding.EncodeToString(h.loginMAC(payload)),
+		Path:     oidcCallbackPath,
+		MaxAge:   int(oidcLoginTimeout.Seconds()),
+		Secure:   h.cookies.useSecureCookie(r),
+		HttpOnly: true,
+		// Lax, as the cookie must be sent on the redirect from the issuer.
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// takeLoginCookie returns the login stored in the request's cookie, if it
+// is valid and matches the state, and clears the cookie.
+func (h *oidcHandler) takeLoginCookie(w http.ResponseWriter, r *http.Request, state string) (oidcLogin, bool) {
+	cookie, err := r.Cookie(h.loginCookieName())
+	if err != nil {
+		return oidcLogin{}, false
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     h.loginCookieName(),
+		Path:     oidcCallbackPath,
+		MaxAge:   -1,
+		Secure:   h.cookies.useSecureCookie(r),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	payload, mac, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return oidcLogin{}, false
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(mac)
+	if err != nil || !hmac.Equal(sig, h.loginMAC(payload)) {
+		return oidcLogin{}, false
+	}
+	bs, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return oidcLogin{}, false
+	}
+	var login oidcLogin
+	if err := json.Unmarshal(bs, &login); err != nil {
+		return oidcLogin{}, false
+	}
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(login.State)) != 1 {
+		return oidcLogin{}, false
+	}
+	return login, login.Expires.After(h.timeNow())
+}
+
+func (h *oidcHandler) loginMAC(payload string) []byte {
+	mac := hmac.New(sha256.New, h.loginKey)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// A jsonWebKey is an RSA or EC public key from the issuer's key set.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !curve.IsOnCurve(key.X, key.Y) {
+			return nil, errors.New("point not on curve")
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
@@ -0,0 +1,294 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/db/sqlite"
+	"github.com/syncthing/syncthing/internal/gen/apiproto"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+// mockIssuer is a minimal OpenID Connect provider issuing ID tokens with
+// the given claims.
+type mockIssuer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	claims    jwt.MapClaims
+	nonce     string
+	challenge string
+}
+
+func newMockIssuer(t *testing.T) *mockIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &mockIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 iss.URL,
+			"authorization_endpoint": iss.URL + "/authorize",
+			"token_endpoint":         iss.URL + "/token",
+			"jwks_uri":               iss.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, _ := r.BasicAuth(); user != "syncthing" || pass != "s3cret" {
+			http.Error(w, "bad client credentials", http.StatusUnauthorized)
+			return
+		}
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if r.FormValue("code") != "the-code" || base64.RawURLEncoding.EncodeToString(verifier[:]) != iss.challenge {
+			http.Error(w, "bad code", http.StatusBadRequest)
+			return
+		}
+		claims := jwt.MapClaims{
+			"iss":   iss.URL,
+			"aud":   "syncthing",
+			"sub":   "1234",
+			"exp":   time.Now().Add(time.Hour).Unix(),
+			"nonce": iss.nonce,
+		}
+		for k, v := range iss.claims {
+			claims[k] = v
+		}
+		tok := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		tok.Header["kid"] = "test"
+		signed, err := tok.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": signed})
+	})
+	iss.Server = httptest.NewServer(mux)
+	t.Cleanup(iss.Close)
+	return iss
+}
+
+// login runs the flow through the handler and returns the callback
+// response.
+func (iss *mockIssuer) login(t *testing.T, h *oidcHandler) *httptest.ResponseRecorder {
+	t.Helper()
+	state, cookies := iss.startLogin(t, h)
+	return callback(h, state, cookies)
+}
+
+// startLogin starts the flow through the handler and returns the state
+// and the cookies the browser gets.
+func (iss *mockIssuer) startLogin(t *testing.T, h *oidcHandler) (string, []*http.Cookie) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	h.handleLogin(rec, httptest.NewRequest(http.MethodGet, "http://localhost:8384"+oidcLoginPath, nil))
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("login: unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+	loc, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := loc.Query()
+	if got := loc.Scheme + "://" + loc.Host + loc.Path; got != iss.URL+"/authorize" {
+		t.Fatalf("unexpected authorization endpoint %q", got)
+	}
+	if q.Get("redirect_uri") != "http://localhost:8384"+oidcCallbackPath {
+		t.Errorf("unexpected redirect URI %q", q.Get("redirect_uri"))
+	}
+	if q.Get("scope") != "openid profile email" {
+		t.Errorf("unexpected scope %q", q.Get("scope"))
+	}
+	iss.nonce = q.Get("nonce")
+	iss.challenge = q.Get("code_challenge")
+
+	return q.Get("state"), rec.Result().Cookies()
+}
+
+func callback(h *oidcHandler, state string, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	cb := url.Values{"state": {state}, "code": {"the-code"}}
+	req := httptest.NewRequest(http.MethodGet, "http://localhost:8384"+oidcCallbackPath+"?"+cb.Encode(), nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	h.handleCallback(rec, req)
+	return rec
+}
+
+func sessionCookie(h *oidcHandler, rec *httptest.ResponseRecorder) bool {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == h.cookies.cookieName {
+			return true
+		}
+	}
+	return false
+}
+
+func newTestOIDCHandler(t *testing.T, cfg config.OIDCConfiguration) *oidcHandler {
+	t.Helper()
+	mdb, err := sqlite.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		mdb.Close()
+	})
+	cookies := newTokenCookieManager("TESTID", config.GUIConfiguration{AuthMode: config.AuthModeOIDC}, events.NoopLogger, db.NewMiscDB(mdb))
+	return newOIDCHandler(cfg, cookies, events.NoopLogger)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	t.Parallel()
+
+	iss := newMockIssuer(t)
+	iss.claims = jwt.MapClaims{"preferred_username": "jb", "groups": []string{"staff", "ops"}}
+	cfg := config.OIDCConfiguration{
+		Issuer:        iss.URL + "/",
+		ClientID:      "syncthing",
+		ClientSecret:  "s3cret",
+		AllowedGroups: []string{"ops"},
+		ClaimRoles: []config.OIDCClaimRole{
+			{Claim: "groups", Value: "ops", Role: config.GUIRoleOperator, Folders: []string{"default"}},
+		},
+	}
+	h := newTestOIDCHandler(t, cfg)
+
+	rec := iss.login(t, h)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/" {
+		t.Fatalf("callback: unexpected status %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The session cookie identifies the user, with the claims used for
+	// role mapping.
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	owner, ok := h.cookies.sessionOwner(req)
+	if !ok {
+		t.Fatal("expected a session")
+	}
+	if owner.GetName() != "jb" || !slices.Equal(owner.GetGroups(), []string{"groups=staff", "groups=ops"}) {
+		t.Errorf("unexpected session owner %v", owner)
+	}
+	p, ok := resolvePrincipal(owner, config.GUIConfiguration{AuthMode: config.AuthModeOIDC}, config.LDAPConfiguration{}, cfg)
+	if !ok || p.role != config.GUIRoleOperator || !slices.Equal(p.folders, []string{"default"}) {
+		t.Errorf("unexpected principal %v", p)
+	}
+
+	// The login can only be completed by the browser that started it, with
+	// the state it was given.
+	state, cookies := iss.startLogin(t, h)
+	if rec := callback(h, state, nil); rec.Code != http.StatusBadRequest || sessionCookie(h, rec) {
+		t.Errorf("unexpected status %d for callback without login cookie", rec.Code)
+	}
+	if rec := callback(h, "foo", cookies); rec.Code != http.StatusBadRequest || sessionCookie(h, rec) {
+		t.Errorf("unexpected status %d for unknown state", rec.Code)
+	}
+	forged := []*http.Cookie{{Name: cookies[0].Name, Value: "x" + cookies[0].Value}}
+	if rec := callback(h, state, forged); rec.Code != http.StatusBadRequest || sessionCookie(h, rec) {
+		t.Errorf("unexpected status %d for forged login cookie", rec.Code)
+	}
+	h.timeNow = func() time.Time { return time.Now().Add(oidcLoginTimeout + time.Minute) }
+	if rec := callback(h, state, cookies); rec.Code != http.StatusBadRequest || sessionCookie(h, rec) {
+		t.Errorf("unexpected status %d for expired login", rec.Code)
+	}
+	h.timeNow = time.Now
+
+	// Users outside the allowed groups don't get a session.
+	iss.claims = jwt.MapClaims{"preferred_username": "eve", "groups": []string{"staff"}}
+	rec = iss.login(t, h)
+	if rec.Code != http.StatusForbidden || sessionCookie(h, rec) {
+		t.Errorf("unexpected status %d for user outside allowed groups", rec.Code)
+	}
+
+	// Nor do users with a token for another client.
+	iss.claims = jwt.MapClaims{"preferred_username": "jb", "groups": []string{"ops"}, "aud": "other"}
+	rec = iss.login(t, h)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("unexpected status %d for token with wrong audience", rec.Code)
+	}
+}
+
+func TestOIDCPrincipal(t *testing.T) {
+	t.Parallel()
+
+	cfg := config.OIDCConfiguration{
+		ClaimRoles: []config.OIDCClaimRole{
+			{Claim: "groups", Value: "admins", Role: config.GUIRoleAdmin},
+			{Claim: "groups", Value: "ops", Role: config.GUIRoleOperator, Folders: []string{"a"}},
+			{Claim: "department", Value: "support", Role: config.GUIRoleOperator, Folders: []string{"b"}},
+			{Claim: "employee", Value: "true", Role: config.GUIRoleViewer},
+		},
+	}
+
+	cases := []struct {
+		groups  []string
+		ok      bool
+		role    config.GUIRole
+		folders []string
+	}{
+		{nil, false, 0, nil},
+		{[]string{"groups=Admins"}, false, 0, nil}, // claim values are case sensitive
+		{[]string{"employee=true"}, true, config.GUIRoleViewer, nil},
+		{[]string{"employee=true", "groups=ops", "department=support"}, true, config.GUIRoleOperator, []string{"a", "b"}},
+		{[]string{"groups=ops", "groups=admins"}, true, config.GUIRoleAdmin, nil},
+	}
+	for _, tc := range cases {
+		p, ok := oidcPrincipal(&apiproto.TokenOwner{Name: "someone", Groups: tc.groups}, cfg)
+		if ok != tc.ok {
+			t.Errorf("%v: ok %v != expected %v", tc.groups, ok, tc.ok)
+			continue
+		}
+		if ok && (p.role != tc.role || !slices.Equal(p.folders, tc.folders)) {
+			t.Errorf("%v: got %v %v, expected %v %v", tc.groups, p.role, p.folders, tc.role, tc.folders)
+		}
+	}
+
+	// Without claim mappings, all allowed users are administrators.
+	cfg = config.OIDCConfiguration{AllowedUsers: []string{"jb"}}
+	if p, ok := oidcPrincipal(&apiproto.TokenOwner{Name: "jb"}, cfg); !ok || !p.isAdmin() {
+		t.Error("allowed user without claim mappings should be admin")
+	}
+	if _, ok := oidcPrincipal(&apiproto.TokenOwner{Name: "eve"}, cfg); ok {
+		t.Error("user not in the allow list should have no access")
+	}
+	if _, ok := oidcPrincipal(nil, cfg); ok {
+		t.Error("session without owner should have no access")
+	}
+}
@@ -0,0 +1,524 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"connectrpc.com/connect"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/syncthing/syncthing/internal/gen/bep"
+	"github.com/syncthing/syncthing/internal/gen/controlproto"
+	"github.com/syncthing/syncthing/internal/gen/controlproto/controlprotoconnect"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/model"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// rpcPrefix is where the Connect/gRPC control service is mounted. Clients
+// use it as the base URL.
+const rpcPrefix = "/rest/rpc"
+
+var (
+	errRPCForbidden      = connect.NewError(connect.CodePermissionDenied, errors.New("forbidden"))
+	errRPCNoSuchFolder   = connect.NewError(connect.CodeNotFound, errors.New("no folder with given ID"))
+	errRPCNoSuchDevice   = connect.NewError(connect.CodeNotFound, errors.New("no device with given ID"))
+	errRPCMissingFolder  = connect.NewError(connect.CodeInvalidArgument, errors.New("missing folder"))
+	errRPCMissingDevice  = connect.NewError(connect.CodeInvalidArgument, errors.New("missing device"))
+	errRPCConfigNotSaved = errors.New("failed to save config")
+)
+
+// controlServer implements the ControlService on top of the same model
+// and configuration as the REST handlers. The route policy lets every
+// authenticated principal in; each call checks what it needs itself.
+type controlServer struct {
+	s *service
+}
+
+var _ controlprotoconnect.ControlServiceHandler = (*controlServer)(nil)
+
+func (s *service) registerRPC(restMux *authzRouter) {
+	path, handler := controlprotoconnect.NewControlServiceHandler(&controlServer{s: s})
+	restMux.Handler(http.MethodPost, rpcPrefix+path+"*method", http.StripPrefix(rpcPrefix, handler))
+}
+
+// authorize returns the principal of the call if it has at least the given
+// role, and may access the folder when one is given.
+func authorize(ctx context.Context, role config.GUIRole, folder string) (*principal, error) {
+	p := principalFromContext(ctx)
+	if p.role < role || p.eventsOnly {
+		return nil, errRPCForbidden
+	}
+	if folder != "" && !p.canAccessFolder(folder) {
+		return nil, errRPCForbidden
+	}
+	return p, nil
+}
+
+func (c *controlServer) ListFolders(ctx context.Context, _ *connect.Request[controlproto.ListFoldersRequest]) (*connect.Response[controlproto.ListFoldersResponse], error) {
+	p, err := authorize(ctx, config.GUIRoleViewer, "")
+	if err != nil {
+		return nil, err
+	}
+	res := &controlproto.ListFoldersResponse{}
+	for _, folder := range filterFolders(p, c.s.cfg.FolderList()) {
+		res.Folders = append(res.Folders, folderToProto(folder))
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (c *controlServer) GetFolder(ctx context.Context, req *connect.Request[controlproto.GetFolderRequest]) (*connect.Response[controlproto.FolderConfig], error) {
+	if _, err := authorize(ctx, config.GUIRoleViewer, req.Msg.Id); err != nil {
+		return nil, err
+	}
+	folder, ok := c.s.cfg.Folder(req.Msg.Id)
+	if !ok {
+		return nil, errRPCNoSuchFolder
+	}
+	return connect.NewResponse(folderToProto(folder)), nil
+}
+
+func (c *controlServer) PutFolder(ctx context.Context, req *connect.Request[controlproto.PutFolderRequest]) (*connect.Response[controlproto.FolderConfig], error) {
+	if _, err := authorize(ctx, config.GUIRoleAdmin, ""); err != nil {
+		return nil, err
+	}
+	msg := req.Msg.GetFolder()
+	if msg.GetId() == "" {
+		return nil, errRPCMissingFolder
+	}
+	folder, ok := c.s.cfg.Folder(msg.Id)
+	if !ok {
+		folder = c.s.cfg.DefaultFolder()
+	}
+	if err := folderFromProto(msg, &folder); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	waiter, err := auditedConfig(ctx, c.s.cfg).Modify(func(cfg *config.Configuration) {
+		cfg.SetFolder(folder)
+	})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err := c.finish(waiter); err != nil {
+		return nil, err
+	}
+	updated, _ := c.s.cfg.Folder(msg.Id)
+	return connect.NewResponse(folderToProto(updated)), nil
+}
+
+func (c *controlServer) DeleteFolder(ctx context.Context, req *connect.Request[controlproto.DeleteFolderRequest]) (*connect.Response[controlproto.DeleteFolderResponse], error) {
+	if _, err := authorize(ctx, config.GUIRoleAdmin, ""); err != nil {
+		return nil, err
+	}
+	if _, ok := c.s.cfg.Folder(req.Msg.Id); !ok {
+		return nil, errRPCNoSuchFolder
+	}
+	waiter, err := auditedConfig(ctx, c.s.cfg).RemoveFolder(req.Msg.Id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err := c.finish(waiter); err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&controlproto.DeleteFolderResponse{}), nil
+}
+
+func (c *controlServer) GetFolderStatus(ctx context.Context, req *connect.Request[controlproto.GetFolderStatusRequest]) (*connect.Response[controlproto.FolderStatus], error) {
+	if _, err := authorize(ctx, config.GUIRoleViewer, req.Msg.Id); err != nil {
+		return nil, err
+	}
+	sum, err := c.s.fss.Summary(req.Msg.Id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+	return connect.NewResponse(&controlproto.FolderStatus{
+		Id:                req.Msg.Id,
+		State:             sum.State,
+		StateChanged:      timestamppb.New(sum.StateChanged),
+		Error:             sum.Error,
+		Errors:            int32(sum.Errors),
+		GlobalFiles:       int64(sum.GlobalFiles),
+		GlobalDirectories: int64(sum.GlobalDirectories),
+		GlobalBytes:       sum.GlobalBytes,
+		LocalFiles:        int64(sum.LocalFiles),
+		LocalDirectories:  int64(sum.LocalDirectories),
+		LocalBytes:        sum.LocalBytes,
+		NeedFiles:         int64(sum.NeedFiles),
+		NeedDirectories:   int64(sum.NeedDirectories),
+		NeedDeletes:       int64(sum.NeedDeletes),
+		NeedBytes:         sum.NeedBytes,
+		InSyncFiles:       int64(sum.InSyncFiles),
+		InSyncBytes:       sum.InSyncBytes,
+		Sequence:          sum.Sequence,
+	}), nil
+}
+
+func (c *controlServer) ListDevices(ctx context.Context, _ *connect.Request[controlproto.ListDevicesRequest]) (*connect.Response[controlproto.ListDevicesResponse], error) {
+	if _, err := authorize(ctx, config.GUIRoleViewer, ""); err != nil {
+		return nil, err
+	}
+	res := &controlproto.ListDevicesResponse{}
+	for _, device := range c.s.cfg.DeviceList() {
+		res.Devices = append(res.Devices, deviceToProto(device))
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (c *controlServer) GetDevice(ctx context.Context, req *connect.Request[controlproto.GetDeviceRequest]) (*connect.Response[controlproto.DeviceConfig], error) {
+	if _, err := authorize(ctx, config.GUIRoleViewer, ""); err != nil {
+		return nil, err
+	}
+	id, err := protocol.DeviceIDFromString(req.Msg.DeviceId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	device, ok := c.s.cfg.Device(id)
+	if !ok {
+		return nil, errRPCNoSuchDevice
+	}
+	return connect.NewResponse(deviceToProto(device)), nil
+}
+
+func (c *controlServer) PutDevice(ctx context.Context, req *connect.Request[controlproto.PutDeviceRequest]) (*connect.Response[controlproto.DeviceConfig], error) {
+	if _, err := authorize(ctx, config.GUIRoleAdmin, ""); err != nil {
+		return nil, err
+	}
+	msg := req.Msg.GetDevice()
+	if msg.GetDeviceId() == "" {
+		return nil, errRPCMissingDevice
+	}
+	id, err := protocol.DeviceIDFromString(msg.DeviceId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	device, ok := c.s.cfg.Device(id)
+	if !ok {
+		device = c.s.cfg.DefaultDevice()
+		device.DeviceID = id
+	}
+	device.Name = msg.Name
+	if len(msg.Addresses) > 0 {
+		device.Addresses = msg.Addresses
+	}
+	device.Paused = msg.Paused
+	device.Introducer = msg.Introducer
+	device.AutoAcceptFolders = msg.AutoAcceptFolders
+
+	waiter, err := auditedConfig(ctx, c.s.cfg).Modify(func(cfg *config.Configuration) {
+		cfg.SetDevice(device)
+	})
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err := c.finish(waiter); err != nil {
+		return nil, err
+	}
+	updated, _ := c.s.cfg.Device(id)
+	return connect.NewResponse(deviceToProto(updated)), nil
+}
+
+func (c *controlServer) DeleteDevice(ctx context.Context, req *connect.Request[controlproto.DeleteDeviceRequest]) (*connect.Response[controlproto.DeleteDeviceResponse], error) {
+	if _, err := authorize(ctx, config.GUIRoleAdmin, ""); err != nil {
+		return nil, err
+	}
+	id, err := protocol.DeviceIDFromString(req.Msg.DeviceId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if _, ok := c.s.cfg.Device(id); !ok {
+		return nil, errRPCNoSuchDevice
+	}
+	waiter, err := auditedConfig(ctx, c.s.cfg).RemoveDevice(id)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err := c.finish(waiter); err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(&controlproto.DeleteDeviceResponse{}), nil
+}
+
+func (c *controlServer) GetDeviceStatus(ctx context.Context, req *connect.Request[controlproto.GetDeviceStatusRequest]) (*connect.Response[controlproto.DeviceStatus], error) {
+	if _, err := authorize(ctx, config.GUIRoleViewer, ""); err != nil {
+		return nil, err
+	}
+	id, err := protocol.DeviceIDFromString(req.Msg.DeviceId)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	conns, _ := c.s.model.ConnectionStats()["connections"].(map[string]model.ConnectionStats)
+	cs, ok := conns[id.String()]
+	if !ok {
+		return nil, errRPCNoSuchDevice
+	}
+	res := &controlproto.DeviceStatus{
+		DeviceId:       id.String(),
+		Connected:      cs.Connected,
+		Paused:         cs.Paused,
+		ClientVersion:  cs.ClientVersion,
+		Address:        cs.Address,
+		ConnectionType: cs.Type,
+		IsLocal:        cs.IsLocal,
+		Crypto:         cs.Crypto,
+		InBytesTotal:   cs.InBytesTotal,
+		OutBytesTotal:  cs.OutBytesTotal,
+	}
+	if !cs.StartedAt.IsZero() {
+		res.StartedAt = timestamppb.New(cs.StartedAt)
+	}
+	if stats, err := c.s.model.DeviceStatistics(); err == nil {
+		if st, ok := stats[id]; ok {
+			if !st.LastSeen.IsZero() {
+				res.LastSeen = timestamppb.New(st.LastSeen)
+			}
+			res.LastConnectionDurationS = st.LastConnectionDurationS
+		}
+	}
+	return connect.NewResponse(res), nil
+}
+
+func (c *controlServer) Scan(ctx context.Context, req *connect.Request[controlproto.ScanRequest]) (*connect.Response[controlproto.ScanResponse], error) {
+	p := principalFromContext(ctx)
+	if req.Msg.Folder == "" && p.folders != nil {
+		// Scanning everything is for those who can see everything.
+		return nil, errRPCForbidden
+	}
+	if _, err := authorize(ctx, config.GUIRoleOperator, req.Msg.Folder); err != nil {
+		return nil, err
+	}
+	if req.Msg.Folder != "" {
+		if err := c.s.model.ScanFolderSubdirs(req.Msg.Folder, req.Msg.Subdirs); err != nil {
+			return nil, rpcFolderError(err)
+		}
+	} else if errs := c.s.model.ScanFolders(); len(errs) > 0 {
+		msgs := make([]string, 0, len(errs))
+		for folder, err := range errs {
+			msgs = append(msgs, folder+": "+err.Error())
+		}
+		slices.Sort(msgs)
+		return nil, connect.NewError(connect.CodeInternal, errors.New(strings.Join(msgs, "; ")))
+	}
+	return connect.NewResponse(&controlproto.ScanResponse{}), nil
+}
+
+func (c *controlServer) Browse(ctx context.Context, req *connect.Request[controlproto.BrowseRequest]) (*connect.Response[controlproto.BrowseResponse], error) {
+	if req.Msg.Folder == "" {
+		return nil, errRPCMissingFolder
+	}
+	if _, err := authorize(ctx, config.GUIRoleViewer, req.Msg.Folder); err != nil {
+		return nil, err
+	}
+	levels := -1
+	if req.Msg.Levels != nil {
+		levels = int(*req.Msg.Levels)
+	}
+	tree, err := c.s.model.GlobalDirectoryTree(req.Msg.Folder, req.Msg.Prefix, levels, req.Msg.DirsOnly)
+	if err != nil {
+		return nil, rpcFolderError(err)
+	}
+	return connect.NewResponse(&controlproto.BrowseResponse{Entries: treeToProto(tree)}), nil
+}
+
+func (c *controlServer) Need(ctx context.Context, req *connect.Request[controlproto.NeedRequest]) (*connect.Response[controlproto.NeedResponse], error) {
+	if req.Msg.Folder == "" {
+		return nil, errRPCMissingFolder
+	}
+	if _, err := authorize(ctx, config.GUIRoleViewer, req.Msg.Folder); err != nil {
+		return nil, err
+	}
+	page, perpage := int(req.Msg.Page), int(req.Msg.PerPage)
+	if page < 1 {
+		page = 1
+	}
+	if perpage < 1 {
+		perpage = 1 << 16
+	}
+	progress, queued, rest, err := c.s.model.NeedFolderFiles(req.Msg.Folder, page, perpage)
+	if err != nil {
+		return nil, rpcFolderError(err)
+	}
+	return connect.NewResponse(&controlproto.NeedResponse{
+		Progress: filesToProto(progress),
+		Queued:   filesToProto(queued),
+		Rest:     filesToProto(rest),
+		Page:     int32(page),
+		PerPage:  int32(perpage),
+	}), nil
+}
+
+func (c *controlServer) StreamEvents(ctx context.Context, req *connect.Request[controlproto.StreamEventsRequest], stream *connect.ServerStream[controlproto.StreamEventsResponse]) error {
+	p := principalFromContext(ctx)
+	if p.role < config.GUIRoleViewer {
+		return errRPCForbidden
+	}
+	sub := c.s.getEventSub(c.s.getEventMask(strings.Join(req.Msg.Types, ",")))
+	since := -1
+	if req.Msg.Since != nil {
+		since = int(*req.Msg.Since)
+	}
+	err := c.s.streamEvents(ctx, p, sub, since, req.Msg.Folder, req.Msg.DeviceId, &rpcEventOutput{stream: stream})
+	if ctx.Err() != nil {
+		return nil // the client went away
+	}
+	return err
+}
+
+// finish waits for a configuration change to be applied and saves it.
+func (c *controlServer) finish(waiter config.Waiter) error {
+	waiter.Wait()
+	if err := c.s.cfg.Save(); err != nil {
+		return connect.NewError(connect.CodeInternal, errors.Join(errRPCConfigNotSaved, err))
+	}
+	return nil
+}
+
+type rpcEventOutput struct {
+	stream *connect.ServerStream[controlproto.StreamEventsResponse]
+}
+
+func (o *rpcEventOutput) gap(from, to int) error {
+	return o.stream.Send(&controlproto.StreamEventsResponse{
+		Item: &controlproto.StreamEventsResponse_Gap{Gap: &controlproto.EventGap{From: int64(from), To: int64(to)}},
+	})
+}
+
+func (o *rpcEventOutput) reset(last int) error {
+	return o.stream.Send(&controlproto.StreamEventsResponse{
+		Item: &controlproto.StreamEventsResponse_Reset_{Reset_: &controlproto.EventReset{Id: int64(last)}},
+	})
+}
+
+func (o *rpcEventOutput) event(ev events.Event) error {
+	// The event data is whatever its producer made it, so take the same
+	// detour through JSON as the REST API.
+	data := &structpb.Value{}
+	if bs, err := json.Marshal(ev.Data); err == nil {
+		_ = data.UnmarshalJSON(bs)
+	}
+	return o.stream.Send(&controlproto.StreamEventsResponse{
+		Item: &controlproto.StreamEventsResponse_Event{Event: &controlproto.Event{
+			Id:       int64(ev.SubscriptionID),
+			GlobalId: int64(ev.GlobalID),
+			Time:     timestamppb.New(ev.Time),
+			Type:     ev.Type.String(),
+			Data:     data,
+		}},
+	})
+}
+
+func (*rpcEventOutput) flush(int, bool) error {
+	// Every message is sent as it comes.
+	return nil
+}
+
+func rpcFolderError(err error) error {
+	if isFolderNotFound(err) {
+		return connect.NewError(connect.CodeNotFound, err)
+	}
+	return connect.NewError(connect.CodeInternal, err)
+}
+
+func folderToProto(f config.FolderConfiguration) *controlproto.FolderConfig {
+	res := &controlproto.FolderConfig{
+		Id:               f.ID,
+		Label:            f.Label,
+		Path:             f.Path,
+		Type:             bep.FolderType(f.Type),
+		Paused:           f.Paused,
+		RescanIntervalS:  int32(f.RescanIntervalS),
+		FsWatcherEnabled: f.FSWatcherEnabled,
+	}
+	for _, id := range f.DeviceIDs() {
+		res.DeviceIds = append(res.DeviceIds, id.String())
+	}
+	return res
+}
+
+// folderFromProto sets the fields of the folder that the message carries.
+// Devices already sharing the folder keep their settings.
+func folderFromProto(msg *controlproto.FolderConfig, f *config.FolderConfiguration) error {
+	f.ID = msg.Id
+	f.Label = msg.Label
+	if msg.Path != "" {
+		f.Path = msg.Path
+	}
+	f.Type = config.FolderType(msg.Type)
+	f.Paused = msg.Paused
+	if msg.RescanIntervalS > 0 {
+		f.RescanIntervalS = int(msg.RescanIntervalS)
+	}
+	f.FSWatcherEnabled = msg.FsWatcherEnabled
+
+	if msg.DeviceIds == nil {
+		return nil
+	}
+	devices := make([]config.FolderDeviceConfiguration, 0, len(msg.DeviceIds))
+	for _, s := range msg.DeviceIds {
+		id, err := protocol.DeviceIDFromString(s)
+		if err != nil {
+			return err
+		}
+		dev, ok := f.Device(id)
+		if !ok {
+			dev = config.FolderDeviceConfiguration{DeviceID: id}
+		}
+		devices = append(devices, dev)
+	}
+	f.Devices = devices
+	return nil
+}
+
+func deviceToProto(d config.DeviceConfiguration) *controlproto.DeviceConfig {
+	return &controlproto.DeviceConfig{
+		DeviceId:          d.DeviceID.String(),
+		Name:              d.Name,
+		Addresses:         d.Addresses,
+		Paused:            d.Paused,
+		Introducer:        d.Introducer,
+		AutoAcceptFolders: d.AutoAcceptFolders,
+	}
+}
+
+func treeToProto(entries []*model.TreeEntry) []*controlproto.TreeEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+	res := make([]*controlproto.TreeEntry, len(entries))
+	for i, e := range entries {
+		res[i] = &controlproto.TreeEntry{
+			Name:     e.Name,
+			ModTime:  timestamppb.New(e.ModTime),
+			Size:     e.Size,
+			Type:     e.Type,
+			Children: treeToProto(e.Children),
+		}
+	}
+	return res
+}
+
+func filesToProto(fs []protocol.FileInfo) []*controlproto.FileEntry {
+	res := make([]*controlproto.FileEntry, len(fs))
+	for i, f := range fs {
+		res[i] = &controlproto.FileEntry{
+			Name:     f.FileName(),
+			Size:     f.FileSize(),
+			Modified: timestamppb.New(f.ModTime()),
+			Type:     f.FileType().String(),
+			Deleted:  f.IsDeleted(),
+			Sequence: f.SequenceNo(),
+		}
+	}
+	return res
+}
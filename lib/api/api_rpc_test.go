@@ -0,0 +1,204 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/syncthing/syncthing/internal/gen/bep"
+	"github.com/syncthing/syncthing/internal/gen/controlproto"
+	"github.com/syncthing/syncthing/internal/gen/controlproto/controlprotoconnect"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/model"
+	modelmocks "github.com/syncthing/syncthing/lib/model/mocks"
+)
+
+// startRPC serves the control service with the principal named by the
+// X-Test-Principal header, and returns a client for it.
+func startRPC(t *testing.T, svc *service, principals map[string]*principal) controlprotoconnect.ControlServiceClient {
+	t.Helper()
+	restMux := newAuthzRouter()
+	svc.registerRPC(restMux)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if p, ok := principals[r.Header.Get("X-Test-Principal")]; ok {
+			r = withPrincipal(r, p)
+		}
+		restMux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return controlprotoconnect.NewControlServiceClient(srv.Client(), srv.URL+rpcPrefix)
+}
+
+func as[T any](name string, msg *T) *connect.Request[T] {
+	req := connect.NewRequest(msg)
+	req.Header().Set("X-Test-Principal", name)
+	return req
+}
+
+func TestRPCFoldersAndAuthz(t *testing.T) {
+	t.Parallel()
+
+	cfg := newMockedConfig()
+	folders := []config.FolderConfiguration{
+		{ID: "a", Label: "A", Path: "/a", Type: config.FolderTypeReceiveOnly, Devices: []config.FolderDeviceConfiguration{{DeviceID: dev1}}},
+		{ID: "b", Label: "B", Path: "/b"},
+	}
+	cfg.FolderListReturns(folders)
+	cfg.FolderCalls(func(id string) (config.FolderConfiguration, bool) {
+		for _, f := range folders {
+			if f.ID == id {
+				return f, true
+			}
+		}
+		return config.FolderConfiguration{}, false
+	})
+	cfg.DefaultFolderReturns(config.FolderConfiguration{RescanIntervalS: 3600, FSWatcherEnabled: true})
+	m := new(modelmocks.Model)
+	summary := &modelmocks.FolderSummaryService{}
+	summary.SummaryReturns(&model.FolderSummary{State: "idle", NeedFiles: 3}, nil)
+	svc := &service{cfg: cfg, model: m, fss: summary}
+
+	client := startRPC(t, svc, map[string]*principal{
+		"viewer":   rolePrincipal("viewer", config.GUIRoleViewer, []string{"a"}),
+		"operator": rolePrincipal("operator", config.GUIRoleOperator, nil),
+		"events":   {name: "events", role: config.GUIRoleViewer, eventsOnly: true},
+	})
+	ctx := context.Background()
+
+	// Viewers see the folders they have access to.
+	list, err := client.ListFolders(ctx, as("viewer", &controlproto.ListFoldersRequest{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Msg.Folders) != 1 {
+		t.Fatalf("expected one folder, got %v", list.Msg.Folders)
+	}
+	if f := list.Msg.Folders[0]; f.Id != "a" || f.Type != bep.FolderType_FOLDER_TYPE_RECEIVE_ONLY || len(f.DeviceIds) != 1 || f.DeviceIds[0] != dev1.String() {
+		t.Errorf("unexpected folder %v", f)
+	}
+	status, err := client.GetFolderStatus(ctx, as("viewer", &controlproto.GetFolderStatusRequest{Id: "a"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Msg.State != "idle" || status.Msg.NeedFiles != 3 {
+		t.Errorf("unexpected status %v", status.Msg)
+	}
+
+	checkCode := func(err error, code connect.Code) {
+		t.Helper()
+		if connect.CodeOf(err) != code {
+			t.Errorf("expected %v, got %v", code, err)
+		}
+	}
+	_, err = client.GetFolder(ctx, as("viewer", &controlproto.GetFolderRequest{Id: "b"}))
+	checkCode(err, connect.CodePermissionDenied)
+	_, err = client.Scan(ctx, as("viewer", &controlproto.ScanRequest{Folder: "a"}))
+	checkCode(err, connect.CodePermissionDenied)
+	_, err = client.ListFolders(ctx, as("events", &controlproto.ListFoldersRequest{}))
+	checkCode(err, connect.CodePermissionDenied)
+	_, err = client.PutFolder(ctx, as("operator", &controlproto.PutFolderRequest{Folder: &controlproto.FolderConfig{Id: "c"}}))
+	checkCode(err, connect.CodePermissionDenied)
+	_, err = client.GetFolder(ctx, as("admin", &controlproto.GetFolderRequest{Id: "c"}))
+	checkCode(err, connect.CodeNotFound)
+
+	// Operators can scan.
+	if _, err := client.Scan(ctx, as("operator", &controlproto.ScanRequest{Folder: "b", Subdirs: []string{"x"}})); err != nil {
+		t.Fatal(err)
+	}
+	if folder, subs := m.ScanFolderSubdirsArgsForCall(0); folder != "b" || len(subs) != 1 || subs[0] != "x" {
+		t.Errorf("unexpected scan of %q %v", folder, subs)
+	}
+	m.ScanFolderSubdirsReturns(model.ErrFolderMissing)
+	_, err = client.Scan(ctx, as("operator", &controlproto.ScanRequest{Folder: "c"}))
+	checkCode(err, connect.CodeNotFound)
+
+	// Administrators (here, anyone without a principal) can add folders,
+	// which start out from the defaults.
+	if _, err := client.PutFolder(ctx, as("admin", &controlproto.PutFolderRequest{Folder: &controlproto.FolderConfig{Id: "c", Path: "/c", FsWatcherEnabled: true, DeviceIds: []string{dev1.String()}}})); err != nil {
+		t.Fatal(err)
+	}
+	var added config.FolderConfiguration
+	modified := &config.Configuration{}
+	cfg.ModifyArgsForCall(0)(modified)
+	if len(modified.Folders) == 1 {
+		added = modified.Folders[0]
+	}
+	if added.ID != "c" || added.Path != "/c" || added.RescanIntervalS != 3600 || !added.FSWatcherEnabled || len(added.Devices) != 1 {
+		t.Errorf("unexpected folder added %+v", added)
+	}
+}
+
+func TestRPCStreamEvents(t *testing.T) {
+	t.Parallel()
+
+	evLogger := events.NewLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go evLogger.Serve(ctx)
+
+	svc := &service{
+		cfg:                  newMockedConfig(),
+		evLogger:             evLogger,
+		eventSubs:            make(map[events.EventType]events.BufferedSubscription),
+		eventStreamKeepalive: 100 * time.Millisecond,
+	}
+	client := startRPC(t, svc, map[string]*principal{
+		"events": {name: "events", role: config.GUIRoleViewer, folders: []string{"a"}, eventsOnly: true},
+	})
+
+	// Subscribe up front, so that the events below are buffered.
+	svc.getEventSub(events.FolderSummary | events.DeviceConnected)
+	evLogger.Log(events.FolderSummary, map[string]interface{}{"folder": "a", "n": 1})
+	evLogger.Log(events.FolderSummary, map[string]interface{}{"folder": "b", "n": 2})
+	evLogger.Log(events.DeviceConnected, map[string]string{"id": dev1.String()})
+	evLogger.Log(events.FolderSummary, map[string]interface{}{"folder": "a", "n": 4})
+
+	since := int64(0)
+	stream, err := client.StreamEvents(ctx, as("events", &controlproto.StreamEventsRequest{
+		Since:  &since,
+		Types:  []string{"FolderSummary", "DeviceConnected"},
+		Folder: "a",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		// Closing drains the response, so end the stream first.
+		cancel()
+		stream.Close()
+	}()
+
+	var got []*controlproto.Event
+	for len(got) < 2 && stream.Receive() {
+		if ev := stream.Msg().GetEvent(); ev != nil {
+			got = append(got, ev)
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Id != 1 || got[1].Id != 4 {
+		t.Errorf("unexpected events %v", got)
+	}
+	data := got[1].Data.GetStructValue().AsMap()
+	if got[1].Type != "FolderSummary" || data["folder"] != "a" || data["n"] != float64(4) {
+		t.Errorf("unexpected event %v", got[1])
+	}
+
+	// Only the stream is open to principals limited to events.
+	_, err = client.ListDevices(ctx, as("events", &controlproto.ListDevicesRequest{}))
+	if connect.CodeOf(err) != connect.CodePermissionDenied {
+		t.Errorf("expected permission denied, got %v", err)
+	}
+}
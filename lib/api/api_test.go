@@ -7,12 +7,15 @@
 package api
 
 import (
+	"bufio"
 	"bytes"
 	"compress/gzip"
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -33,6 +36,7 @@ import (
 	"github.com/syncthing/syncthing/lib/assets"
 	"github.com/syncthing/syncthing/lib/build"
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/confighistory"
 	connmocks "github.com/syncthing/syncthing/lib/connections/mocks"
 	discovermocks "github.com/syncthing/syncthing/lib/discover/mocks"
 	"github.com/syncthing/syncthing/lib/events"
@@ -90,7 +94,7 @@ func TestStopAfterBrokenConfig(t *testing.T) {
 		mdb.Close()
 	})
 	kdb := db.NewMiscDB(mdb)
-	srv := New(protocol.LocalDeviceID, w, "", "syncthing", nil, nil, nil, events.NoopLogger, nil, nil, nil, nil, nil, nil, false, kdb).(*service)
+	srv := New(protocol.LocalDeviceID, w, "", "syncthing", nil, nil, nil, events.NoopLogger, nil, nil, nil, nil, nil, nil, nil, nil, false, kdb).(*service)
 
 	srv.started = make(chan string)
 
@@ -266,6 +270,18 @@ func TestAPIServiceRequests(t *testing.T) {
 			Prefix: "",
 		},
 
+		// /rest/folder
+		{
+			URL:    "/rest/folder/verify?folder=default&spotcheck=100",
+			Code:   200,
+			Type:   "application/json",
+			Prefix: "{",
+		},
+		{
+			URL:  "/rest/folder/verify?folder=default&spotcheck=101",
+			Code: 400,
+		},
+
 		// /rest/stats
 		{
 			URL:    "/rest/stats/device",
@@ -1057,7 +1073,7 @@ func startHTTPWithShutdownTimeout(t *testing.T, cfg config.Wrapper, shutdownTime
 		mdb.Close()
 	})
 	kdb := db.NewMiscDB(mdb)
-	svc := New(protocol.LocalDeviceID, cfg, assetDir, "syncthing", m, eventSub, diskEventSub, events.NoopLogger, discoverer, connections, urService, mockedSummary, errorLog, systemLog, false, kdb).(*service)
+	svc := New(protocol.LocalDeviceID, cfg, assetDir, "syncthing", m, eventSub, diskEventSub, events.NoopLogger, discoverer, connections, urService, nil, nil, mockedSummary, errorLog, systemLog, false, kdb).(*service)
 	svc.started = addrChan
 
 	if shutdownTimeout > 0 {
@@ -1089,6 +1105,265 @@ func startHTTPWithShutdownTimeout(t *testing.T, cfg config.Wrapper, shutdownTime
 	return baseURL
 }
 
+func TestAccountRoles(t *testing.T) {
+	t.Parallel()
+
+	gui := config.GUIConfiguration{
+		User:       "admin",
+		RawAddress: "127.0.0.1:0",
+		APIKey:     testAPIKey,
+		Accounts: []config.GUIAccount{
+			{Name: "viewer", Role: config.GUIRoleViewer},
+			{Name: "helpdesk", Role: config.GUIRoleOperator, Folders: []string{"default"}},
+		},
+	}
+	if err := gui.SetPassword("pass"); err != nil {
+		t.Fatal(err)
+	}
+	for i := range gui.Accounts {
+		if err := gui.Accounts[i].SetPassword("pass"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := newMockedConfig()
+	cfg.GUIReturns(gui)
+	untrusted := []config.FolderDeviceConfiguration{{DeviceID: dev1, EncryptionPassword: "folder secret"}}
+	raw := config.Configuration{
+		GUI:     gui,
+		Folders: []config.FolderConfiguration{{ID: "default", Devices: untrusted}, {ID: "other"}},
+	}
+	cfg.RawCopyReturns(raw)
+	cfg.FolderListReturns(raw.Folders)
+	cfg.FolderReturns(raw.Folders[0], true)
+	baseURL := startHTTP(t, cfg)
+
+	// Log in each user and pick up the session and CSRF cookies.
+	type session struct {
+		cookies   []*http.Cookie
+		csrfName  string
+		csrfValue string
+	}
+	login := func(user string) session {
+		t.Helper()
+		resp := httpGet(baseURL+"/", user, "pass", "", "", nil, t)
+		resp.Body.Close()
+		if !hasSessionCookie(resp.Cookies()) {
+			t.Fatalf("no session cookie for %s", user)
+		}
+		sess := session{cookies: resp.Cookies()}
+		for _, cookie := range resp.Cookies() {
+			if strings.HasPrefix(cookie.Name, "CSRF-Token") {
+				sess.csrfName = cookie.Name
+				sess.csrfValue = cookie.Value
+			}
+		}
+		return sess
+	}
+	do := func(sess session, method, path string) *http.Response {
+		t.Helper()
+		return httpRequest(method, baseURL+path, nil, "", "", "", "", sess.csrfName, sess.csrfValue, sess.cookies, t)
+	}
+
+	admin := login("admin")
+	viewer := login("viewer")
+	helpdesk := login("helpdesk")
+
+	cases := []struct {
+		sess   session
+		method string
+		path   string
+		status int
+	}{
+		{viewer, http.MethodGet, "/rest/system/version", http.StatusOK},
+		{viewer, http.MethodGet, "/rest/config/gui", http.StatusForbidden},
+		{viewer, http.MethodPost, "/rest/db/scan?folder=default", http.StatusForbidden},
+		{viewer, http.MethodPost, "/rest/system/ping", http.StatusOK},
+		{helpdesk, http.MethodPost, "/rest/db/scan?folder=default", http.StatusOK},
+		{helpdesk, http.MethodPost, "/rest/db/scan?folder=other", http.StatusForbidden},
+		{helpdesk, http.MethodPost, "/rest/db/scan", http.StatusForbidden},
+		{helpdesk, http.MethodPost, "/rest/db/override?folder=default", http.StatusForbidden},
+		{helpdesk, http.MethodPost, "/rest/db/revert?folder=default", http.StatusForbidden},
+		{helpdesk, http.MethodPost, "/rest/folder/versions?folder=default", http.StatusForbidden},
+		{helpdesk, http.MethodGet, "/rest/config/folders/other", http.StatusForbidden},
+		{helpdesk, http.MethodPatch, "/rest/config/folders/default", http.StatusForbidden},
+		{helpdesk, http.MethodPost, "/rest/system/restart", http.StatusForbidden},
+		{helpdesk, http.MethodPost, "/rest/system/pause", http.StatusForbidden},
+		{helpdesk, http.MethodPost, "/rest/system/resume?device=" + dev1.String(), http.StatusForbidden},
+		{admin, http.MethodPost, "/rest/db/scan?folder=other", http.StatusOK},
+		{admin, http.MethodGet, "/rest/config/gui", http.StatusOK},
+	}
+	for _, tc := range cases {
+		resp := do(tc.sess, tc.method, tc.path)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s: status %d, expected %d", tc.method, tc.path, resp.StatusCode, tc.status)
+		}
+	}
+
+	// The configuration is redacted and limited to the scoped folders.
+	resp := do(helpdesk, http.MethodGet, "/rest/config")
+	defer resp.Body.Close()
+	var got config.Configuration
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.GUI.APIKey != "" || got.GUI.Password != "" || got.GUI.Accounts[0].Password != "" {
+		t.Error("secrets not redacted from configuration")
+	}
+	if len(got.Folders) != 1 || got.Folders[0].ID != "default" {
+		t.Errorf("unexpected folders in configuration: %v", got.Folders)
+	}
+
+	// Nor do viewers see the passwords of folders shared with untrusted
+	// devices.
+	for _, path := range []string{"/rest/config", "/rest/config/folders", "/rest/config/folders/default"} {
+		resp := do(viewer, http.MethodGet, path)
+		bs, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK || !bytes.Contains(bs, []byte(dev1.String())) {
+			t.Errorf("%s: unexpected response %d %s", path, resp.StatusCode, bs)
+		}
+		if bytes.Contains(bs, []byte("folder secret")) {
+			t.Errorf("%s: encryption password not redacted", path)
+		}
+	}
+}
+
+func TestClientCertificateWithoutCSRF(t *testing.T) {
+	t.Parallel()
+
+	caFile, clientCert := newTestClientCert(t, "automation")
+	gui := config.GUIConfiguration{
+		User:           "admin",
+		RawAddress:     "127.0.0.1:0",
+		APIKey:         testAPIKey,
+		ClientCertMode: config.ClientCertModeOptional,
+		ClientCAFile:   caFile,
+		Accounts:       []config.GUIAccount{{Name: "automation", Role: config.GUIRoleAdmin}},
+	}
+	if err := gui.SetPassword("pass"); err != nil {
+		t.Fatal(err)
+	}
+	cfg := newMockedConfig()
+	cfg.GUIReturns(gui)
+	cfg.RawCopyReturns(config.Configuration{GUI: gui})
+	baseURL := strings.Replace(startHTTP(t, cfg), "http://", "https://", 1)
+
+	post := func(certs []tls.Certificate, header http.Header) int {
+		t.Helper()
+		client := &http.Client{
+			Timeout: 15 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true, Certificates: certs}, //nolint:gosec
+			},
+		}
+		req, err := http.NewRequest(http.MethodPost, baseURL+"/rest/system/error/clear", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		maps.Copy(req.Header, header)
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// The certificate is enough, like an API key.
+	if status := post([]tls.Certificate{clientCert}, nil); status != http.StatusOK {
+		t.Errorf("unexpected status %d with a client certificate", status)
+	}
+	if status := post([]tls.Certificate{clientCert}, http.Header{"Sec-Fetch-Site": {"same-origin"}, "Origin": {baseURL}}); status != http.StatusOK {
+		t.Errorf("unexpected status %d for a same origin request", status)
+	}
+	// But not for a browser sending it along with a request for another
+	// site, or that may be.
+	for _, header := range []http.Header{
+		{"Sec-Fetch-Site": {"cross-site"}},
+		{"Sec-Fetch-Site": {"same-site"}},
+		{"Origin": {"https://example.com"}},
+		{"Referer": {"https://example.com/form"}},
+		{"Cookie": {"session=1"}},
+	} {
+		if status := post([]tls.Certificate{clientCert}, header); status != http.StatusForbidden {
+			t.Errorf("unexpected status %d for a request with %v", status, header)
+		}
+	}
+	// Without it, we're not authenticated.
+	if status := post(nil, nil); status == http.StatusOK {
+		t.Error("request without client certificate or credentials succeeded")
+	}
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	t.Parallel()
+
+	gui := config.GUIConfiguration{
+		User:       "admin",
+		RawAddress: "127.0.0.1:0",
+		APIKey:     testAPIKey,
+	}
+	if err := gui.SetPassword("pass"); err != nil {
+		t.Fatal(err)
+	}
+	cfg := newMockedConfig()
+	cfg.GUIReturns(gui)
+	baseURL := startHTTP(t, cfg)
+
+	create := func(name, scope string) string {
+		t.Helper()
+		resp := httpRequest(http.MethodPost, baseURL+"/rest/config/gui/tokens", map[string]string{"name": name, "scope": scope}, "", "", testAPIKey, "", "", "", nil, t)
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("creating token: status %d", resp.StatusCode)
+		}
+		var res struct{ Token string }
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		return res.Token
+	}
+	readToken := create("read", "read")
+	eventsToken := create("events", "events")
+
+	cases := []struct {
+		token  string
+		method string
+		path   string
+		status int
+	}{
+		{readToken, http.MethodGet, "/rest/system/version", http.StatusOK},
+		{readToken, http.MethodPost, "/rest/system/restart", http.StatusForbidden},
+		{readToken, http.MethodGet, "/rest/config/gui/tokens", http.StatusForbidden},
+		{eventsToken, http.MethodGet, "/rest/events?timeout=0", http.StatusOK},
+		{eventsToken, http.MethodGet, "/rest/system/version", http.StatusForbidden},
+		{"sttok-invalid", http.MethodGet, "/rest/system/version", http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		resp := httpRequest(tc.method, baseURL+tc.path, nil, "", "", "", tc.token, "", "", nil, t)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s: status %d, expected %d", tc.method, tc.path, resp.StatusCode, tc.status)
+		}
+	}
+
+	// Revoked tokens stop working.
+	resp := httpRequest(http.MethodDelete, baseURL+"/rest/config/gui/tokens/read", nil, "", "", testAPIKey, "", "", "", nil, t)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("revoking token: status %d", resp.StatusCode)
+	}
+	resp = httpRequest(http.MethodGet, baseURL+"/rest/system/version", nil, "", "", "", readToken, "", "", nil, t)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("revoked token: status %d", resp.StatusCode)
+	}
+}
+
 func TestCSRFRequired(t *testing.T) {
 	t.Parallel()
 
@@ -1575,7 +1850,7 @@ func TestEventMasks(t *testing.T) {
 		mdb.Close()
 	})
 	kdb := db.NewMiscDB(mdb)
-	svc := New(protocol.LocalDeviceID, cfg, "", "syncthing", nil, defSub, diskSub, events.NoopLogger, nil, nil, nil, nil, nil, nil, false, kdb).(*service)
+	svc := New(protocol.LocalDeviceID, cfg, "", "syncthing", nil, defSub, diskSub, events.NoopLogger, nil, nil, nil, nil, nil, nil, nil, nil, false, kdb).(*service)
 
 	if mask := svc.getEventMask(""); mask != DefaultEventMask {
 		t.Errorf("incorrect default mask %x != %x", int64(mask), int64(DefaultEventMask))
@@ -1861,3 +2136,193 @@ func runningInContainer() bool {
 	}
 	return false
 }
+
+func TestEventStream(t *testing.T) {
+	t.Parallel()
+
+	evLogger := events.NewLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go evLogger.Serve(ctx)
+
+	// A small buffer, so that old events are lost.
+	defSub := events.NewBufferedSubscription(evLogger.Subscribe(DefaultEventMask), 5)
+	mdb, err := sqlite.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		mdb.Close()
+	})
+	svc := New(protocol.LocalDeviceID, newMockedConfig(), "", "syncthing", nil, defSub, nil, evLogger, nil, nil, nil, nil, nil, nil, nil, nil, false, db.NewMiscDB(mdb)).(*service)
+	svc.eventStreamKeepalive = 100 * time.Millisecond
+
+	for i := 1; i <= 10; i++ {
+		folder := "a"
+		if i%2 == 0 {
+			folder = "b"
+		}
+		evLogger.Log(events.FolderSummary, map[string]interface{}{"folder": folder, "n": i})
+	}
+	if evs := defSub.Since(9, nil, 10*time.Second); len(evs) != 1 {
+		t.Fatal("events were not buffered")
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(svc.getEventStream))
+	defer srv.Close()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"?folder=a", nil)
+	req.Header.Set("Last-Event-ID", "2")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	// Events 3 to 5 were lost, of the remaining ones only those for folder
+	// "a" are sent, and the stream position is the last event.
+	var lines []string
+	br := bufio.NewScanner(resp.Body)
+	for br.Scan() {
+		if line := br.Text(); line != "" {
+			lines = append(lines, line)
+			if line == "id: 10" {
+				break
+			}
+		}
+	}
+	expected := []string{
+		"event: gap", `data: {"from":3,"to":5}`,
+		"id: 7", "data", "id: 9", "data",
+		"id: 10",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("unexpected stream %q", lines)
+	}
+	for i, exp := range expected {
+		if !strings.HasPrefix(lines[i], exp) {
+			t.Errorf("line %d: %q, expected %q", i, lines[i], exp)
+		}
+	}
+	if !strings.Contains(lines[3], `"folder":"a"`) {
+		t.Errorf("unexpected event %q", lines[3])
+	}
+
+	// Resuming from an ID of an earlier run resets the stream to the
+	// newest event and continues from there.
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "50")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	br = bufio.NewScanner(resp.Body)
+	lines = nil
+	for br.Scan() {
+		line := br.Text()
+		if line == "" || strings.HasPrefix(line, ":") {
+			continue
+		}
+		lines = append(lines, line)
+		if len(lines) == 3 {
+			evLogger.Log(events.FolderSummary, map[string]interface{}{"folder": "a", "n": 11})
+		}
+		if len(lines) == 5 {
+			break
+		}
+	}
+	expected = []string{
+		"event: reset", `data: {"id":10}`, "id: 10",
+		"id: 11", "data",
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("unexpected stream %q", lines)
+	}
+	for i, exp := range expected {
+		if !strings.HasPrefix(lines[i], exp) {
+			t.Errorf("line %d: %q, expected %q", i, lines[i], exp)
+		}
+	}
+}
+
+func TestAuditMiddleware(t *testing.T) {
+	t.Parallel()
+
+	evLogger := events.NewLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go evLogger.Serve(ctx)
+	sub := evLogger.Subscribe(events.APIRequest)
+	defer sub.Unsubscribe()
+
+	current := config.New(protocol.LocalDeviceID)
+	current.GUI.APIKey = "secret"
+	cfg := newMockedConfig()
+	cfg.ModifyCalls(func(fn config.ModifyFunction) (config.Waiter, error) {
+		to := current.Copy()
+		fn(&to)
+		current = to
+		return nil, nil
+	})
+	cfg.RawCopyCalls(func() config.Configuration {
+		return current.Copy()
+	})
+	mdb, err := sqlite.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		mdb.Close()
+	})
+	history := confighistory.New(cfg, evLogger, db.NewMiscDB(mdb))
+
+	handler := auditMiddleware(evLogger, history, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			// A change by someone else while the request is handled
+			// isn't attributed to it.
+			cfg.Modify(func(cfg *config.Configuration) {
+				cfg.Options.MaxRecvKbps = 200
+			})
+			auditedConfig(r.Context(), cfg).Modify(func(cfg *config.Configuration) {
+				cfg.GUI.APIKey = "other secret"
+				cfg.Options.MaxSendKbps = 100
+			})
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/rest/system/status", nil),
+		httptest.NewRequest(http.MethodPost, "/rest/system/ping", nil),
+		httptest.NewRequest(http.MethodPost, rpcPrefix+"/controlproto.ControlService/ListFolders", nil),
+		withPrincipal(httptest.NewRequest(http.MethodPatch, "/rest/config/options?x=1", nil), rolePrincipal("jb", config.GUIRoleAdmin, nil)),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Only the mutation is audited.
+	var ev events.Event
+	select {
+	case ev = <-sub.C():
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event")
+	}
+	data := ev.Data.(map[string]any)
+	if data["username"] != "jb" || data["method"] != http.MethodPatch || data["endpoint"] != "/rest/config/options" || data["query"] != "x=1" || data["status"] != http.StatusAccepted {
+		t.Errorf("unexpected event data %v", data)
+	}
+	changes, _ := data["changes"].([]config.Change)
+	if len(changes) != 1 || changes[0].Path != "options.maxSendKbps" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+	if entries := history.Entries(); len(entries) != 1 || entries[0].Author != "jb" || entries[0].Source != confighistory.SourceAPI {
+		t.Errorf("unexpected history %v", entries)
+	}
+	select {
+	case ev := <-sub.C():
+		t.Errorf("unexpected event %v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
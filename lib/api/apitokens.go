@@ -0,0 +1,360 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package api
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/gen/apiproto"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+const (
+	apiTokenPrefix = "sttok-"
+	apiTokenLength = 40
+)
+
+// The scopes of API tokens.
+const (
+	apiTokenScopeAdmin    = "admin"
+	apiTokenScopeOperator = "operator"
+	apiTokenScopeRead     = "read"
+	apiTokenScopeEvents   = "events"
+)
+
+var (
+	errTokenExists     = errors.New("a token with that name already exists")
+	errTokenNotFound   = errors.New("no token with that name")
+	errTokenNameEmpty  = errors.New("token name must not be empty")
+	errTokenBadScope   = errors.New("token scope must be one of admin, operator, read or events")
+	errTokenBadExpires = errors.New("token expiry time is in the past")
+)
+
+// An apiTokenManager keeps the named API tokens, in addition to the API key
+// in the GUI configuration. Only a hash of each token is stored.
+type apiTokenManager struct {
+	miscDB  *db.Typed
+	timeNow func() time.Time // can be overridden for testing
+
+	mut       sync.Mutex
+	tokens    *apiproto.APITokenSet
+	saveTimer *time.Timer
+}
+
+func newAPITokenManager(miscDB *db.Typed) *apiTokenManager {
+	var tokens apiproto.APITokenSet
+	if bs, ok, _ := miscDB.Bytes("apiTokens"); ok {
+		_ = proto.Unmarshal(bs, &tokens) // best effort
+	}
+	if tokens.Tokens == nil {
+		tokens.Tokens = make(map[string]*apiproto.APIToken)
+	}
+	return &apiTokenManager{
+		miscDB:  miscDB,
+		timeNow: time.Now,
+		tokens:  &tokens,
+	}
+}
+
+// apiTokenInfo is the API representation of a token, without the secret.
+type apiTokenInfo struct {
+	Name        string    `json:"name"`
+	Scope       string    `json:"scope"`
+	Folders     []string  `json:"folders"`
+	Created     time.Time `json:"created"`
+	Expires     time.Time `json:"expires,omitzero"`
+	LastUsed    time.Time `json:"lastUsed,omitzero"`
+	LastAddress string    `json:"lastAddress,omitempty"`
+}
+
+func newAPITokenInfo(tok *apiproto.APIToken) apiTokenInfo {
+	info := apiTokenInfo{
+		Name:        tok.GetName(),
+		Scope:       tok.GetScope(),
+		Folders:     slices.Clone(tok.GetFolders()),
+		Created:     time.Unix(0, tok.GetCreated()),
+		LastAddress: tok.GetLastAddress(),
+	}
+	if info.Folders == nil {
+		info.Folders = []string{}
+	}
+	if tok.GetExpires() != 0 {
+		info.Expires = time.Unix(0, tok.GetExpires())
+	}
+	if tok.GetLastUsed() != 0 {
+		info.LastUsed = time.Unix(0, tok.GetLastUsed())
+	}
+	return info
+}
+
+// List returns the current tokens, sorted by name.
+func (m *apiTokenManager) List() []apiTokenInfo {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.pruneLocked()
+	infos := make([]apiTokenInfo, 0, len(m.tokens.Tokens))
+	for _, tok := range m.tokens.Tokens {
+		infos = append(infos, newAPITokenInfo(tok))
+	}
+	slices.SortFunc(infos, func(a, b apiTokenInfo) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+	return infos
+}
+
+// Create adds a token with the given properties and returns the secret
+// token, which is not stored.
+func (m *apiTokenManager) Create(name, scope string, folders []string, expires time.Time) (string, apiTokenInfo, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", apiTokenInfo{}, errTokenNameEmpty
+	}
+	switch scope {
+	case apiTokenScopeAdmin, apiTokenScopeOperator, apiTokenScopeRead, apiTokenScopeEvents:
+	default:
+		return "", apiTokenInfo{}, errTokenBadScope
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	now := m.timeNow()
+	if !expires.IsZero() && !expires.After(now) {
+		return "", apiTokenInfo{}, errTokenBadExpires
+	}
+	m.pruneLocked()
+	for _, tok := range m.tokens.Tokens {
+		if tok.GetName() == name {
+			return "", apiTokenInfo{}, errTokenExists
+		}
+	}
+
+	token := apiTokenPrefix + rand.String(apiTokenLength)
+	tok := &apiproto.APIToken{
+		Name:    name,
+		Scope:   scope,
+		Folders: slices.Clone(folders),
+		Created: now.UnixNano(),
+	}
+	if !expires.IsZero() {
+		tok.Expires = expires.UnixNano()
+	}
+	m.tokens.Tokens[hashAPIToken(token)] = tok
+	m.saveLocked()
+
+	return token, newAPITokenInfo(tok), nil
+}
+
+// Delete removes the token with the given name.
+func (m *apiTokenManager) Delete(name string) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	for hash, tok := range m.tokens.Tokens {
+		if tok.GetName() == name {
+			delete(m.tokens.Tokens, hash)
+			m.saveLocked()
+			return nil
+		}
+	}
+	return errTokenNotFound
+}
+
+// IsValidAPIKey returns true if the key is a current token, without
+// recording its use.
+func (m *apiTokenManager) IsValidAPIKey(key string) bool {
+	if !strings.HasPrefix(key, apiTokenPrefix) {
+		return false
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	tok, ok := m.tokens.Tokens[hashAPIToken(key)]
+	return ok && !m.expiredLocked(tok)
+}
+
+// authenticate returns the principal for the API token in the request, if
+// there is a current one, and records its use. Attempts with unknown or
+// expired tokens, and uses from a new address, result in a LoginAttempt
+// event.
+func (m *apiTokenManager) authenticate(r *http.Request, evLogger events.Logger) (*principal, bool) {
+	key := apiKeyFromRequest(r)
+	if !strings.HasPrefix(key, apiTokenPrefix) {
+		return nil, false
+	}
+	address, _ := remoteAddress(r)
+
+	m.mut.Lock()
+	tok, ok := m.tokens.Tokens[hashAPIToken(key)]
+	if !ok || m.expiredLocked(tok) {
+		m.mut.Unlock()
+		name := ""
+		if ok {
+			name = tok.GetName()
+		}
+		emitAPITokenAttempt(false, name, r, evLogger)
+		antiBruteForceSleep()
+		return nil, false
+	}
+	newAddress := tok.GetLastAddress() != address
+	tok.LastUsed = m.timeNow().UnixNano()
+	tok.LastAddress = address
+	name, p := tok.GetName(), tokenPrincipal(tok)
+	m.saveLocked()
+	m.mut.Unlock()
+
+	if newAddress {
+		emitAPITokenAttempt(true, name, r, evLogger)
+	}
+	return p, true
+}
+
+func tokenPrincipal(tok *apiproto.APIToken) *principal {
+	name := "token:" + tok.GetName()
+	switch tok.GetScope() {
+	case apiTokenScopeAdmin:
+		return rolePrincipal(name, config.GUIRoleAdmin, nil)
+	case apiTokenScopeOperator:
+		return rolePrincipal(name, config.GUIRoleOperator, tok.GetFolders())
+	case apiTokenScopeEvents:
+		p := rolePrincipal(name, config.GUIRoleViewer, tok.GetFolders())
+		p.eventsOnly = true
+		return p
+	default:
+		return rolePrincipal(name, config.GUIRoleViewer, tok.GetFolders())
+	}
+}
+
+func (m *apiTokenManager) expiredLocked(tok *apiproto.APIToken) bool {
+	return tok.GetExpires() != 0 && tok.GetExpires() < m.timeNow().UnixNano()
+}
+
+func (m *apiTokenManager) pruneLocked() {
+	for hash, tok := range m.tokens.Tokens {
+		if m.expiredLocked(tok) {
+			delete(m.tokens.Tokens, hash)
+		}
+	}
+}
+
+func (m *apiTokenManager) saveLocked() {
+	m.pruneLocked()
+
+	// Postpone saving until one second of inactivity.
+	if m.saveTimer == nil {
+		m.saveTimer = time.AfterFunc(time.Second, m.scheduledSave)
+	} else {
+		m.saveTimer.Reset(time.Second)
+	}
+}
+
+func (m *apiTokenManager) scheduledSave() {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	m.saveTimer = nil
+
+	bs, _ := proto.Marshal(m.tokens)       // can't fail
+	_ = m.miscDB.PutBytes("apiTokens", bs) // can fail, but what are we going to do?
+}
+
+func hashAPIToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+func emitAPITokenAttempt(success bool, name string, r *http.Request, evLogger events.Logger) {
+	remoteAddress, proxy := remoteAddress(r)
+	evData := map[string]any{
+		"success":       success,
+		"apiToken":      name,
+		"remoteAddress": remoteAddress,
+	}
+	if proxy != "" {
+		evData["proxy"] = proxy
+	}
+	evLogger.Log(events.LoginAttempt, evData)
+
+	if success {
+		return
+	}
+	l := slog.Default().With(slogutil.Address(remoteAddress), slog.String("apiToken", name))
+	if proxy != "" {
+		l = l.With("proxy", proxy)
+	}
+	l.Warn("Invalid or expired API token supplied during API authorization")
+}
+
+// apiKeyValidators accepts a key that any of the validators accepts.
+type apiKeyValidators []apiKeyValidator
+
+func (vs apiKeyValidators) IsValidAPIKey(key string) bool {
+	return slices.ContainsFunc(vs, func(v apiKeyValidator) bool {
+		return v.IsValidAPIKey(key)
+	})
+}
+
+func (c *configMuxBuilder) registerAPITokens(path string) {
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
+		sendJSON(w, c.apiTokens.List())
+	})
+
+	c.HandlerFunc(http.MethodPost, path, func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Name    string    `json:"name"`
+			Scope   string    `json:"scope"`
+			Folders []string  `json:"folders"`
+			Expires time.Time `json:"expires"`
+		}
+		if err := unmarshalTo(r.Body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if req.Scope == "" {
+			req.Scope = apiTokenScopeRead
+		}
+		token, info, err := c.apiTokens.Create(req.Name, req.Scope, req.Folders, req.Expires)
+		switch {
+		case errors.Is(err, errTokenExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sendJSON(w, struct {
+			apiTokenInfo
+			Token string `json:"token"`
+		}{info, token})
+	})
+
+	c.Handle(http.MethodDelete, path+"/:name", func(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+		if err := c.apiTokens.Delete(p.ByName("name")); err != nil {
+			http.Error(w, fmt.Sprintf("%s: %s", err, p.ByName("name")), http.StatusNotFound)
+		}
+	})
+}
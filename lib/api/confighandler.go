@@ -8,28 +8,33 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log/slog"
 	"net/http"
+	"strconv"
 
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/syncthing/syncthing/internal/slogutil"
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/confighistory"
 	"github.com/syncthing/syncthing/lib/protocol"
 	"github.com/syncthing/syncthing/lib/structutil"
 )
 
 type configMuxBuilder struct {
-	*httprouter.Router
+	*authzRouter
 
-	id  protocol.DeviceID
-	cfg config.Wrapper
+	id        protocol.DeviceID
+	cfg       config.Wrapper
+	apiTokens *apiTokenManager
+	history   *confighistory.History
 }
 
 func (c *configMuxBuilder) registerConfig(path string) {
-	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
-		sendJSON(w, c.cfg.RawCopy())
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, redactConfig(principalFrom(r), c.cfg.RawCopy()))
 	})
 
 	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
@@ -38,8 +43,8 @@ func (c *configMuxBuilder) registerConfig(path string) {
 }
 
 func (c *configMuxBuilder) registerConfigDeprecated(path string) {
-	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
-		sendJSON(w, c.cfg.RawCopy())
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, redactConfig(principalFrom(r), c.cfg.RawCopy()))
 	})
 
 	c.HandlerFunc(http.MethodPost, path, func(w http.ResponseWriter, r *http.Request) {
@@ -60,8 +65,8 @@ func (c *configMuxBuilder) registerConfigRequiresRestart(path string) {
 }
 
 func (c *configMuxBuilder) registerFolders(path string) {
-	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
-		sendJSON(w, c.cfg.FolderList())
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, redactFolders(principalFrom(r), c.cfg.FolderList()))
 	})
 
 	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
@@ -79,7 +84,7 @@ func (c *configMuxBuilder) registerFolders(path string) {
 				return
 			}
 		}
-		waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+		waiter, err := auditedConfig(r.Context(), c.cfg).Modify(func(cfg *config.Configuration) {
 			cfg.SetFolders(folders)
 		})
 		if err != nil {
@@ -114,7 +119,7 @@ func (c *configMuxBuilder) registerDevices(path string) {
 				return
 			}
 		}
-		waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+		waiter, err := auditedConfig(r.Context(), c.cfg).Modify(func(cfg *config.Configuration) {
 			cfg.SetDevices(devices)
 		})
 		if err != nil {
@@ -130,12 +135,15 @@ func (c *configMuxBuilder) registerDevices(path string) {
 }
 
 func (c *configMuxBuilder) registerFolder(path string) {
-	c.Handle(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	c.Handle(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 		folder, ok := c.cfg.Folder(p.ByName("id"))
 		if !ok {
 			http.Error(w, "No folder with given ID", http.StatusNotFound)
 			return
 		}
+		if !principalFrom(r).isAdmin() {
+			folder = redactFolder(folder)
+		}
 		sendJSON(w, folder)
 	})
 
@@ -152,8 +160,8 @@ func (c *configMuxBuilder) registerFolder(path string) {
 		c.adjustFolder(w, r, folder, false)
 	})
 
-	c.Handle(http.MethodDelete, path, func(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
-		waiter, err := c.cfg.RemoveFolder(p.ByName("id"))
+	c.Handle(http.MethodDelete, path, func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
+		waiter, err := auditedConfig(r.Context(), c.cfg).RemoveFolder(p.ByName("id"))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -193,13 +201,13 @@ func (c *configMuxBuilder) registerDevice(path string) {
 		}
 	})
 
-	c.Handle(http.MethodDelete, path, func(w http.ResponseWriter, _ *http.Request, p httprouter.Params) {
+	c.Handle(http.MethodDelete, path, func(w http.ResponseWriter, r *http.Request, p httprouter.Params) {
 		id, err := protocol.DeviceIDFromString(p.ByName("id"))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		waiter, err := c.cfg.RemoveDevice(id)
+		waiter, err := auditedConfig(r.Context(), c.cfg).RemoveDevice(id)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -209,8 +217,12 @@ func (c *configMuxBuilder) registerDevice(path string) {
 }
 
 func (c *configMuxBuilder) registerDefaultFolder(path string) {
-	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
-		sendJSON(w, c.cfg.DefaultFolder())
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, r *http.Request) {
+		folder := c.cfg.DefaultFolder()
+		if !principalFrom(r).isAdmin() {
+			folder = redactFolder(folder)
+		}
+		sendJSON(w, folder)
 	})
 
 	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
@@ -251,7 +263,7 @@ func (c *configMuxBuilder) registerDefaultIgnores(path string) {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-		waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+		waiter, err := auditedConfig(r.Context(), c.cfg).Modify(func(cfg *config.Configuration) {
 			cfg.Defaults.Ignores = ignores
 		})
 		if err != nil {
@@ -294,6 +306,22 @@ func (c *configMuxBuilder) registerLDAP(path string) {
 	})
 }
 
+func (c *configMuxBuilder) registerOIDC(path string) {
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
+		sendJSON(w, c.cfg.OIDC())
+	})
+
+	c.HandlerFunc(http.MethodPut, path, func(w http.ResponseWriter, r *http.Request) {
+		var cfg config.OIDCConfiguration
+		structutil.SetDefaults(&cfg)
+		c.adjustOIDC(w, r, cfg)
+	})
+
+	c.HandlerFunc(http.MethodPatch, path, func(w http.ResponseWriter, r *http.Request) {
+		c.adjustOIDC(w, r, c.cfg.OIDC())
+	})
+}
+
 func (c *configMuxBuilder) registerGUI(path string) {
 	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
 		sendJSON(w, c.cfg.GUI())
@@ -310,6 +338,59 @@ func (c *configMuxBuilder) registerGUI(path string) {
 	})
 }
 
+func (c *configMuxBuilder) registerHistory(path string) {
+	c.HandlerFunc(http.MethodGet, path, func(w http.ResponseWriter, _ *http.Request) {
+		entries := []confighistory.Entry{}
+		if c.history != nil {
+			entries = append(entries, c.history.Entries()...)
+		}
+		sendJSON(w, entries)
+	})
+
+	// The changes from one version to another, or to the current
+	// configuration.
+	c.HandlerFunc(http.MethodGet, path+"/diff", func(w http.ResponseWriter, r *http.Request) {
+		qs := r.URL.Query()
+		from, err := c.historyVersion(qs.Get("from"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		to := c.cfg.RawCopy()
+		if qs.Has("to") {
+			if to, err = c.historyVersion(qs.Get("to")); err != nil {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+		}
+		changes := []config.Change{}
+		changes = append(changes, config.Diff(redactSecrets(from), redactSecrets(to))...)
+		sendJSON(w, changes)
+	})
+
+	c.HandlerFunc(http.MethodPost, path+"/rollback", func(w http.ResponseWriter, r *http.Request) {
+		version, err := strconv.Atoi(r.URL.Query().Get("version"))
+		if err != nil || c.history == nil {
+			http.Error(w, confighistory.ErrNoSuchVersion.Error(), http.StatusNotFound)
+			return
+		}
+		switch err := c.history.Rollback(version, principalFrom(r).name); {
+		case errors.Is(err, confighistory.ErrNoSuchVersion):
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func (c *configMuxBuilder) historyVersion(s string) (config.Configuration, error) {
+	version, err := strconv.Atoi(s)
+	if err != nil || c.history == nil {
+		return config.Configuration{}, confighistory.ErrNoSuchVersion
+	}
+	return c.history.Config(version)
+}
+
 func (c *configMuxBuilder) adjustConfig(w http.ResponseWriter, r *http.Request) {
 	to, err := config.ReadJSON(r.Body, c.id)
 	r.Body.Close()
@@ -320,7 +401,7 @@ func (c *configMuxBuilder) adjustConfig(w http.ResponseWriter, r *http.Request)
 	}
 	var errMsg string
 	var status int
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := auditedConfig(r.Context(), c.cfg).Modify(func(cfg *config.Configuration) {
 		if err := c.postAdjustGui(&cfg.GUI, &to.GUI); err != nil {
 			errMsg = err.Error()
 			status = http.StatusInternalServerError
@@ -342,7 +423,7 @@ func (c *configMuxBuilder) adjustFolder(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := auditedConfig(r.Context(), c.cfg).Modify(func(cfg *config.Configuration) {
 		if defaults {
 			cfg.Defaults.Folder = folder
 		} else {
@@ -361,7 +442,7 @@ func (c *configMuxBuilder) adjustDevice(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := auditedConfig(r.Context(), c.cfg).Modify(func(cfg *config.Configuration) {
 		if defaults {
 			cfg.Defaults.Device = device
 		} else {
@@ -380,7 +461,7 @@ func (c *configMuxBuilder) adjustOptions(w http.ResponseWriter, r *http.Request,
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := auditedConfig(r.Context(), c.cfg).Modify(func(cfg *config.Configuration) {
 		cfg.Options = opts
 	})
 	if err != nil {
@@ -398,7 +479,7 @@ func (c *configMuxBuilder) adjustGUI(w http.ResponseWriter, r *http.Request, gui
 	}
 	var errMsg string
 	var status int
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := auditedConfig(r.Context(), c.cfg).Modify(func(cfg *config.Configuration) {
 		if err := c.postAdjustGui(&cfg.GUI, &gui); err != nil {
 			errMsg = err.Error()
 			status = http.StatusInternalServerError
@@ -422,6 +503,13 @@ func (c *configMuxBuilder) postAdjustGui(from *config.GUIConfiguration, to *conf
 			return err
 		}
 	}
+	for i := range to.Accounts {
+		// SetPassword leaves existing hashes alone
+		if err := to.Accounts[i].SetPassword(to.Accounts[i].Password); err != nil {
+			slog.Error("Failed to hash password", slogutil.Error(err), slog.String("username", to.Accounts[i].Name))
+			return err
+		}
+	}
 	return nil
 }
 
@@ -430,7 +518,7 @@ func (c *configMuxBuilder) adjustLDAP(w http.ResponseWriter, r *http.Request, ld
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	waiter, err := c.cfg.Modify(func(cfg *config.Configuration) {
+	waiter, err := auditedConfig(r.Context(), c.cfg).Modify(func(cfg *config.Configuration) {
 		cfg.LDAP = ldap
 	})
 	if err != nil {
@@ -440,6 +528,21 @@ func (c *configMuxBuilder) adjustLDAP(w http.ResponseWriter, r *http.Request, ld
 	c.finish(w, waiter)
 }
 
+func (c *configMuxBuilder) adjustOIDC(w http.ResponseWriter, r *http.Request, oidc config.OIDCConfiguration) {
+	if err := unmarshalTo(r.Body, &oidc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	waiter, err := auditedConfig(r.Context(), c.cfg).Modify(func(cfg *config.Configuration) {
+		cfg.OIDC = oidc
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	c.finish(w, waiter)
+}
+
 // Unmarshals the content of the given body and stores it in to (i.e. to must be a pointer).
 func unmarshalTo(body io.ReadCloser, to interface{}) error {
 	bs, err := io.ReadAll(body)
@@ -43,6 +43,9 @@ func newTokenManager(key string, miscDB *db.Typed, lifetime time.Duration, maxIt
 	if tokens.Tokens == nil {
 		tokens.Tokens = make(map[string]int64)
 	}
+	if tokens.Owners == nil {
+		tokens.Owners = make(map[string]*apiproto.TokenOwner)
+	}
 	return &tokenManager{
 		key:      key,
 		miscDB:   miscDB,
@@ -56,6 +59,13 @@ func newTokenManager(key string, miscDB *db.Typed, lifetime time.Duration, maxIt
 // Check returns true if the token is valid, and updates the token's expiry
 // time. The token is removed if it is expired.
 func (m *tokenManager) Check(token string) bool {
+	_, ok := m.CheckOwner(token)
+	return ok
+}
+
+// CheckOwner is like Check, and also returns the owner of the token, if
+// one was given when the token was created.
+func (m *tokenManager) CheckOwner(token string) (*apiproto.TokenOwner, bool) {
 	m.mut.Lock()
 	defer m.mut.Unlock()
 
@@ -64,24 +74,33 @@ func (m *tokenManager) Check(token string) bool {
 		if expires < m.timeNow().UnixNano() {
 			// The token is expired.
 			m.saveLocked() // removes expired tokens
-			return false
+			return nil, false
 		}
 
 		// Give the token further life.
 		m.tokens.Tokens[token] = m.timeNow().Add(m.lifetime).UnixNano()
 		m.saveLocked()
 	}
-	return ok
+	return m.tokens.Owners[token], ok
 }
 
 // New creates a new token and returns it.
 func (m *tokenManager) New() string {
+	return m.NewOwned(nil)
+}
+
+// NewOwned creates a new token belonging to the given owner and returns
+// it.
+func (m *tokenManager) NewOwned(owner *apiproto.TokenOwner) string {
 	token := rand.String(randomTokenLength)
 
 	m.mut.Lock()
 	defer m.mut.Unlock()
 
 	m.tokens.Tokens[token] = m.timeNow().Add(m.lifetime).UnixNano()
+	if owner != nil {
+		m.tokens.Owners[token] = owner
+	}
 	m.saveLocked()
 
 	return token
@@ -125,6 +144,13 @@ func (m *tokenManager) saveLocked() {
 		}
 	}
 
+	// Forget the owners of removed tokens.
+	for token := range m.tokens.Owners {
+		if _, ok := m.tokens.Tokens[token]; !ok {
+			delete(m.tokens.Owners, token)
+		}
+	}
+
 	// Postpone saving until one second of inactivity.
 	if m.saveTimer == nil {
 		m.saveTimer = time.AfterFunc(time.Second, m.scheduledSave)
@@ -161,18 +187,8 @@ func newTokenCookieManager(shortID string, guiCfg config.GUIConfiguration, evLog
 	}
 }
 
-func (m *tokenCookieManager) createSession(username string, persistent bool, w http.ResponseWriter, r *http.Request) {
-	sessionid := m.tokens.New()
-
-	// Best effort detection of whether the connection is HTTPS --
-	// either directly to us, or as used by the client towards a reverse
-	// proxy who sends us headers.
-	connectionIsHTTPS := r.TLS != nil ||
-		strings.ToLower(r.Header.Get("X-Forwarded-Proto")) == "https" ||
-		strings.Contains(strings.ToLower(r.Header.Get("Forwarded")), "proto=https")
-	// If the connection is HTTPS, or *should* be HTTPS, set the Secure
-	// bit in cookies.
-	useSecureCookie := connectionIsHTTPS || m.guiCfg.UseTLS()
+func (m *tokenCookieManager) createSession(owner *apiproto.TokenOwner, persistent bool, w http.ResponseWriter, r *http.Request) {
+	sessionid := m.tokens.NewOwned(owner)
 
 	maxAge := 0
 	if persistent {
@@ -184,26 +200,42 @@ func (m *tokenCookieManager) createSession(username string, persistent bool, w h
 		// In HTTP spec Max-Age <= 0 means delete immediately,
 		// but in http.Cookie MaxAge = 0 means unspecified (session) and MaxAge < 0 means delete immediately
 		MaxAge: maxAge,
-		Secure: useSecureCookie,
+		Secure: m.useSecureCookie(r),
 		Path:   "/",
 	})
 
-	emitLoginAttempt(true, username, r, m.evLogger)
+	emitLoginAttempt(true, owner.GetName(), r, m.evLogger)
+}
+
+// useSecureCookie returns whether cookies set in response to the request
+// should have the Secure bit.
+func (m *tokenCookieManager) useSecureCookie(r *http.Request) bool {
+	// Best effort detection of whether the connection is HTTPS --
+	// either directly to us, or as used by the client towards a reverse
+	// proxy who sends us headers.
+	connectionIsHTTPS := r.TLS != nil ||
+		strings.ToLower(r.Header.Get("X-Forwarded-Proto")) == "https" ||
+		strings.Contains(strings.ToLower(r.Header.Get("Forwarded")), "proto=https")
+	// If the connection is HTTPS, or *should* be HTTPS, set the Secure
+	// bit in cookies.
+	return connectionIsHTTPS || m.guiCfg.UseTLS()
 }
 
-func (m *tokenCookieManager) hasValidSession(r *http.Request) bool {
+// sessionOwner returns the owner of the request's session, if it has a
+// valid one. Sessions created before owners were recorded have a nil owner.
+func (m *tokenCookieManager) sessionOwner(r *http.Request) (*apiproto.TokenOwner, bool) {
 	for _, cookie := range r.Cookies() {
 		// We iterate here since there may, historically, be multiple
 		// cookies with the same name but different path. Any "old" ones
 		// won't match an existing session and will be ignored, then
 		// later removed on logout or when timing out.
 		if cookie.Name == m.cookieName {
-			if m.tokens.Check(cookie.Value) {
-				return true
+			if owner, ok := m.tokens.CheckOwner(cookie.Value); ok {
+				return owner, true
 			}
 		}
 	}
-	return false
+	return nil, false
 }
 
 func (m *tokenCookieManager) destroySession(w http.ResponseWriter, r *http.Request) {
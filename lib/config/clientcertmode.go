@@ -0,0 +1,46 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+// ClientCertMode determines whether GUI and API clients are asked for a TLS
+// client certificate.
+type ClientCertMode int32
+
+const (
+	ClientCertModeOff      ClientCertMode = 0
+	ClientCertModeOptional ClientCertMode = 1 // verified if presented
+	ClientCertModeRequired ClientCertMode = 2
+)
+
+func (t ClientCertMode) String() string {
+	switch t {
+	case ClientCertModeOff:
+		return "off"
+	case ClientCertModeOptional:
+		return "optional"
+	case ClientCertModeRequired:
+		return "required"
+	default:
+		return "unknown"
+	}
+}
+
+func (t ClientCertMode) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *ClientCertMode) UnmarshalText(bs []byte) error {
+	switch string(bs) {
+	case "optional":
+		*t = ClientCertModeOptional
+	case "required":
+		*t = ClientCertModeRequired
+	default:
+		*t = ClientCertModeOff
+	}
+	return nil
+}
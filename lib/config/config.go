@@ -33,7 +33,7 @@ import (
 
 const (
 	OldestHandledVersion = 10
-	CurrentVersion       = 51
+	CurrentVersion       = 52
 	MaxRescanIntervalS   = 365 * 24 * 60 * 60
 )
 
@@ -92,15 +92,17 @@ var (
 )
 
 type Configuration struct {
-	Version                  int                   `json:"version" xml:"version,attr"`
-	Folders                  []FolderConfiguration `json:"folders" xml:"folder"`
-	Devices                  []DeviceConfiguration `json:"devices" xml:"device"`
-	GUI                      GUIConfiguration      `json:"gui" xml:"gui"`
-	LDAP                     LDAPConfiguration     `json:"ldap" xml:"ldap"`
-	Options                  OptionsConfiguration  `json:"options" xml:"options"`
-	IgnoredDevices           []ObservedDevice      `json:"remoteIgnoredDevices" xml:"remoteIgnoredDevice"`
-	DeprecatedPendingDevices []ObservedDevice      `json:"-" xml:"pendingDevice,omitempty"` // Deprecated: Do not use.
-	Defaults                 Defaults              `json:"defaults" xml:"defaults"`
+	Version                  int                    `json:"version" xml:"version,attr"`
+	Folders                  []FolderConfiguration  `json:"folders" xml:"folder"`
+	Devices                  []DeviceConfiguration  `json:"devices" xml:"device"`
+	GUI                      GUIConfiguration       `json:"gui" xml:"gui"`
+	LDAP                     LDAPConfiguration      `json:"ldap" xml:"ldap"`
+	OIDC                     OIDCConfiguration      `json:"oidc" xml:"oidc"`
+	Options                  OptionsConfiguration   `json:"options" xml:"options"`
+	IgnoredDevices           []ObservedDevice       `json:"remoteIgnoredDevices" xml:"remoteIgnoredDevice"`
+	DeprecatedPendingDevices []ObservedDevice       `json:"-" xml:"pendingDevice,omitempty"` // Deprecated: Do not use.
+	Defaults                 Defaults               `json:"defaults" xml:"defaults"`
+	Webhooks                 []WebhookConfiguration `json:"webhooks" xml:"webhook"`
 }
 
 type Defaults struct {
@@ -252,11 +254,18 @@ func (cfg Configuration) Copy() Configuration {
 
 	newCfg.Options = cfg.Options.Copy()
 	newCfg.GUI = cfg.GUI.Copy()
+	newCfg.LDAP = cfg.LDAP.Copy()
+	newCfg.OIDC = cfg.OIDC.Copy()
 
 	// DeviceIDs are values
 	newCfg.IgnoredDevices = make([]ObservedDevice, len(cfg.IgnoredDevices))
 	copy(newCfg.IgnoredDevices, cfg.IgnoredDevices)
 
+	newCfg.Webhooks = make([]WebhookConfiguration, len(cfg.Webhooks))
+	for i := range newCfg.Webhooks {
+		newCfg.Webhooks[i] = cfg.Webhooks[i].Copy()
+	}
+
 	return newCfg
 }
 
@@ -289,6 +298,8 @@ func (cfg *Configuration) prepare(myID protocol.DeviceID) error {
 
 	cfg.Defaults.prepare(myID, existingDevices)
 
+	cfg.prepareWebhooks()
+
 	cfg.removeDeprecatedProtocols()
 
 	structutil.FillNilExceptDeprecated(cfg)
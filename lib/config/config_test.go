@@ -71,6 +71,8 @@ func TestDefaultValues(t *testing.T) {
 			NATRenewalM:               30,
 			NATTimeoutS:               10,
 			AutoUpgradeIntervalH:      12,
+			AutoUpgradeRolloutPct:     100,
+			AutoUpgradeHealthCheckS:   300,
 			KeepTemporariesH:          24,
 			CacheIgnoredFiles:         false,
 			ProgressUpdateIntervalS:   5,
@@ -80,6 +82,8 @@ func TestDefaultValues(t *testing.T) {
 			URInitialDelayS:           1800,
 			URPostInsecurely:          false,
 			ReleasesURL:               "https://upgrades.syncthing.net/meta.json",
+			ReleaseChannel:            "stable",
+			ReleaseSigningKeys:        []string{},
 			AlwaysLocalNets:           []string{},
 			OverwriteRemoteDevNames:   false,
 			TempIndexMinBlocks:        10,
@@ -94,11 +98,14 @@ func TestDefaultValues(t *testing.T) {
 			FeatureFlags:              []string{},
 			AuditEnabled:              false,
 			AuditFile:                 "",
+			AuditEvents:               []events.EventType{},
+			AuditMaxFiles:             5,
 			ConnectionPriorityTCPLAN:  10,
 			ConnectionPriorityQUICLAN: 20,
 			ConnectionPriorityTCPWAN:  30,
 			ConnectionPriorityQUICWAN: 40,
 			ConnectionPriorityRelay:   50,
+			MaxConcurrentHooks:        2,
 		},
 		Defaults: Defaults{
 			Folder: FolderConfiguration{
@@ -126,6 +133,11 @@ func TestDefaultValues(t *testing.T) {
 					MaxSingleEntrySize: 1024,
 					MaxTotalSize:       4096,
 				},
+				RansomwareMinFiles: 10,
+				ScrubMaxKiBps:      10240,
+				PostSyncTimeoutS:   60,
+				PullPriorities:     []PullPriority{},
+				SharedIgnores:      SharedIgnores{Lines: []string{}},
 			},
 			Device: DeviceConfiguration{
 				Addresses:       []string{"dynamic"},
@@ -138,6 +150,7 @@ func TestDefaultValues(t *testing.T) {
 			},
 		},
 		IgnoredDevices: []ObservedDevice{},
+		Webhooks:       []WebhookConfiguration{},
 	}
 	expected.Devices = []DeviceConfiguration{expected.Defaults.Device.Copy()}
 	expected.Devices[0].DeviceID = device1
@@ -146,6 +159,7 @@ func TestDefaultValues(t *testing.T) {
 	cfg := New(device1)
 	cfg.GUI = GUIConfiguration{}
 	cfg.LDAP = LDAPConfiguration{}
+	cfg.OIDC = OIDCConfiguration{}
 
 	if diff, equal := messagediff.PrettyDiff(expected, cfg); !equal {
 		t.Errorf("Default config differs. Diff:\n%s", diff)
@@ -204,6 +218,12 @@ func TestDeviceConfig(t *testing.T) {
 					MaxTotalSize:       4096,
 					Entries:            []XattrFilterEntry{},
 				},
+				RansomwareMinFiles:     10,
+				ScrubMaxKiBps:          10240,
+				PostSyncTimeoutS:       60,
+				ShareBlocksWithFolders: true, // existed before it was a setting
+				PullPriorities:         []PullPriority{},
+				SharedIgnores:          SharedIgnores{Lines: []string{}},
 			},
 		}
 
@@ -275,6 +295,9 @@ func TestOverriddenValues(t *testing.T) {
 		NATRenewalM:               15,
 		NATTimeoutS:               15,
 		AutoUpgradeIntervalH:      24,
+		AutoUpgradeRolloutPct:     25,
+		AutoUpgradeMinReleaseAgeH: 48,
+		AutoUpgradeHealthCheckS:   60,
 		KeepTemporariesH:          48,
 		CacheIgnoredFiles:         true,
 		ProgressUpdateIntervalS:   10,
@@ -286,6 +309,8 @@ func TestOverriddenValues(t *testing.T) {
 		URInitialDelayS:           800,
 		URPostInsecurely:          true,
 		ReleasesURL:               "https://localhost/releases",
+		ReleaseChannel:            "internal",
+		ReleaseSigningKeys:        []string{"key"},
 		AlwaysLocalNets:           []string{},
 		OverwriteRemoteDevNames:   true,
 		TempIndexMinBlocks:        100,
@@ -299,11 +324,17 @@ func TestOverriddenValues(t *testing.T) {
 		FeatureFlags:              []string{"feature"},
 		AuditEnabled:              true,
 		AuditFile:                 "nggyu",
+		AuditEvents:               []events.EventType{events.ConfigSaved, events.APIRequest},
+		AuditMaxSizeKiB:           1024,
+		AuditMaxAgeH:              24,
+		AuditMaxFiles:             3,
+		AuditSyslogAddress:        "default",
 		ConnectionPriorityTCPLAN:  40,
 		ConnectionPriorityQUICLAN: 45,
 		ConnectionPriorityTCPWAN:  50,
 		ConnectionPriorityQUICWAN: 55,
 		ConnectionPriorityRelay:   9000,
+		MaxConcurrentHooks:        4,
 	}
 	expectedPath := "/media/syncthing"
 
@@ -1678,3 +1709,70 @@ func TestCopyMatching(t *testing.T) {
 		t.Error("NoCopy")
 	}
 }
+
+func TestWebhookConfiguration(t *testing.T) {
+	t.Parallel()
+
+	const data = `<webhook id="chat"><url>https://example.com/hook</url><event>FolderCompletion</event><event>StateChanged</event><folder>photos</folder></webhook>`
+	var wc WebhookConfiguration
+	if err := xml.Unmarshal([]byte(data), &wc); err != nil {
+		t.Fatal(err)
+	}
+	if wc.EventMask() != events.FolderCompletion|events.StateChanged {
+		t.Errorf("unexpected event mask %v", wc.EventMask())
+	}
+	if wc.URL != "https://example.com/hook" || len(wc.Folders) != 1 {
+		t.Errorf("unexpected config %+v", wc)
+	}
+	if (WebhookConfiguration{}).EventMask() != events.AllEvents {
+		t.Error("webhook without events should get all events")
+	}
+
+	// Webhooks get unique IDs.
+	cfg := New(device1)
+	cfg.Webhooks = []WebhookConfiguration{{URL: "a"}, {ID: "x", URL: "b"}, {ID: "x", URL: "c"}}
+	if err := cfg.prepare(device1); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Webhooks[0].ID == "" || cfg.Webhooks[1].ID != "x" || cfg.Webhooks[2].ID == "x" || cfg.Webhooks[2].ID == "" {
+		t.Errorf("unexpected webhook IDs %v %v %v", cfg.Webhooks[0].ID, cfg.Webhooks[1].ID, cfg.Webhooks[2].ID)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	from := New(device1)
+	from.Folders = []FolderConfiguration{{ID: "a", Path: "/a"}, {ID: "b", Path: "/b"}}
+	from.Devices = []DeviceConfiguration{{DeviceID: device1, Addresses: []string{"dynamic"}}}
+	to := from.Copy()
+	to.Folders = []FolderConfiguration{{ID: "b", Path: "/b", Paused: true}, {ID: "c", Path: "/c"}}
+	to.Devices[0].Addresses = []string{"tcp://192.0.2.1:22000"}
+	to.Options.MaxSendKbps = 100
+
+	if changes := Diff(from, from.Copy()); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+
+	changes := Diff(from, to)
+	var paths []string
+	for _, c := range changes {
+		paths = append(paths, c.Path)
+	}
+	expected := []string{
+		"devices[" + device1.String() + "].addresses",
+		"folders[a]",
+		"folders[b].paused",
+		"folders[c]",
+		"options.maxSendKbps",
+	}
+	if !slices.Equal(paths, expected) {
+		t.Fatalf("expected changes to %v, got %v", expected, paths)
+	}
+	if changes[1].To != nil || changes[3].From != nil {
+		t.Errorf("unexpected removal or addition %v, %v", changes[1], changes[3])
+	}
+	if changes[2].From != false || changes[2].To != true {
+		t.Errorf("unexpected change %v", changes[2])
+	}
+}
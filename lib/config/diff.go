@@ -0,0 +1,159 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// A Change is a setting that differs between two configurations. The path
+// is made up of the JSON names of the settings, with list entries given by
+// their ID in brackets, as in "folders[abcd-1234].paused". From is nil for
+// added entries, To for removed ones.
+type Change struct {
+	Path string `json:"path"`
+	From any    `json:"from,omitempty"`
+	To   any    `json:"to,omitempty"`
+}
+
+// The fields that identify list entries, in order of preference.
+var diffKeys = []string{"id", "deviceID", "name"}
+
+// Diff returns the settings that differ between the two configurations,
+// ordered by path. Lists without identifiable entries, such as addresses,
+// are compared as a whole.
+func Diff(from, to Configuration) []Change {
+	var changes []Change
+	diffValues(&changes, "", jsonValue(from), jsonValue(to))
+	slices.SortFunc(changes, func(a, b Change) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+	return changes
+}
+
+func jsonValue(cfg Configuration) any {
+	bs, err := json.Marshal(cfg)
+	if err != nil {
+		panic("bug: configuration must marshal: " + err.Error())
+	}
+	var v any
+	_ = json.Unmarshal(bs, &v)
+	return v
+}
+
+func diffValues(changes *[]Change, path string, from, to any) {
+	switch from := from.(type) {
+	case map[string]any:
+		if to, ok := to.(map[string]any); ok {
+			diffMaps(changes, path, from, to)
+			return
+		}
+	case []any:
+		if to, ok := to.([]any); ok {
+			if key, ok := listKey(from, to); ok {
+				diffLists(changes, path, key, from, to)
+				return
+			}
+		}
+	}
+	if isEmpty(from) && isEmpty(to) {
+		// Nil and empty lists are the same setting.
+		return
+	}
+	if !reflect.DeepEqual(from, to) {
+		*changes = append(*changes, Change{Path: path, From: from, To: to})
+	}
+}
+
+func diffMaps(changes *[]Change, path string, from, to map[string]any) {
+	for name, fv := range from {
+		diffValues(changes, joinPath(path, name), fv, to[name])
+	}
+	for name, tv := range to {
+		if _, ok := from[name]; !ok {
+			diffValues(changes, joinPath(path, name), nil, tv)
+		}
+	}
+}
+
+func diffLists(changes *[]Change, path, key string, from, to []any) {
+	fromByKey := make(map[string]any, len(from))
+	for _, e := range from {
+		fromByKey[entryKey(e, key)] = e
+	}
+	toByKey := make(map[string]any, len(to))
+	for _, e := range to {
+		toByKey[entryKey(e, key)] = e
+	}
+	for k, fe := range fromByKey {
+		entryPath := fmt.Sprintf("%s[%s]", path, k)
+		if te, ok := toByKey[k]; ok {
+			diffValues(changes, entryPath, fe, te)
+		} else {
+			*changes = append(*changes, Change{Path: entryPath, From: fe})
+		}
+	}
+	for k, te := range toByKey {
+		if _, ok := fromByKey[k]; !ok {
+			*changes = append(*changes, Change{Path: fmt.Sprintf("%s[%s]", path, k), To: te})
+		}
+	}
+}
+
+// listKey returns the field identifying the entries of both lists, if
+// they all have one.
+func listKey(from, to []any) (string, bool) {
+	if len(from) == 0 && len(to) == 0 {
+		return "", false
+	}
+	for _, key := range diffKeys {
+		if allHaveKey(from, key) && allHaveKey(to, key) {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+func allHaveKey(list []any, key string) bool {
+	for _, e := range list {
+		m, ok := e.(map[string]any)
+		if !ok {
+			return false
+		}
+		if _, ok := m[key].(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func entryKey(e any, key string) string {
+	return e.(map[string]any)[key].(string)
+}
+
+func isEmpty(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case []any:
+		return len(v) == 0
+	case map[string]any:
+		return len(v) == 0
+	}
+	return false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
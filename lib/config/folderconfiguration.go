@@ -87,6 +87,22 @@ type FolderConfiguration struct {
 	SyncXattrs              bool                        `json:"syncXattrs" xml:"syncXattrs"`
 	SendXattrs              bool                        `json:"sendXattrs" xml:"sendXattrs"`
 	XattrFilter             XattrFilter                 `json:"xattrFilter" xml:"xattrFilter"`
+	MassChangeMaxPct        int                         `json:"massChangeMaxPct" xml:"massChangeMaxPct"`
+	MassChangeMaxFiles      int                         `json:"massChangeMaxFiles" xml:"massChangeMaxFiles"`
+	RansomwareDetection     bool                        `json:"ransomwareDetection" xml:"ransomwareDetection"`
+	RansomwareMinFiles      int                         `json:"ransomwareMinFiles" xml:"ransomwareMinFiles" default:"10"`
+	ScrubIntervalS          int                         `json:"scrubIntervalS" xml:"scrubIntervalS"`
+	ScrubMaxKiBps           int                         `json:"scrubMaxKiBps" xml:"scrubMaxKiBps" default:"10240"`
+	ScrubRepair             bool                        `json:"scrubRepair" xml:"scrubRepair"`
+	SeedMode                bool                        `json:"seedMode" xml:"seedMode"`
+	PostSyncCommand         string                      `json:"postSyncCommand" xml:"postSyncCommand"`
+	PostSyncTimeoutS        int                         `json:"postSyncTimeoutS" xml:"postSyncTimeoutS" default:"60"`
+	PullPriorities          []PullPriority              `json:"pullPriorities" xml:"pullPriority"`
+	ShareBlocksWithFolders  bool                        `json:"shareBlocksWithFolders" xml:"shareBlocksWithFolders"`
+	SyncHardLinks           bool                        `json:"syncHardLinks" xml:"syncHardLinks"`
+	ShareIgnores            bool                        `json:"shareIgnores" xml:"shareIgnores"`
+	AcceptSharedIgnores     bool                        `json:"acceptSharedIgnores" xml:"acceptSharedIgnores"`
+	SharedIgnores           SharedIgnores               `json:"sharedIgnores" xml:"sharedIgnores"`
 	// Legacy deprecated
 	DeprecatedReadOnly       bool    `json:"-" xml:"ro,attr,omitempty"`        // Deprecated: Do not use.
 	DeprecatedMinDiskFreePct float64 `json:"-" xml:"minDiskFreePct,omitempty"` // Deprecated: Do not use.
@@ -111,10 +127,40 @@ type XattrFilterEntry struct {
 	Permit bool   `json:"permit" xml:"permit,attr"`
 }
 
+// Pull priority rule. Files to pull are taken in order of the priority of
+// the first rule whose pattern matches, highest first, with zero for files
+// not matching any rule. Files of the same priority are pulled in the
+// configured pull order. Patterns use the syntax of ignore patterns.
+type PullPriority struct {
+	Pattern  string `json:"pattern" xml:"pattern,attr"`
+	Priority int    `json:"priority" xml:"priority,attr"`
+}
+
+// Ignore patterns shared between the devices of a folder that opt in with
+// ShareIgnores, applied after those in the local .stignore. Every change
+// increases the version, and the document with the highest version wins,
+// ties broken by the ID of the device that made the change. Newer versions
+// from other devices are only adopted with AcceptSharedIgnores.
+type SharedIgnores struct {
+	Lines      []string          `json:"lines" xml:"line"`
+	Version    uint64            `json:"version" xml:"version,attr"`
+	ModifiedBy protocol.DeviceID `json:"modifiedBy" xml:"modifiedBy,attr"`
+}
+
+func (s SharedIgnores) ToProtocol() protocol.SharedIgnores {
+	return protocol.SharedIgnores{
+		Lines:      s.Lines,
+		Version:    s.Version,
+		ModifiedBy: s.ModifiedBy,
+	}
+}
+
 func (f FolderConfiguration) Copy() FolderConfiguration {
 	c := f
 	c.Devices = make([]FolderDeviceConfiguration, len(f.Devices))
 	copy(c.Devices, f.Devices)
+	c.PullPriorities = slices.Clone(f.PullPriorities)
+	c.SharedIgnores.Lines = slices.Clone(f.SharedIgnores.Lines)
 	c.Versioning = f.Versioning.Copy()
 	return c
 }
@@ -328,6 +374,27 @@ func (f *FolderConfiguration) prepare(myID protocol.DeviceID, existingDevices ma
 		f.MaxConcurrentWrites = maxConcurrentWritesLimit
 	}
 
+	if f.MassChangeMaxPct < 0 {
+		f.MassChangeMaxPct = 0
+	} else if f.MassChangeMaxPct > 100 {
+		f.MassChangeMaxPct = 100
+	}
+	if f.MassChangeMaxFiles < 0 {
+		f.MassChangeMaxFiles = 0
+	}
+	if f.RansomwareMinFiles < 1 {
+		f.RansomwareMinFiles = 1
+	}
+	if f.ScrubIntervalS < 0 {
+		f.ScrubIntervalS = 0
+	}
+	if f.ScrubMaxKiBps < 0 {
+		f.ScrubMaxKiBps = 0
+	}
+	if f.PostSyncTimeoutS < 1 {
+		f.PostSyncTimeoutS = 1
+	}
+
 	if f.Type == FolderTypeReceiveEncrypted {
 		f.IgnorePerms = true
 	}
@@ -0,0 +1,109 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"slices"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// GUIRole is the permission level of a GUI/API user. Each role includes the
+// permissions of the roles below it.
+type GUIRole int32
+
+const (
+	// GUIRoleViewer can look at status, statistics and configuration but
+	// not change anything.
+	GUIRoleViewer GUIRole = 0
+	// GUIRoleOperator can additionally trigger operations on folders and
+	// devices, such as rescans and pausing. Operations that can discard
+	// data, such as overrides, reverts and restoring old versions, are
+	// for administrators.
+	GUIRoleOperator GUIRole = 1
+	// GUIRoleAdmin can do everything, including changing the configuration.
+	GUIRoleAdmin GUIRole = 2
+)
+
+func (r GUIRole) String() string {
+	switch r {
+	case GUIRoleViewer:
+		return "viewer"
+	case GUIRoleOperator:
+		return "operator"
+	case GUIRoleAdmin:
+		return "admin"
+	default:
+		return "unknown"
+	}
+}
+
+func (r GUIRole) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r *GUIRole) UnmarshalText(bs []byte) error {
+	switch string(bs) {
+	case "admin":
+		*r = GUIRoleAdmin
+	case "operator":
+		*r = GUIRoleOperator
+	default:
+		*r = GUIRoleViewer
+	}
+	return nil
+}
+
+// A GUIAccount is an additional local user of the GUI and API, besides the
+// main user in the GUI configuration which is always an administrator.
+type GUIAccount struct {
+	Name     string  `json:"name" xml:"name,attr"`
+	Password string  `json:"password" xml:"password,omitempty"`
+	Role     GUIRole `json:"role" xml:"role"`
+	// Folders limits a viewer or operator to the given folder IDs. An empty
+	// list means all folders. Administrators always have access to all
+	// folders.
+	Folders []string `json:"folders" xml:"folder"`
+}
+
+// SetPassword takes a bcrypt hash or a plaintext password and stores it.
+// Plaintext passwords are hashed.
+func (a *GUIAccount) SetPassword(password string) error {
+	if bcryptExpr.MatchString(password) {
+		a.Password = password
+		return nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	a.Password = string(hash)
+	return nil
+}
+
+// CompareHashedPassword returns nil when the given plaintext password matches the stored hash.
+func (a GUIAccount) CompareHashedPassword(password string) error {
+	return bcrypt.CompareHashAndPassword([]byte(a.Password), []byte(password))
+}
+
+func (a GUIAccount) Copy() GUIAccount {
+	a.Folders = slices.Clone(a.Folders)
+	return a
+}
+
+// An LDAPGroupRole grants a role, and optionally a folder scope, to the
+// members of an LDAP group.
+type LDAPGroupRole struct {
+	Group   string   `json:"group" xml:"group,attr"`
+	Role    GUIRole  `json:"role" xml:"role"`
+	Folders []string `json:"folders" xml:"folder"`
+}
+
+func (g LDAPGroupRole) Copy() LDAPGroupRole {
+	g.Folders = slices.Clone(g.Folders)
+	return g
+}
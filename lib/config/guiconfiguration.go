@@ -19,25 +19,38 @@ import (
 )
 
 type GUIConfiguration struct {
-	Enabled                   bool     `json:"enabled" xml:"enabled,attr" default:"true"`
-	RawAddress                string   `json:"address" xml:"address" default:"127.0.0.1:8384"`
-	RawUnixSocketPermissions  string   `json:"unixSocketPermissions" xml:"unixSocketPermissions,omitempty"`
-	User                      string   `json:"user" xml:"user,omitempty"`
-	Password                  string   `json:"password" xml:"password,omitempty"`
-	AuthMode                  AuthMode `json:"authMode" xml:"authMode,omitempty"`
-	MetricsWithoutAuth        bool     `json:"metricsWithoutAuth" xml:"metricsWithoutAuth" default:"false"`
-	RawUseTLS                 bool     `json:"useTLS" xml:"tls,attr"`
-	APIKey                    string   `json:"apiKey" xml:"apikey,omitempty"`
-	InsecureAdminAccess       bool     `json:"insecureAdminAccess" xml:"insecureAdminAccess,omitempty"`
-	Theme                     string   `json:"theme" xml:"theme" default:"default"`
-	InsecureSkipHostCheck     bool     `json:"insecureSkipHostcheck" xml:"insecureSkipHostcheck,omitempty"`
-	InsecureAllowFrameLoading bool     `json:"insecureAllowFrameLoading" xml:"insecureAllowFrameLoading,omitempty"`
-	SendBasicAuthPrompt       bool     `json:"sendBasicAuthPrompt" xml:"sendBasicAuthPrompt,attr"`
+	Enabled                   bool           `json:"enabled" xml:"enabled,attr" default:"true"`
+	RawAddress                string         `json:"address" xml:"address" default:"127.0.0.1:8384"`
+	RawUnixSocketPermissions  string         `json:"unixSocketPermissions" xml:"unixSocketPermissions,omitempty"`
+	User                      string         `json:"user" xml:"user,omitempty"`
+	Password                  string         `json:"password" xml:"password,omitempty"`
+	AuthMode                  AuthMode       `json:"authMode" xml:"authMode,omitempty"`
+	MetricsWithoutAuth        bool           `json:"metricsWithoutAuth" xml:"metricsWithoutAuth" default:"false"`
+	RawUseTLS                 bool           `json:"useTLS" xml:"tls,attr"`
+	APIKey                    string         `json:"apiKey" xml:"apikey,omitempty"`
+	InsecureAdminAccess       bool           `json:"insecureAdminAccess" xml:"insecureAdminAccess,omitempty"`
+	Theme                     string         `json:"theme" xml:"theme" default:"default"`
+	InsecureSkipHostCheck     bool           `json:"insecureSkipHostcheck" xml:"insecureSkipHostcheck,omitempty"`
+	InsecureAllowFrameLoading bool           `json:"insecureAllowFrameLoading" xml:"insecureAllowFrameLoading,omitempty"`
+	SendBasicAuthPrompt       bool           `json:"sendBasicAuthPrompt" xml:"sendBasicAuthPrompt,attr"`
+	Accounts                  []GUIAccount   `json:"accounts" xml:"account"`
+	ClientCertMode            ClientCertMode `json:"clientCertMode" xml:"clientCertMode,omitempty"`
+	ClientCAFile              string         `json:"clientCAFile" xml:"clientCAFile,omitempty"`
 }
 
 func (c GUIConfiguration) IsAuthEnabled() bool {
 	// This function should match isAuthEnabled() in syncthingController.js
-	return c.AuthMode == AuthModeLDAP || (len(c.User) > 0 && len(c.Password) > 0)
+	return c.AuthMode == AuthModeLDAP || c.AuthMode == AuthModeOIDC || (len(c.User) > 0 && len(c.Password) > 0) || len(c.Accounts) > 0 || c.ClientCertMode != ClientCertModeOff
+}
+
+// Account returns the local account with the given name, if any.
+func (c GUIConfiguration) Account(name string) (GUIAccount, bool) {
+	for _, acc := range c.Accounts {
+		if acc.Name == name {
+			return acc, true
+		}
+	}
+	return GUIAccount{}, false
 }
 
 func (GUIConfiguration) IsOverridden() bool {
@@ -179,5 +192,12 @@ func (c *GUIConfiguration) prepare() {
 }
 
 func (c GUIConfiguration) Copy() GUIConfiguration {
+	if c.Accounts != nil {
+		accounts := make([]GUIAccount, len(c.Accounts))
+		for i, acc := range c.Accounts {
+			accounts[i] = acc.Copy()
+		}
+		c.Accounts = accounts
+	}
 	return c
 }
@@ -13,8 +13,27 @@ type LDAPConfiguration struct {
 	InsecureSkipVerify bool          `json:"insecureSkipVerify" xml:"insecureSkipVerify,omitempty" default:"false"`
 	SearchBaseDN       string        `json:"searchBaseDN" xml:"searchBaseDN,omitempty"`
 	SearchFilter       string        `json:"searchFilter" xml:"searchFilter,omitempty"`
+	// GroupAttribute is the attribute of the user entry found by the
+	// search that lists the user's groups. Defaults to memberOf.
+	GroupAttribute string          `json:"groupAttribute" xml:"groupAttribute,omitempty"`
+	GroupRoles     []LDAPGroupRole `json:"groupRoles" xml:"groupRole"`
 }
 
 func (c LDAPConfiguration) Copy() LDAPConfiguration {
+	if c.GroupRoles != nil {
+		roles := make([]LDAPGroupRole, len(c.GroupRoles))
+		for i, gr := range c.GroupRoles {
+			roles[i] = gr.Copy()
+		}
+		c.GroupRoles = roles
+	}
 	return c
 }
+
+// MemberAttribute returns the attribute listing the groups of a user.
+func (c LDAPConfiguration) MemberAttribute() string {
+	if c.GroupAttribute == "" {
+		return "memberOf"
+	}
+	return c.GroupAttribute
+}
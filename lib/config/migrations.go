@@ -30,6 +30,7 @@ import (
 // put the newest on top for readability.
 var (
 	migrations = migrationSet{
+		{52, migrateToConfigV52},
 		{51, migrateToConfigV51},
 		{50, migrateToConfigV50},
 		{37, migrateToConfigV37},
@@ -101,6 +102,14 @@ func (m migration) apply(cfg *Configuration) {
 	cfg.Version = m.targetVersion
 }
 
+func migrateToConfigV52(cfg *Configuration) {
+	// Folders used to take blocks from all others. Existing ones keep
+	// doing so, new ones only when the user opts in.
+	for i := range cfg.Folders {
+		cfg.Folders[i].ShareBlocksWithFolders = true
+	}
+}
+
 func migrateToConfigV51(cfg *Configuration) {
 	oldDefault := 2
 	for i, fcfg := range cfg.Folders {
@@ -6,7 +6,11 @@
 
 package config
 
-import "testing"
+import (
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
 
 func TestMigrateCrashReporting(t *testing.T) {
 	// When migrating from pre-crash-reporting configs, crash reporting is
@@ -34,3 +38,17 @@ func TestMigrateCrashReporting(t *testing.T) {
 		}
 	}
 }
+
+func TestMigrateShareBlocksWithFolders(t *testing.T) {
+	// Existing folders keep taking blocks from the others, new ones don't.
+	cfg := Configuration{Version: 51, Folders: []FolderConfiguration{{ID: "a"}}}
+	migrationsMut.Lock()
+	migrations.apply(&cfg)
+	migrationsMut.Unlock()
+	if !cfg.Folders[0].ShareBlocksWithFolders {
+		t.Error("existing folder doesn't share blocks after migration")
+	}
+	if cfg.Defaults.Folder.ShareBlocksWithFolders || New(protocol.EmptyDeviceID).Defaults.Folder.ShareBlocksWithFolders {
+		t.Error("new folders share blocks by default")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"slices"
+	"strings"
+)
+
+type OIDCConfiguration struct {
+	Issuer       string `json:"issuer" xml:"issuer,omitempty"`
+	ClientID     string `json:"clientID" xml:"clientID,omitempty"`
+	ClientSecret string `json:"clientSecret" xml:"clientSecret,omitempty"`
+	// RedirectURL is the callback URL registered with the issuer. When
+	// empty it's derived from the address the GUI is accessed on.
+	RedirectURL string `json:"redirectURL" xml:"redirectURL,omitempty"`
+	// Scopes are requested in addition to openid.
+	Scopes        []string `json:"scopes" xml:"scope"`
+	UsernameClaim string   `json:"usernameClaim" xml:"usernameClaim,omitempty"`
+	GroupsClaim   string   `json:"groupsClaim" xml:"groupsClaim,omitempty"`
+	// When AllowedUsers or AllowedGroups are set, only the listed users
+	// and the members of the listed groups may log in.
+	AllowedUsers  []string `json:"allowedUsers" xml:"allowedUser"`
+	AllowedGroups []string `json:"allowedGroups" xml:"allowedGroup"`
+	// ClaimRoles grant roles based on the claims of the ID token. Without
+	// any, users that may log in are administrators.
+	ClaimRoles []OIDCClaimRole `json:"claimRoles" xml:"claimRole"`
+}
+
+// An OIDCClaimRole grants a role, and optionally a folder scope, to users
+// whose ID token claim has the given value, or contains it when the claim
+// is a list.
+type OIDCClaimRole struct {
+	Claim   string   `json:"claim" xml:"claim,attr"`
+	Value   string   `json:"value" xml:"value,attr"`
+	Role    GUIRole  `json:"role" xml:"role"`
+	Folders []string `json:"folders" xml:"folder"`
+}
+
+func (c OIDCConfiguration) Copy() OIDCConfiguration {
+	c.Scopes = slices.Clone(c.Scopes)
+	c.AllowedUsers = slices.Clone(c.AllowedUsers)
+	c.AllowedGroups = slices.Clone(c.AllowedGroups)
+	if c.ClaimRoles != nil {
+		roles := make([]OIDCClaimRole, len(c.ClaimRoles))
+		for i, cr := range c.ClaimRoles {
+			cr.Folders = slices.Clone(cr.Folders)
+			roles[i] = cr
+		}
+		c.ClaimRoles = roles
+	}
+	return c
+}
+
+// IssuerURL returns the issuer without any trailing slash.
+func (c OIDCConfiguration) IssuerURL() string {
+	return strings.TrimSuffix(c.Issuer, "/")
+}
+
+// RequestScopes returns the scopes to request, always including openid.
+func (c OIDCConfiguration) RequestScopes() []string {
+	scopes := []string{"openid"}
+	extra := c.Scopes
+	if len(extra) == 0 {
+		extra = []string{"profile", "email"}
+	}
+	for _, s := range extra {
+		if s != "" && !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// UsernameClaimName returns the claim holding the user name. Defaults to
+// preferred_username.
+func (c OIDCConfiguration) UsernameClaimName() string {
+	if c.UsernameClaim == "" {
+		return "preferred_username"
+	}
+	return c.UsernameClaim
+}
+
+// GroupsClaimName returns the claim listing the user's groups. Defaults to
+// groups.
+func (c OIDCConfiguration) GroupsClaimName() string {
+	if c.GroupsClaim == "" {
+		return "groups"
+	}
+	return c.GroupsClaim
+}
@@ -13,6 +13,7 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/syncthing/syncthing/lib/events"
 	"github.com/syncthing/syncthing/lib/protocol"
 	"github.com/syncthing/syncthing/lib/rand"
 	"github.com/syncthing/syncthing/lib/stringutil"
@@ -43,6 +44,9 @@ type OptionsConfiguration struct {
 	URPostInsecurely            bool     `json:"urPostInsecurely" xml:"urPostInsecurely" default:"false"`
 	URInitialDelayS             int      `json:"urInitialDelayS" xml:"urInitialDelayS" default:"1800"`
 	AutoUpgradeIntervalH        int      `json:"autoUpgradeIntervalH" xml:"autoUpgradeIntervalH" default:"12"`
+	AutoUpgradeRolloutPct       int      `json:"autoUpgradeRolloutPct" xml:"autoUpgradeRolloutPct" default:"100"`
+	AutoUpgradeMinReleaseAgeH   int      `json:"autoUpgradeMinReleaseAgeH" xml:"autoUpgradeMinReleaseAgeH"`
+	AutoUpgradeHealthCheckS     int      `json:"autoUpgradeHealthCheckS" xml:"autoUpgradeHealthCheckS" default:"300"`
 	UpgradeToPreReleases        bool     `json:"upgradeToPreReleases" xml:"upgradeToPreReleases"`
 	KeepTemporariesH            int      `json:"keepTemporariesH" xml:"keepTemporariesH" default:"24"`
 	CacheIgnoredFiles           bool     `json:"cacheIgnoredFiles" xml:"cacheIgnoredFiles" default:"false"`
@@ -50,6 +54,8 @@ type OptionsConfiguration struct {
 	LimitBandwidthInLan         bool     `json:"limitBandwidthInLan" xml:"limitBandwidthInLan" default:"false"`
 	MinHomeDiskFree             Size     `json:"minHomeDiskFree" xml:"minHomeDiskFree" default:"1 %"`
 	ReleasesURL                 string   `json:"releasesURL" xml:"releasesURL" default:"https://upgrades.syncthing.net/meta.json"`
+	ReleaseChannel              string   `json:"releaseChannel" xml:"releaseChannel" default:"stable"`
+	ReleaseSigningKeys          []string `json:"releaseSigningKeys" xml:"releaseSigningKey"`
 	AlwaysLocalNets             []string `json:"alwaysLocalNets" xml:"alwaysLocalNet"`
 	OverwriteRemoteDevNames     bool     `json:"overwriteRemoteDeviceNamesOnConnect" xml:"overwriteRemoteDeviceNamesOnConnect" default:"false"`
 	TempIndexMinBlocks          int      `json:"tempIndexMinBlocks" xml:"tempIndexMinBlocks" default:"10"`
@@ -69,6 +75,18 @@ type OptionsConfiguration struct {
 	FeatureFlags                []string `json:"featureFlags" xml:"featureFlag"`
 	AuditEnabled                bool     `json:"auditEnabled" xml:"auditEnabled" default:"false" restart:"true"`
 	AuditFile                   string   `json:"auditFile" xml:"auditFile" restart:"true"`
+	// The event types to audit, all of them when empty.
+	AuditEvents []events.EventType `json:"auditEvents" xml:"auditEvent" restart:"true"`
+	// The audit file is rotated when it grows larger than AuditMaxSizeKiB
+	// or older than AuditMaxAgeH, zero meaning no limit, keeping
+	// AuditMaxFiles rotated files.
+	AuditMaxSizeKiB int `json:"auditMaxSizeKiB" xml:"auditMaxSizeKiB" restart:"true"`
+	AuditMaxAgeH    int `json:"auditMaxAgeH" xml:"auditMaxAgeH" restart:"true"`
+	AuditMaxFiles   int `json:"auditMaxFiles" xml:"auditMaxFiles" default:"5" restart:"true"`
+	// The syslog socket to also send audit records to, such as
+	// "unixgram:///dev/log" or "udp://loghost:514", or "default" for the
+	// local syslog or journald socket.
+	AuditSyslogAddress string `json:"auditSyslogAddress" xml:"auditSyslogAddress" restart:"true"`
 	// The number of connections at which we stop trying to connect to more
 	// devices, zero meaning no limit. Does not affect incoming connections.
 	ConnectionLimitEnough int `json:"connectionLimitEnough" xml:"connectionLimitEnough"`
@@ -82,6 +100,9 @@ type OptionsConfiguration struct {
 	ConnectionPriorityQUICWAN          int `json:"connectionPriorityQuicWan" xml:"connectionPriorityQuicWan" default:"40"`
 	ConnectionPriorityRelay            int `json:"connectionPriorityRelay" xml:"connectionPriorityRelay" default:"50"`
 	ConnectionPriorityUpgradeThreshold int `json:"connectionPriorityUpgradeThreshold" xml:"connectionPriorityUpgradeThreshold" default:"0"`
+	// The number of post-sync hooks that may run at the same time, over
+	// all folders.
+	MaxConcurrentHooks int `json:"maxConcurrentHooks" xml:"maxConcurrentHooks" default:"2"`
 	// Legacy deprecated
 	DeprecatedUPnPEnabled        bool     `json:"-" xml:"upnpEnabled,omitempty"`        // Deprecated: Do not use.
 	DeprecatedUPnPLeaseM         int      `json:"-" xml:"upnpLeaseMinutes,omitempty"`   // Deprecated: Do not use.
@@ -102,6 +123,9 @@ func (opts OptionsConfiguration) Copy() OptionsConfiguration {
 	copy(optsCopy.AlwaysLocalNets, opts.AlwaysLocalNets)
 	optsCopy.UnackedNotificationIDs = make([]string, len(opts.UnackedNotificationIDs))
 	copy(optsCopy.UnackedNotificationIDs, opts.UnackedNotificationIDs)
+	optsCopy.ReleaseSigningKeys = make([]string, len(opts.ReleaseSigningKeys))
+	copy(optsCopy.ReleaseSigningKeys, opts.ReleaseSigningKeys)
+	optsCopy.AuditEvents = slices.Clone(opts.AuditEvents)
 	return optsCopy
 }
 
@@ -140,6 +164,23 @@ func (opts *OptionsConfiguration) prepare(guiPWIsSet bool) {
 		opts.ConnectionPriorityTCPWAN = opts.ConnectionPriorityTCPLAN + 1
 	}
 
+	opts.AuditMaxSizeKiB = max(0, opts.AuditMaxSizeKiB)
+	opts.AuditMaxAgeH = max(0, opts.AuditMaxAgeH)
+	opts.AuditMaxFiles = max(0, opts.AuditMaxFiles)
+	opts.MaxConcurrentHooks = max(1, opts.MaxConcurrentHooks)
+
+	// The rollout percentage is, well, a percentage.
+	opts.AutoUpgradeRolloutPct = max(0, min(100, opts.AutoUpgradeRolloutPct))
+	if opts.AutoUpgradeMinReleaseAgeH < 0 {
+		opts.AutoUpgradeMinReleaseAgeH = 0
+	}
+	if opts.AutoUpgradeHealthCheckS < 0 {
+		opts.AutoUpgradeHealthCheckS = 0
+	}
+
+	// An empty channel is the default one, see upgrade.NewChannel.
+	opts.ReleaseChannel = strings.ToLower(strings.TrimSpace(opts.ReleaseChannel))
+
 	// If usage reporting is enabled we must have a unique ID.
 	if opts.URAccepted > 0 && opts.URUniqueID == "" {
 		opts.URUniqueID = rand.String(8)
@@ -160,6 +201,18 @@ func (opts OptionsConfiguration) RequiresRestartOnly() OptionsConfiguration {
 	return optsCopy
 }
 
+// AuditEventMask returns the mask of the event types to audit.
+func (opts OptionsConfiguration) AuditEventMask() events.EventType {
+	var mask events.EventType
+	for _, t := range opts.AuditEvents {
+		mask |= t
+	}
+	if mask == 0 {
+		return events.AllEvents
+	}
+	return mask
+}
+
 func (opts OptionsConfiguration) IsStunDisabled() bool {
 	return opts.StunKeepaliveMinS < 1 || opts.StunKeepaliveStartS < 1 || !opts.NATEnabled
 }
@@ -0,0 +1,63 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package config
+
+import (
+	"slices"
+
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/rand"
+)
+
+// A WebhookConfiguration describes an HTTP endpoint that is sent the
+// matching events as they happen.
+type WebhookConfiguration struct {
+	ID     string             `json:"id" xml:"id,attr"`
+	URL    string             `json:"url" xml:"url"`
+	Events []events.EventType `json:"events" xml:"event"`
+	// Folders and Devices limit the webhook to events about the given
+	// folders and devices, when set.
+	Folders []string            `json:"folders" xml:"folder"`
+	Devices []protocol.DeviceID `json:"devices" xml:"device"`
+	// Secret is the key for the HMAC-SHA256 signature of each request, if
+	// set.
+	Secret string `json:"secret" xml:"secret,omitempty"`
+	Paused bool   `json:"paused" xml:"paused,attr"`
+}
+
+// EventMask returns the mask of the configured event types, or all events
+// when none are configured.
+func (c WebhookConfiguration) EventMask() events.EventType {
+	var mask events.EventType
+	for _, t := range c.Events {
+		mask |= t
+	}
+	if mask == 0 {
+		return events.AllEvents
+	}
+	return mask
+}
+
+func (c WebhookConfiguration) Copy() WebhookConfiguration {
+	c.Events = slices.Clone(c.Events)
+	c.Folders = slices.Clone(c.Folders)
+	c.Devices = slices.Clone(c.Devices)
+	return c
+}
+
+func (cfg *Configuration) prepareWebhooks() {
+	seen := make(map[string]bool, len(cfg.Webhooks))
+	for i := range cfg.Webhooks {
+		// Webhooks need a unique ID to keep their undelivered events
+		// apart.
+		for cfg.Webhooks[i].ID == "" || seen[cfg.Webhooks[i].ID] {
+			cfg.Webhooks[i].ID = rand.String(8)
+		}
+		seen[cfg.Webhooks[i].ID] = true
+	}
+}
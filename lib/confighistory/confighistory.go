@@ -0,0 +1,237 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package confighistory keeps the past versions of the configuration, so
+// that changes can be reviewed and rolled back.
+package confighistory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+const (
+	indexKey      = "configHistory"
+	versionPrefix = "configHistory/"
+	maxEntries    = 100 // the oldest are dropped beyond this
+)
+
+// The sources of configuration changes.
+const (
+	// SourceStartup is the configuration as loaded at startup, including
+	// any edits made to the file while Syncthing wasn't running.
+	SourceStartup = "startup"
+	// SourceSystem is a change made by Syncthing itself, such as for an
+	// introducer or an auto accepted folder, or one whose author isn't
+	// known.
+	SourceSystem = "system"
+	// SourceAPI is a change made through the REST API.
+	SourceAPI = "api"
+	// SourceRollback is a rollback to an earlier version.
+	SourceRollback = "rollback"
+)
+
+var ErrNoSuchVersion = errors.New("no such configuration version")
+
+// An Entry describes a version of the configuration.
+type Entry struct {
+	Version int       `json:"version"`
+	Time    time.Time `json:"time"`
+	Author  string    `json:"author,omitempty"`
+	Source  string    `json:"source"`
+}
+
+// The History records every saved configuration in the database.
+type History struct {
+	cfg      config.Wrapper
+	evLogger events.Logger
+	miscDB   *db.Typed
+
+	mut     sync.Mutex
+	entries []Entry              // oldest first
+	latest  config.Configuration // the configuration of the last entry
+}
+
+func New(cfg config.Wrapper, evLogger events.Logger, miscDB *db.Typed) *History {
+	h := &History{
+		cfg:      cfg,
+		evLogger: evLogger,
+		miscDB:   miscDB,
+	}
+	if bs, ok, _ := miscDB.Bytes(indexKey); ok {
+		_ = json.Unmarshal(bs, &h.entries) // best effort
+	}
+	if len(h.entries) > 0 {
+		h.latest, _ = h.Config(h.entries[len(h.entries)-1].Version)
+	}
+	return h
+}
+
+func (h *History) Serve(ctx context.Context) error {
+	sub := h.evLogger.Subscribe(events.ConfigSaved)
+	defer sub.Unsubscribe()
+
+	h.Record(h.cfg.RawCopy(), "", SourceStartup)
+
+	for {
+		select {
+		case ev, ok := <-sub.C():
+			if !ok {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			if cfg, ok := ev.Data.(config.Configuration); ok {
+				h.Record(cfg, "", SourceSystem)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Record adds the configuration to the history, unless it's the same as
+// the latest version. A change recorded from the ConfigSaved event is
+// attributed to its author when they are recorded later.
+func (h *History) Record(cfg config.Configuration, author, source string) {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+
+	if len(h.entries) > 0 && len(config.Diff(h.latest, cfg)) == 0 {
+		last := &h.entries[len(h.entries)-1]
+		if last.Source == SourceSystem && source != SourceSystem {
+			last.Author = author
+			last.Source = source
+			h.saveIndexLocked()
+		}
+		return
+	}
+
+	bs, err := json.Marshal(cfg)
+	if err != nil {
+		return
+	}
+	version := 1
+	if len(h.entries) > 0 {
+		version = h.entries[len(h.entries)-1].Version + 1
+	}
+	if err := h.miscDB.PutBytes(versionKey(version), bs); err != nil {
+		slog.Warn("Failed to save configuration history", slogutil.Error(err))
+		return
+	}
+	h.entries = append(h.entries, Entry{
+		Version: version,
+		Time:    time.Now().Truncate(time.Second),
+		Author:  author,
+		Source:  source,
+	})
+	h.latest = cfg
+	if over := len(h.entries) - maxEntries; over > 0 {
+		for _, e := range h.entries[:over] {
+			_ = h.miscDB.Delete(versionKey(e.Version))
+		}
+		h.entries = slices.Delete(h.entries, 0, over)
+	}
+	h.saveIndexLocked()
+}
+
+// Entries returns the recorded versions, oldest first.
+func (h *History) Entries() []Entry {
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	return slices.Clone(h.entries)
+}
+
+// Config returns the given version of the configuration.
+func (h *History) Config(version int) (config.Configuration, error) {
+	bs, ok, err := h.miscDB.Bytes(versionKey(version))
+	if err != nil {
+		return config.Configuration{}, err
+	}
+	if !ok {
+		return config.Configuration{}, ErrNoSuchVersion
+	}
+	var cfg config.Configuration
+	if err := json.Unmarshal(bs, &cfg); err != nil {
+		return config.Configuration{}, fmt.Errorf("version %d: %w", version, err)
+	}
+	return cfg, nil
+}
+
+// Rollback replaces the configuration with the given version, which
+// becomes the latest one. The current credentials and secrets are kept, so
+// that rolling back doesn't revive a rotated password or key.
+func (h *History) Rollback(version int, author string) error {
+	old, err := h.Config(version)
+	if err != nil {
+		return err
+	}
+	waiter, err := h.cfg.Modify(func(cfg *config.Configuration) {
+		keepSecrets(&old, *cfg)
+		*cfg = old
+	})
+	if err != nil {
+		return err
+	}
+	waiter.Wait()
+	if err := h.cfg.Save(); err != nil {
+		return err
+	}
+	h.Record(h.cfg.RawCopy(), author, SourceRollback)
+	return nil
+}
+
+// keepSecrets sets the credentials and secrets of the old configuration to
+// those of the current one. GUI accounts that no longer exist aren't
+// restored, as that would bring back their old passwords.
+func keepSecrets(old *config.Configuration, cur config.Configuration) {
+	old.GUI.Password = cur.GUI.Password
+	old.GUI.APIKey = cur.GUI.APIKey
+	old.OIDC.ClientSecret = cur.OIDC.ClientSecret
+
+	accounts := old.GUI.Accounts[:0]
+	for _, acc := range old.GUI.Accounts {
+		i := slices.IndexFunc(cur.GUI.Accounts, func(c config.GUIAccount) bool { return c.Name == acc.Name })
+		if i < 0 {
+			continue
+		}
+		acc.Password = cur.GUI.Accounts[i].Password
+		accounts = append(accounts, acc)
+	}
+	old.GUI.Accounts = accounts
+
+	for i, hook := range old.Webhooks {
+		if j := slices.IndexFunc(cur.Webhooks, func(c config.WebhookConfiguration) bool { return c.ID == hook.ID }); j >= 0 {
+			old.Webhooks[i].Secret = cur.Webhooks[j].Secret
+		}
+	}
+}
+
+func (h *History) saveIndexLocked() {
+	bs, _ := json.Marshal(h.entries) // can't fail
+	if err := h.miscDB.PutBytes(indexKey, bs); err != nil {
+		slog.Warn("Failed to save configuration history", slogutil.Error(err))
+	}
+}
+
+func (h *History) String() string {
+	return fmt.Sprintf("History@%p", h)
+}
+
+func versionKey(version int) string {
+	return versionPrefix + strconv.Itoa(version)
+}
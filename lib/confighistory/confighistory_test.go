@@ -0,0 +1,143 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package confighistory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/db/sqlite"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestHistory(t *testing.T) {
+	t.Parallel()
+
+	sdb, err := sqlite.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sdb.Close()
+	})
+	miscDB := db.NewMiscDB(sdb)
+
+	orig := config.New(protocol.LocalDeviceID)
+	orig.Options.MaxSendKbps = 10
+	cfg := config.Wrap(filepath.Join(t.TempDir(), "config.xml"), orig, protocol.LocalDeviceID, events.NoopLogger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cfg.Serve(ctx)
+
+	h := New(cfg, events.NoopLogger, miscDB)
+	h.Record(cfg.RawCopy(), "", SourceStartup)
+	h.Record(cfg.RawCopy(), "", SourceSystem) // no change
+
+	// An API change is first seen through the ConfigSaved event, then
+	// attributed.
+	changed := cfg.RawCopy()
+	changed.Options.MaxSendKbps = 20
+	h.Record(changed, "", SourceSystem)
+	h.Record(changed, "jb", SourceAPI)
+
+	entries := h.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("expected two entries, got %v", entries)
+	}
+	if e := entries[0]; e.Version != 1 || e.Source != SourceStartup || e.Author != "" {
+		t.Errorf("unexpected first entry %v", e)
+	}
+	if e := entries[1]; e.Version != 2 || e.Source != SourceAPI || e.Author != "jb" {
+		t.Errorf("unexpected second entry %v", e)
+	}
+
+	// The history survives restarts.
+	h = New(cfg, events.NoopLogger, miscDB)
+	if len(h.Entries()) != 2 {
+		t.Fatalf("expected the entries to be loaded, got %v", h.Entries())
+	}
+	v2, err := h.Config(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v2.Options.MaxSendKbps != 20 {
+		t.Errorf("unexpected version 2 %v", v2.Options)
+	}
+	if _, err := h.Config(3); err != ErrNoSuchVersion {
+		t.Errorf("expected no such version, got %v", err)
+	}
+
+	// Rolling back applies the old configuration as a new version.
+	if err := h.Rollback(1, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if kbps := cfg.Options().MaxSendKbps; kbps != 10 {
+		t.Errorf("expected the rollback to be applied, got %d", kbps)
+	}
+	entries = h.Entries()
+	if len(entries) != 3 {
+		t.Fatalf("expected three entries, got %v", entries)
+	}
+	if e := entries[2]; e.Version != 3 || e.Source != SourceRollback || e.Author != "admin" {
+		t.Errorf("unexpected rollback entry %v", e)
+	}
+}
+
+func TestRollbackKeepsSecrets(t *testing.T) {
+	t.Parallel()
+
+	sdb, err := sqlite.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sdb.Close()
+	})
+
+	orig := config.New(protocol.LocalDeviceID)
+	orig.GUI.APIKey = "old-key"
+	orig.GUI.Accounts = []config.GUIAccount{{Name: "jb", Password: "old-hash", Role: config.GUIRoleOperator}, {Name: "eve", Password: "eve-hash"}}
+	orig.Options.MaxSendKbps = 10
+	cfg := config.Wrap(filepath.Join(t.TempDir(), "config.xml"), orig, protocol.LocalDeviceID, events.NoopLogger)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go cfg.Serve(ctx)
+
+	h := New(cfg, events.NoopLogger, db.NewMiscDB(sdb))
+	h.Record(cfg.RawCopy(), "", SourceStartup)
+
+	// The API key and a password are rotated, and an account removed,
+	// along with another change.
+	waiter, err := cfg.Modify(func(c *config.Configuration) {
+		c.GUI.APIKey = "new-key"
+		c.GUI.Accounts = []config.GUIAccount{{Name: "jb", Password: "new-hash", Role: config.GUIRoleAdmin}}
+		c.Options.MaxSendKbps = 20
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	waiter.Wait()
+	h.Record(cfg.RawCopy(), "admin", SourceAPI)
+
+	if err := h.Rollback(1, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	if kbps := cfg.Options().MaxSendKbps; kbps != 10 {
+		t.Errorf("expected the rollback to be applied, got %d", kbps)
+	}
+	gui := cfg.GUI()
+	if gui.APIKey != "new-key" {
+		t.Errorf("expected the rotated API key to survive, got %q", gui.APIKey)
+	}
+	if len(gui.Accounts) != 1 || gui.Accounts[0].Password != "new-hash" || gui.Accounts[0].Role != config.GUIRoleOperator {
+		t.Errorf("unexpected accounts after rollback %v", gui.Accounts)
+	}
+}
@@ -57,6 +57,10 @@ const (
 	ListenAddressesChanged
 	LoginAttempt
 	Failure
+	APIRequest
+	MassChangeDetected
+	CorruptionDetected
+	SharedIgnoresChanged
 
 	AllEvents = (1 << iota) - 1
 )
@@ -134,6 +138,14 @@ func (t EventType) String() string {
 		return "FolderWatchStateChanged"
 	case Failure:
 		return "Failure"
+	case APIRequest:
+		return "APIRequest"
+	case MassChangeDetected:
+		return "MassChangeDetected"
+	case CorruptionDetected:
+		return "CorruptionDetected"
+	case SharedIgnoresChanged:
+		return "SharedIgnoresChanged"
 	default:
 		return "Unknown"
 	}
@@ -155,6 +167,11 @@ func (t *EventType) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+func (t *EventType) UnmarshalText(bs []byte) error {
+	*t = UnmarshalEventType(string(bs))
+	return nil
+}
+
 func UnmarshalEventType(s string) EventType {
 	switch s {
 	case "Starting":
@@ -221,6 +238,14 @@ func UnmarshalEventType(s string) EventType {
 		return FolderWatchStateChanged
 	case "Failure":
 		return Failure
+	case "APIRequest":
+		return APIRequest
+	case "MassChangeDetected":
+		return MassChangeDetected
+	case "CorruptionDetected":
+		return CorruptionDetected
+	case "SharedIgnoresChanged":
+		return SharedIgnoresChanged
 	default:
 		return 0
 	}
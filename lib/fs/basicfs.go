@@ -197,6 +197,18 @@ func (f *BasicFilesystem) RemoveAll(name string) error {
 	return os.RemoveAll(name)
 }
 
+func (f *BasicFilesystem) CreateHardLink(oldname, newname string) error {
+	oldname, err := f.rooted(oldname)
+	if err != nil {
+		return err
+	}
+	newname, err = f.rooted(newname)
+	if err != nil {
+		return err
+	}
+	return os.Link(oldname, newname)
+}
+
 func (f *BasicFilesystem) Rename(oldpath, newpath string) error {
 	oldpath, err := f.rooted(oldpath)
 	if err != nil {
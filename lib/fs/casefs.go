@@ -322,6 +322,20 @@ func (f *caseFilesystem) CreateSymlink(target, name string) error {
 	return nil
 }
 
+func (f *caseFilesystem) CreateHardLink(oldname, newname string) error {
+	if err := f.checkCase(oldname); err != nil {
+		return err
+	}
+	if err := f.checkCase(newname); err != nil {
+		return err
+	}
+	if err := f.Filesystem.CreateHardLink(oldname, newname); err != nil {
+		return err
+	}
+	f.dropCache()
+	return nil
+}
+
 func (f *caseFilesystem) Walk(root string, walkFn WalkFunc) error {
 	// Walking the filesystem is likely (in Syncthing's case certainly) done
 	// to pick up external changes, for which caching is undesirable.
@@ -345,6 +345,10 @@ func (fs *fakeFS) CreateSymlink(target, name string) error {
 	return nil
 }
 
+func (*fakeFS) CreateHardLink(_, _ string) error {
+	return errors.New("hard links not supported")
+}
+
 func (fs *fakeFS) DirNames(name string) ([]string, error) {
 	fs.mut.Lock()
 	defer fs.mut.Unlock()
@@ -0,0 +1,21 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:build !windows
+
+package fs
+
+import "syscall"
+
+// HardLinkID returns the device and inode identifying the data of the file
+// and the number of links to it, if known.
+func HardLinkID(fi FileInfo) (dev, ino, links uint64, ok bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	return uint64(st.Dev), uint64(st.Ino), uint64(st.Nlink), true //nolint:unconvert
+}
@@ -0,0 +1,15 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+//go:build windows
+
+package fs
+
+// HardLinkID returns the device and inode identifying the data of the file
+// and the number of links to it, if known. Lstat doesn't tell on Windows.
+func HardLinkID(_ FileInfo) (dev, ino, links uint64, ok bool) {
+	return 0, 0, 0, false
+}
@@ -61,6 +61,12 @@ func (fs *logFilesystem) CreateSymlink(target, name string) error {
 	return err
 }
 
+func (fs *logFilesystem) CreateHardLink(oldname, newname string) error {
+	err := fs.Filesystem.CreateHardLink(oldname, newname)
+	l.Debugln(fs.getCaller(), fs.Type(), fs.URI(), "CreateHardLink", oldname, newname, err)
+	return err
+}
+
 func (fs *logFilesystem) DirNames(name string) ([]string, error) {
 	names, err := fs.Filesystem.DirNames(name)
 	l.Debugln(fs.getCaller(), fs.Type(), fs.URI(), "DirNames", name, names, err)
@@ -16,6 +16,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -123,7 +124,9 @@ type ChangeDetector interface {
 type Matcher struct {
 	fs             fs.Filesystem
 	lines          []string  // exact lines read from .stignore
-	patterns       []Pattern // patterns including those from included files
+	local          []Pattern // patterns including those from included files
+	shared         []Pattern // patterns shared between devices, see SetShared
+	patterns       []Pattern // local patterns followed by shared ones
 	withCache      bool
 	matches        *cache
 	curHash        string
@@ -210,11 +213,44 @@ func (m *Matcher) parseLocked(r io.Reader, file string) error {
 	// (possibly blank) anyway.
 
 	m.lines = lines
+	m.local = patterns
+	m.updatePatternsLocked()
+
+	return err
+}
+
+// SetShared sets the patterns shared between the devices of the folder.
+// They apply after the local ones, so that those can override them.
+// Including files isn't allowed, as that would refer to local files. When
+// an error is returned no shared patterns are in effect.
+func (m *Matcher) SetShared(lines []string) error {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+
+	var patterns []Pattern
+	var err error
+	if slices.ContainsFunc(lines, func(line string) bool { return strings.HasPrefix(strings.TrimSpace(line), "#include") }) {
+		err = errors.New("#include not allowed in shared patterns")
+	} else {
+		_, patterns, err = parseIgnoreFile(m.fs, strings.NewReader(strings.Join(lines, "\n")), "", m.changeDetector, make(map[string]struct{}))
+	}
+	if err != nil {
+		patterns = nil
+	}
+
+	m.shared = patterns
+	m.updatePatternsLocked()
+
+	return err
+}
+
+func (m *Matcher) updatePatternsLocked() {
+	patterns := append(slices.Clip(m.local), m.shared...)
 
 	newHash := hashPatterns(patterns)
 	if newHash == m.curHash {
 		// We've already loaded exactly these patterns.
-		return err
+		return
 	}
 
 	m.curHash = newHash
@@ -222,8 +258,6 @@ func (m *Matcher) parseLocked(r io.Reader, file string) error {
 	if m.withCache {
 		m.matches = newCache()
 	}
-
-	return err
 }
 
 // Match matches the patterns plus temporary and internal files.
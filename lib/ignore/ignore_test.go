@@ -1731,3 +1731,35 @@ func testEscape(t *testing.T, tests []escapeTest, noErrors bool) {
 		}
 	}
 }
+
+func TestSharedPatterns(t *testing.T) {
+	pats := New(fs.NewFilesystem(fs.FilesystemTypeFake, rand.String(32)))
+	if err := pats.Parse(strings.NewReader("!keep\nlocal\n"), ".stignore"); err != nil {
+		t.Fatal(err)
+	}
+	localHash := pats.Hash()
+
+	if err := pats.SetShared([]string{"keep", "shared"}); err != nil {
+		t.Fatal(err)
+	}
+	if pats.Hash() == localHash {
+		t.Error("hash didn't change with shared patterns")
+	}
+	// Local patterns come first and win.
+	for file, ignored := range map[string]bool{"keep": false, "local": true, "shared": true, "other": false} {
+		if pats.Match(file).IsIgnored() != ignored {
+			t.Errorf("%s: expected ignored %v", file, ignored)
+		}
+	}
+	if lines := pats.Lines(); len(lines) != 2 {
+		t.Errorf("expected only the local lines, got %v", lines)
+	}
+
+	// Including local files isn't allowed, and leaves no shared patterns.
+	if err := pats.SetShared([]string{"shared", "#include other"}); err == nil {
+		t.Error("expected an error for an include")
+	}
+	if pats.Match("shared").IsIgnored() || pats.Hash() != localHash {
+		t.Error("shared patterns still in effect")
+	}
+}
@@ -22,19 +22,21 @@ type LocationEnum string
 // Use strings as keys to make printout and serialization of the locations map
 // more meaningful.
 const (
-	ConfigFile     LocationEnum = "config"
-	CertFile       LocationEnum = "certFile"
-	KeyFile        LocationEnum = "keyFile"
-	HTTPSCertFile  LocationEnum = "httpsCertFile"
-	HTTPSKeyFile   LocationEnum = "httpsKeyFile"
-	LegacyDatabase LocationEnum = "legacyDatabase"
-	Database       LocationEnum = "database"
-	LogFile        LocationEnum = "logFile"
-	PanicLog       LocationEnum = "panicLog"
-	AuditLog       LocationEnum = "auditLog"
-	GUIAssets      LocationEnum = "guiAssets"
-	DefFolder      LocationEnum = "defFolder"
-	LockFile       LocationEnum = "lockFile"
+	ConfigFile      LocationEnum = "config"
+	CertFile        LocationEnum = "certFile"
+	KeyFile         LocationEnum = "keyFile"
+	HTTPSCertFile   LocationEnum = "httpsCertFile"
+	HTTPSKeyFile    LocationEnum = "httpsKeyFile"
+	LegacyDatabase  LocationEnum = "legacyDatabase"
+	Database        LocationEnum = "database"
+	LogFile         LocationEnum = "logFile"
+	PanicLog        LocationEnum = "panicLog"
+	AuditLog        LocationEnum = "auditLog"
+	GUIAssets       LocationEnum = "guiAssets"
+	DefFolder       LocationEnum = "defFolder"
+	LockFile        LocationEnum = "lockFile"
+	UpgradeHealth   LocationEnum = "upgradeHealth"
+	UpgradeRollback LocationEnum = "upgradeRollback"
 )
 
 type BaseDirEnum string
@@ -116,19 +118,21 @@ func GetBaseDir(baseDir BaseDirEnum) string {
 
 // Use the variables from baseDirs here
 var locationTemplates = map[LocationEnum]string{
-	ConfigFile:     "${config}/config.xml",
-	CertFile:       "${config}/cert.pem",
-	KeyFile:        "${config}/key.pem",
-	HTTPSCertFile:  "${config}/https-cert.pem",
-	HTTPSKeyFile:   "${config}/https-key.pem",
-	LegacyDatabase: "${data}/" + levelDBDir,
-	Database:       "${data}/" + databaseName,
-	LogFile:        "${data}/syncthing.log", // --logfile on Windows
-	PanicLog:       "${data}/panic-%{timestamp}.log",
-	AuditLog:       "${data}/audit-%{timestamp}.log",
-	GUIAssets:      "${config}/gui",
-	DefFolder:      "${userHome}/Sync",
-	LockFile:       "${data}/syncthing.lock",
+	ConfigFile:      "${config}/config.xml",
+	CertFile:        "${config}/cert.pem",
+	KeyFile:         "${config}/key.pem",
+	HTTPSCertFile:   "${config}/https-cert.pem",
+	HTTPSKeyFile:    "${config}/https-key.pem",
+	LegacyDatabase:  "${data}/" + levelDBDir,
+	Database:        "${data}/" + databaseName,
+	LogFile:         "${data}/syncthing.log", // --logfile on Windows
+	PanicLog:        "${data}/panic-%{timestamp}.log",
+	AuditLog:        "${data}/audit-%{timestamp}.log",
+	GUIAssets:       "${config}/gui",
+	DefFolder:       "${userHome}/Sync",
+	LockFile:        "${data}/syncthing.lock",
+	UpgradeHealth:   "${data}/upgrade-health",
+	UpgradeRollback: "${data}/upgrade-rollback",
 }
 
 var locations = make(map[LocationEnum]string)
@@ -18,6 +18,8 @@ import (
 	"sync"
 	"time"
 
+	"golang.org/x/time/rate"
+
 	"github.com/syncthing/syncthing/internal/db"
 	"github.com/syncthing/syncthing/internal/itererr"
 	"github.com/syncthing/syncthing/internal/slogutil"
@@ -65,14 +67,20 @@ type folder struct {
 	scanScheduled          chan struct{}
 	versionCleanupInterval time.Duration
 	versionCleanupTimer    *time.Timer
+	scrubInterval          time.Duration
+	scrubTimer             *time.Timer
+	scrubLimiter           *rate.Limiter
+	scrubDB                *db.Typed
 
 	pullScheduled chan struct{}
 	pullPause     time.Duration
 	pullFailTimer *time.Timer
 
-	scanErrors []FileError
-	pullErrors []FileError
-	errorsMut  sync.Mutex
+	scanErrors  []FileError
+	pullErrors  []FileError
+	scrubErrors map[string]FileError // corrupted files, by name
+	hookError   *FileError           // from the last run of the post-sync hook
+	errorsMut   sync.Mutex
 
 	doInSyncChan chan syncRequest
 
@@ -90,6 +98,16 @@ type folder struct {
 	versioner versioner.Versioner
 
 	warnedKqueue bool
+
+	massChangeDB   *db.Typed
+	massChangeMut  sync.Mutex
+	massChange     *MassChange      // changes awaiting confirmation
+	scanMassChange massChangeAction // how the current scan handles mass changes
+	pullMassChange massChangeAction // how pulls handle mass changes, until one completes
+
+	seedDB          *db.Typed
+	seedWaiting     bool      // for the indexes to seed from before the initial scan
+	seedWaitStarted time.Time // when we started waiting, zero if we never did
 }
 
 type syncRequest struct {
@@ -124,6 +142,10 @@ func newFolder(model *model, ignores *ignore.Matcher, cfg config.FolderConfigura
 		scanScheduled:          make(chan struct{}, 1),
 		versionCleanupInterval: time.Duration(cfg.Versioning.CleanupIntervalS) * time.Second,
 		versionCleanupTimer:    time.NewTimer(time.Duration(cfg.Versioning.CleanupIntervalS) * time.Second),
+		scrubInterval:          time.Duration(cfg.ScrubIntervalS) * time.Second,
+		scrubTimer:             time.NewTimer(time.Duration(cfg.ScrubIntervalS) * time.Second),
+		scrubLimiter:           newScrubLimiter(cfg.ScrubMaxKiBps),
+		scrubDB:                db.NewTyped(model.sdb, "scrub/"+cfg.ID),
 
 		pullScheduled: make(chan struct{}, 1), // This needs to be 1-buffered so that we queue a pull if we're busy when it comes.
 
@@ -136,8 +158,12 @@ func newFolder(model *model, ignores *ignore.Matcher, cfg config.FolderConfigura
 		restartWatchChan: make(chan struct{}, 1),
 
 		versioner: ver,
+
+		massChangeDB: db.NewTyped(model.sdb, "masschange/"+cfg.ID),
+		seedDB:       db.NewTyped(model.sdb, "seed/"+cfg.ID),
 	}
 	f.pullPause = f.pullBasePause()
+	f.loadMassChange()
 	f.pullFailTimer = time.NewTimer(0)
 	<-f.pullFailTimer.C
 
@@ -156,6 +182,7 @@ func (f *folder) Serve(ctx context.Context) error {
 	defer func() {
 		f.scanTimer.Stop()
 		f.versionCleanupTimer.Stop()
+		f.scrubTimer.Stop()
 		f.setState(FolderIdle)
 	}()
 
@@ -171,6 +198,16 @@ func (f *folder) Serve(ctx context.Context) error {
 		}
 	}
 
+	if f.scrubEnabled() {
+		f.scrubTimer.Reset(f.scrubDelay())
+	} else if !f.scrubTimer.Stop() {
+		<-f.scrubTimer.C
+	}
+
+	if f.massChangeHeld() {
+		f.setState(FolderAwaitingConfirmation)
+	}
+
 	initialCompleted := f.initialScanFinished
 	pullTimer := time.NewTimer(0)
 	pullTimer.Stop()
@@ -189,7 +226,7 @@ func (f *folder) Serve(ctx context.Context) error {
 				// actual pull. Only set the state to SyncWaiting if we have
 				// reason to believe there is something to sync, to avoid
 				// unnecessary flashing in the GUI.
-				if needCount, err := f.db.CountNeed(f.folderID, protocol.LocalDeviceID); err == nil && needCount.TotalItems() > 0 {
+				if needCount, err := f.db.CountNeed(f.folderID, protocol.LocalDeviceID); err == nil && needCount.TotalItems() > 0 && !f.massChangeHeld() {
 					f.setState(FolderSyncWaiting)
 				}
 				pullTimer.Reset(time.Duration(float64(time.Second) * f.PullerDelayS))
@@ -245,6 +282,10 @@ func (f *folder) Serve(ctx context.Context) error {
 		case <-f.versionCleanupTimer.C:
 			f.sl.DebugContext(ctx, "Doing version cleanup")
 			f.versionCleanupTimerFired(ctx)
+
+		case <-f.scrubTimer.C:
+			f.sl.DebugContext(ctx, "Scrubbing due to timer")
+			f.scrubTimerFired(ctx)
 		}
 
 		if err != nil {
@@ -378,10 +419,24 @@ func (f *folder) pull(ctx context.Context) (success bool, err error) {
 		return true, nil
 	}
 
+	if f.massChangeHeld() {
+		// Pulling resumes once the changes are confirmed.
+		f.setState(FolderAwaitingConfirmation)
+		return true, nil
+	}
+
+	if f.seedWaiting {
+		// Existing files are adopted by scanning, once there is an index
+		// to compare them with, before anything is pulled.
+		f.ScheduleScan()
+		return true, nil
+	}
+
 	defer func() {
 		if success {
 			// We're good, reset the pause interval.
 			f.pullPause = f.pullBasePause()
+			f.pullMassChange = massChangeCheck
 		}
 	}()
 
@@ -398,6 +453,14 @@ func (f *folder) pull(ctx context.Context) (success bool, err error) {
 		return true, nil
 	}
 
+	// Index updates are checked one at a time as they come in, which
+	// doesn't catch a mass change sent in many small ones.
+	if held, err := f.checkNeededMassChange(ctx); err != nil {
+		return false, err
+	} else if held {
+		return true, nil
+	}
+
 	// Abort early (before acquiring a token) if there's a folder error
 	err = f.getHealthErrorWithoutIgnores()
 	if err != nil {
@@ -434,6 +497,12 @@ func (f *folder) pull(ctx context.Context) (success bool, err error) {
 
 	success, err = f.puller.pull(ctx)
 
+	if f.massChangeHeld() {
+		// Held while pulling.
+		f.setState(FolderAwaitingConfirmation)
+		return true, nil
+	}
+
 	if success && err == nil {
 		return true, nil
 	}
@@ -447,6 +516,11 @@ func (f *folder) pull(ctx context.Context) (success bool, err error) {
 }
 
 func (f *folder) scanSubdirs(ctx context.Context, subDirs []string) error {
+	if f.massChangeHeld() {
+		f.sl.DebugContext(ctx, "Not scanning while awaiting confirmation of held changes")
+		return nil
+	}
+
 	f.sl.DebugContext(ctx, "Scanning")
 
 	oldHash := f.ignores.Hash()
@@ -457,6 +531,26 @@ func (f *folder) scanSubdirs(ctx context.Context, subDirs []string) error {
 	}
 	f.setError(ctx, nil)
 
+	waiting, err := f.seedWaitingForIndex()
+	if err != nil {
+		return err
+	}
+	if waiting {
+		if f.seedWaitStarted.IsZero() {
+			f.sl.InfoContext(ctx, "Waiting for the indexes of the other devices before scanning in seed mode")
+			f.seedWaitStarted = time.Now()
+		}
+		if time.Since(f.seedWaitStarted) < seedIndexTimeout {
+			f.seedWaiting = true
+			f.setState(FolderSeedWaiting)
+			return nil
+		}
+		if f.seedWaiting {
+			f.sl.WarnContext(ctx, "Timed out waiting for the indexes of the other devices, scanning without them", slog.Duration("timeout", seedIndexTimeout))
+		}
+	}
+	f.seedWaiting = false
+
 	// Check on the way out if the ignore patterns changed as part of scanning
 	// this folder. If they did we should schedule a pull of the folder so that
 	// we request things we might have suddenly become unignored and so on.
@@ -469,7 +563,13 @@ func (f *folder) scanSubdirs(ctx context.Context, subDirs []string) error {
 	}()
 
 	f.setState(FolderScanWaiting)
-	defer f.setState(FolderIdle)
+	defer func() {
+		if f.massChangeHeld() {
+			f.setState(FolderAwaitingConfirmation)
+		} else {
+			f.setState(FolderIdle)
+		}
+	}()
 
 	if err := f.ioLimiter.TakeWithContext(ctx, 1); err != nil {
 		return err
@@ -506,6 +606,26 @@ func (f *folder) scanSubdirs(ctx context.Context, subDirs []string) error {
 	f.clearScanErrors(subDirs)
 
 	batch := f.newScanBatch()
+	if f.SeedMode && f.Type != config.FolderTypeReceiveEncrypted {
+		batch.seeding = true
+		defer func() { f.addSeedSummary(ctx, batch.seedSummary) }()
+	}
+	batch.massChange = f.scanMassChange
+	// Only local changes that are sent to other devices matter. Approved
+	// suspicious files are tracked too, so that changing them again
+	// doesn't count.
+	detectRansomware := f.RansomwareDetection && (f.Type == config.FolderTypeSendReceive || f.Type == config.FolderTypeSendOnly)
+	if detectRansomware && batch.massChange != massChangeRevert {
+		batch.suspicious = make(map[string]string)
+	}
+	if batch.massChange == massChangeCheck {
+		if batch.limit, err = f.massChangeLimit(); err != nil {
+			return err
+		}
+		if detectRansomware {
+			batch.minSuspicious = f.RansomwareMinFiles
+		}
+	}
 
 	// Schedule a pull after scanning, but only if we actually detected any
 	// changes.
@@ -520,7 +640,7 @@ func (f *folder) scanSubdirs(ctx context.Context, subDirs []string) error {
 	changesHere, err := f.scanSubdirsChangedAndNew(ctx, subDirs, batch)
 	changes += changesHere
 	if err != nil {
-		return err
+		return f.holdIfMassChange(err, batch)
 	}
 
 	if err := batch.Flush(); err != nil {
@@ -539,9 +659,10 @@ func (f *folder) scanSubdirs(ctx context.Context, subDirs []string) error {
 	changesHere, err = f.scanSubdirsDeletedAndIgnored(ctx, subDirs, batch)
 	changes += changesHere
 	if err != nil {
-		return err
+		return f.holdIfMassChange(err, batch)
 	}
 
+	batch.releaseHeld()
 	if err := batch.Flush(); err != nil {
 		return err
 	}
@@ -556,6 +677,19 @@ type scanBatch struct {
 	f           *folder
 	updateBatch *FileInfoBatch
 	toRemove    []string
+
+	massChange massChangeAction
+	limit      int                 // for massChangeCheck, zero for none
+	risky      int                 // deletions and modifications held
+	held       []protocol.FileInfo // risky and suspicious changes, until the scan completes
+
+	minSuspicious  int // for massChangeCheck, zero when not detecting ransomware
+	suspiciousMut  sync.Mutex
+	suspicious     map[string]string // name to reason, as found by the walker; nil when not detecting ransomware
+	heldSuspicious []string
+
+	seeding     bool
+	seedSummary SeedSummary
 }
 
 func (f *folder) newScanBatch() *scanBatch {
@@ -642,10 +776,86 @@ func (b *scanBatch) Update(fi protocol.FileInfo) (bool, error) {
 		b.f.sl.Debug("Merging identical locally changed item with global", slogutil.FilePath(fi.Name))
 		fi = gf
 	}
-	b.updateBatch.Append(fi)
+	if b.seeding {
+		var err error
+		if fi, err = b.seed(fi); err != nil {
+			return false, err
+		}
+	}
+	if b.massChange == massChangeRevert || b.massChange == massChangeCheck && (b.limit > 0 || b.minSuspicious > 0) {
+		cur, ok, err := b.f.db.GetDeviceFile(b.f.folderID, protocol.LocalDeviceID, fi.Name)
+		if err != nil {
+			return false, err
+		}
+		risky := ok && isMassChange(cur, fi)
+		switch {
+		case b.massChange == massChangeRevert:
+			if risky {
+				// Strictly older than what the other devices have, so
+				// that their version gets pulled back.
+				fi.Version = protocol.Vector{}
+			}
+		case b.isSuspicious(cur, ok, fi):
+			b.heldSuspicious = append(b.heldSuspicious, fi.Name)
+			if len(b.heldSuspicious) >= b.minSuspicious {
+				return false, errSuspiciousChange
+			}
+			b.held = append(b.held, fi)
+			return true, nil
+		case !risky || b.limit == 0:
+		case b.risky >= b.limit:
+			return false, errMassChange
+		default:
+			b.risky++
+			b.held = append(b.held, fi)
+			return true, nil
+		}
+	}
+	b.append(fi)
 	return true, nil
 }
 
+func (b *scanBatch) append(fi protocol.FileInfo) {
+	if b.suspicious != nil {
+		b.f.trackSuspicious(fi, b.markedSuspicious(fi.Name))
+	}
+	b.updateBatch.Append(fi)
+}
+
+// markSuspicious is called by the walker for changed files that look like
+// they were encrypted by malware.
+func (b *scanBatch) markSuspicious(name, reason string) {
+	b.suspiciousMut.Lock()
+	b.suspicious[name] = reason
+	b.suspiciousMut.Unlock()
+}
+
+func (b *scanBatch) markedSuspicious(name string) bool {
+	b.suspiciousMut.Lock()
+	defer b.suspiciousMut.Unlock()
+	_, ok := b.suspicious[name]
+	return ok
+}
+
+// isSuspicious returns whether the change from cur to fi counts towards
+// holding changes as suspicious: the new version looks encrypted, and
+// replaces one that existed and didn't.
+func (b *scanBatch) isSuspicious(cur protocol.FileInfo, ok bool, fi protocol.FileInfo) bool {
+	if b.minSuspicious == 0 || fi.IsDeleted() || !ok || cur.IsDeleted() || cur.IsInvalid() {
+		return false
+	}
+	return b.markedSuspicious(fi.Name) && !b.f.wasSuspicious(cur)
+}
+
+// releaseHeld adds the held changes to the batch, once the scan has
+// completed without exceeding the limit.
+func (b *scanBatch) releaseHeld() {
+	for _, fi := range b.held {
+		b.append(fi)
+	}
+	b.held = nil
+}
+
 func (f *folder) scanSubdirsChangedAndNew(ctx context.Context, subDirs []string, batch *scanBatch) (int, error) {
 	changes := 0
 
@@ -672,6 +882,10 @@ func (f *folder) scanSubdirsChangedAndNew(ctx context.Context, subDirs []string,
 		ScanOwnership:         f.SendOwnership || f.SyncOwnership,
 		ScanXattrs:            f.SendXattrs || f.SyncXattrs,
 		XattrFilter:           f.XattrFilter,
+		HardLinks:             f.SyncHardLinks && f.Type != config.FolderTypeReceiveEncrypted,
+	}
+	if batch.suspicious != nil {
+		scanConfig.Suspicious = batch.markSuspicious
 	}
 	var fchan chan scanner.ScanResult
 	if f.Type == config.FolderTypeReceiveEncrypted {
@@ -956,7 +1170,11 @@ func (f *folder) scanTimerFired(ctx context.Context) error {
 		close(f.initialScanFinished)
 	}
 
-	f.Reschedule()
+	if f.seedWaiting {
+		f.scanTimer.Reset(seedIndexRecheck)
+	} else {
+		f.Reschedule()
+	}
 
 	return err
 }
@@ -1222,13 +1440,30 @@ outer:
 	f.scanErrors = filtered
 }
 
+// setHookError records the outcome of running the post-sync hook, clearing
+// the error of the previous run.
+func (f *folder) setHookError(err error) {
+	f.errorsMut.Lock()
+	defer f.errorsMut.Unlock()
+	f.hookError = nil
+	if err != nil {
+		f.hookError = &FileError{Err: "post-sync hook: " + err.Error()}
+	}
+}
+
 func (f *folder) Errors() []FileError {
 	f.errorsMut.Lock()
 	defer f.errorsMut.Unlock()
 	scanLen := len(f.scanErrors)
-	errors := make([]FileError, scanLen+len(f.pullErrors))
+	errors := make([]FileError, scanLen+len(f.pullErrors), scanLen+len(f.pullErrors)+len(f.scrubErrors))
 	copy(errors[:scanLen], f.scanErrors)
 	copy(errors[scanLen:], f.pullErrors)
+	for _, fe := range f.scrubErrors {
+		errors = append(errors, fe)
+	}
+	if f.hookError != nil {
+		errors = append(errors, *f.hookError)
+	}
 	slices.SortFunc(errors, func(a, b FileError) int {
 		return strings.Compare(a.Path, b.Path)
 	})
@@ -0,0 +1,374 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/itererr"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// The percentage limit doesn't apply below this many changes, as it would
+// otherwise trip on nearly any change in small folders.
+const massChangeMinFiles = 10
+
+// How many of the suspicious files are named when holding them.
+const maxSuspiciousExamples = 10
+
+const (
+	MassChangeSourceScan       = "scan"
+	MassChangeSourceIndex      = "index"
+	MassChangeSourceSuspicious = "suspicious"
+)
+
+var (
+	errMassChange       = errors.New("mass change limit exceeded")
+	errSuspiciousChange = errors.New("suspicious changes found")
+	ErrNoMassChange     = errors.New("no changes awaiting confirmation")
+)
+
+// A MassChange describes changes held back because there were more
+// deletions and modifications in one scan, or incoming from other devices
+// before pulling, than the folder allows, or because a scan found files that look like they were
+// encrypted by ransomware.
+type MassChange struct {
+	Source   string            `json:"source"`             // one of the MassChangeSource constants
+	Device   protocol.DeviceID `json:"device,omitzero"`    // the device that sent the index
+	Changes  int               `json:"changes"`            // at least, as counting stops at the limit for scans
+	Limit    int               `json:"limit"`              // for suspicious changes, the number of files that triggers holding them
+	Examples []string          `json:"examples,omitempty"` // some of the suspicious files
+	Time     time.Time         `json:"time"`
+}
+
+type massChangeAction int
+
+const (
+	massChangeCheck   massChangeAction = iota // hold changes over the limit
+	massChangeApprove                         // let all changes through
+	massChangeRevert                          // undo the risky changes
+)
+
+// massChangeLimit returns the number of deletions and modifications
+// allowed at once, or zero for no limit.
+func (f *folder) massChangeLimit() (int, error) {
+	limit := f.MassChangeMaxFiles
+	if f.MassChangeMaxPct > 0 {
+		counts, err := f.db.CountLocal(f.folderID, protocol.LocalDeviceID)
+		if err != nil {
+			return 0, err
+		}
+		items := counts.TotalItems() - counts.Deleted
+		pctLimit := max(massChangeMinFiles, items*f.MassChangeMaxPct/100)
+		if limit == 0 || pctLimit < limit {
+			limit = pctLimit
+		}
+	}
+	return limit, nil
+}
+
+// isMassChange returns whether the change from cur to fi counts towards
+// the limit, that is whether it deletes or changes the contents of an item
+// we have.
+func isMassChange(cur, fi protocol.FileInfo) bool {
+	if cur.IsDeleted() || cur.IsInvalid() || fi.IsInvalid() {
+		return false
+	}
+	if fi.IsDeleted() {
+		return true
+	}
+	return fi.Type != cur.Type || fi.Size != cur.Size || !bytes.Equal(fi.BlocksHash, cur.BlocksHash)
+}
+
+// MassChange returns the changes awaiting confirmation, if any.
+func (f *folder) MassChange() (MassChange, bool) {
+	f.massChangeMut.Lock()
+	defer f.massChangeMut.Unlock()
+	if f.massChange == nil {
+		return MassChange{}, false
+	}
+	return *f.massChange, true
+}
+
+func (f *folder) massChangeHeld() bool {
+	f.massChangeMut.Lock()
+	defer f.massChangeMut.Unlock()
+	return f.massChange != nil
+}
+
+// holdMassChange puts the folder in the awaiting confirmation state until
+// the changes are approved or reverted.
+func (f *folder) holdMassChange(mc MassChange) {
+	f.massChangeMut.Lock()
+	f.massChange = &mc
+	f.massChangeMut.Unlock()
+
+	if bs, err := json.Marshal(mc); err == nil {
+		if err := f.massChangeDB.PutBytes("held", bs); err != nil {
+			f.sl.Warn("Failed to save held mass change", slogutil.Error(err))
+		}
+	}
+
+	attrs := []any{slog.String("source", mc.Source), slog.Int("changes", mc.Changes), slog.Int("limit", mc.Limit)}
+	evData := map[string]any{
+		"folder":  f.ID,
+		"source":  mc.Source,
+		"changes": mc.Changes,
+		"limit":   mc.Limit,
+	}
+	msg := "Holding mass deletion or modification until confirmed"
+	switch mc.Source {
+	case MassChangeSourceIndex:
+		attrs = append(attrs, mc.Device.LogAttr())
+		evData["device"] = mc.Device.String()
+	case MassChangeSourceSuspicious:
+		attrs = append(attrs, slog.Any("examples", mc.Examples))
+		evData["examples"] = mc.Examples
+		msg = "Holding local changes that look like files encrypted by ransomware until confirmed"
+		f.evLogger.Log(events.Failure, "holding suspicious local changes")
+	}
+	f.sl.Warn(msg, attrs...)
+	f.evLogger.Log(events.MassChangeDetected, evData)
+	f.setState(FolderAwaitingConfirmation)
+}
+
+// loadMassChange restores changes held before a restart.
+func (f *folder) loadMassChange() {
+	bs, ok, err := f.massChangeDB.Bytes("held")
+	if err != nil || !ok {
+		return
+	}
+	var mc MassChange
+	if err := json.Unmarshal(bs, &mc); err != nil {
+		return
+	}
+	f.massChange = &mc
+}
+
+// ConfirmMassChange approves or reverts the changes awaiting
+// confirmation. Approved changes from a scan are committed by scanning
+// again, those from another device by pulling. Reverting restores the
+// files from the other devices or, for changes from another device,
+// restores the local versions on all devices.
+func (f *folder) ConfirmMassChange(revert bool) error {
+	return f.doInSync(func(ctx context.Context) error {
+		mc, ok := f.MassChange()
+		if !ok {
+			return ErrNoMassChange
+		}
+		if revert && mc.Source == MassChangeSourceIndex && f.Type != config.FolderTypeSendReceive {
+			return fmt.Errorf("changes from other devices can't be reverted on a %s folder", f.Type)
+		}
+
+		f.massChangeMut.Lock()
+		f.massChange = nil
+		f.massChangeMut.Unlock()
+		_ = f.massChangeDB.Delete("held")
+		f.setState(FolderIdle)
+		f.sl.InfoContext(ctx, "Confirmed held mass change", slog.String("source", mc.Source), slog.Bool("revert", revert))
+
+		switch {
+		case mc.Source == MassChangeSourceIndex && !revert:
+			f.pullMassChange = massChangeApprove
+		case mc.Source == MassChangeSourceScan || mc.Source == MassChangeSourceSuspicious:
+			f.scanMassChange = massChangeApprove
+			if revert {
+				f.scanMassChange = massChangeRevert
+			}
+			defer func() { f.scanMassChange = massChangeCheck }()
+			return f.scanSubdirs(ctx, nil)
+		case revert:
+			if err := f.revertIncoming(ctx); err != nil {
+				return err
+			}
+		}
+		f.SchedulePull()
+		return nil
+	})
+}
+
+// checkIncomingMassChange holds the changes from another device if the
+// index batch deletes or modifies more of our files than allowed. The
+// index is still recorded; it's acting on it that waits for confirmation.
+func (f *folder) checkIncomingMassChange(device protocol.DeviceID, fs []protocol.FileInfo) error {
+	if f.Type == config.FolderTypeSendOnly || f.massChangeHeld() {
+		return nil
+	}
+	limit, err := f.massChangeLimit()
+	if err != nil || limit == 0 {
+		return err
+	}
+	changes := 0
+	for _, fi := range fs {
+		if fi.IsDeleted() && f.IgnoreDelete {
+			continue
+		}
+		cur, ok, err := f.db.GetDeviceFile(f.folderID, protocol.LocalDeviceID, fi.Name)
+		if err != nil {
+			return err
+		}
+		if !ok || fi.Version.LesserEqual(cur.Version) || !isMassChange(cur, fi) {
+			continue
+		}
+		changes++
+	}
+	if changes > limit {
+		f.holdMassChange(MassChange{
+			Source:  MassChangeSourceIndex,
+			Device:  device,
+			Changes: changes,
+			Limit:   limit,
+			Time:    time.Now().Truncate(time.Second),
+		})
+	}
+	return nil
+}
+
+// checkNeededMassChange holds the changes from other devices if what we
+// need, taken together, deletes or modifies more of our files than
+// allowed. It returns whether the changes are held.
+func (f *folder) checkNeededMassChange(ctx context.Context) (bool, error) {
+	if f.Type == config.FolderTypeSendOnly || f.pullMassChange == massChangeApprove {
+		return false, nil
+	}
+	limit, err := f.massChangeLimit()
+	if err != nil || limit == 0 {
+		return false, err
+	}
+	changes := 0
+	byDevice := make(map[protocol.ShortID]int)
+	for need, err := range itererr.Zip(f.db.AllNeededGlobalFiles(f.folderID, protocol.LocalDeviceID, config.PullOrderAlphabetic, 0, 0)) {
+		if err != nil {
+			return false, err
+		}
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		if need.IsDeleted() && f.IgnoreDelete {
+			continue
+		}
+		have, ok, err := f.db.GetDeviceFile(f.folderID, protocol.LocalDeviceID, need.Name)
+		if err != nil {
+			return false, err
+		}
+		if !ok || !isMassChange(have, need) {
+			continue
+		}
+		changes++
+		byDevice[need.ModifiedBy]++
+	}
+	if changes <= limit {
+		return false, nil
+	}
+
+	// Attribute the changes to the device that made most of them.
+	var device protocol.DeviceID
+	most := 0
+	for _, dev := range f.Devices {
+		if n := byDevice[dev.DeviceID.Short()]; n > most {
+			device, most = dev.DeviceID, n
+		}
+	}
+	f.holdMassChange(MassChange{
+		Source:  MassChangeSourceIndex,
+		Device:  device,
+		Changes: changes,
+		Limit:   limit,
+		Time:    time.Now().Truncate(time.Second),
+	})
+	return true, nil
+}
+
+// revertIncoming makes our versions of the items changed by other devices
+// win over theirs, so that the changes are undone everywhere.
+func (f *folder) revertIncoming(ctx context.Context) error {
+	f.setState(FolderScanning)
+	defer f.setState(FolderIdle)
+
+	batch := NewFileInfoBatch(func(files []protocol.FileInfo) error {
+		return f.updateLocalsFromScanning(files)
+	})
+	for need, err := range itererr.Zip(f.db.AllNeededGlobalFiles(f.folderID, protocol.LocalDeviceID, config.PullOrderAlphabetic, 0, 0)) {
+		if err != nil {
+			return err
+		}
+		if err := batch.FlushIfFull(); err != nil {
+			return err
+		}
+		have, ok, err := f.db.GetDeviceFile(f.folderID, protocol.LocalDeviceID, need.Name)
+		if err != nil {
+			return err
+		}
+		// Only what we have is restored; other devices keep what's new
+		// to us.
+		if !ok || !isMassChange(have, need) {
+			continue
+		}
+		have.Version = have.Version.Merge(need.Version).Update(f.shortID)
+		have.Sequence = 0
+		batch.Append(have)
+	}
+	return batch.Flush()
+}
+
+// trackSuspicious remembers the contents of files committed while looking
+// encrypted, so that their next change isn't taken for ransomware.
+func (f *folder) trackSuspicious(fi protocol.FileInfo, suspicious bool) {
+	key := "suspicious/" + fi.Name
+	if suspicious && !fi.IsDeleted() {
+		if err := f.massChangeDB.PutBytes(key, fi.BlocksHash); err != nil {
+			f.sl.Debug("Failed to track suspicious file", slogutil.FilePath(fi.Name), slogutil.Error(err))
+		}
+		return
+	}
+	if _, ok, err := f.massChangeDB.Bytes(key); err == nil && ok {
+		_ = f.massChangeDB.Delete(key)
+	}
+}
+
+// wasSuspicious returns whether the file version is one committed while
+// looking encrypted.
+func (f *folder) wasSuspicious(cur protocol.FileInfo) bool {
+	hash, ok, err := f.massChangeDB.Bytes("suspicious/" + cur.Name)
+	return err == nil && ok && bytes.Equal(hash, cur.BlocksHash)
+}
+
+// holdIfMassChange holds the changes found by a scan that stopped because
+// there were too many or they were suspicious, or returns the error that
+// stopped it.
+func (f *folder) holdIfMassChange(err error, batch *scanBatch) error {
+	switch {
+	case errors.Is(err, errMassChange):
+		f.holdMassChange(MassChange{
+			Source:  MassChangeSourceScan,
+			Changes: batch.risky + 1,
+			Limit:   batch.limit,
+			Time:    time.Now().Truncate(time.Second),
+		})
+	case errors.Is(err, errSuspiciousChange):
+		f.holdMassChange(MassChange{
+			Source:   MassChangeSourceSuspicious,
+			Changes:  len(batch.heldSuspicious),
+			Limit:    batch.minSuspicious,
+			Examples: batch.heldSuspicious[:min(len(batch.heldSuspicious), maxSuspiciousExamples)],
+			Time:     time.Now().Truncate(time.Second),
+		})
+	default:
+		return err
+	}
+	batch.held = nil
+	return nil
+}
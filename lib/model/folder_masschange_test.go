@@ -0,0 +1,288 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func setupMassChangeModel(t *testing.T) (*testModel, fs.Filesystem, []string) {
+	t.Helper()
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.MassChangeMaxFiles = 3
+	setFolder(t, w, fcfg)
+	m := setupModel(t, w)
+	t.Cleanup(func() { cleanupModel(m) })
+
+	ffs := fcfg.Filesystem()
+	var names []string
+	for i := range 10 {
+		name := fmt.Sprintf("file%d", i)
+		writeFile(t, ffs, name, []byte(name))
+		names = append(names, name)
+	}
+	must(t, m.ScanFolder(fcfg.ID))
+	return m, ffs, names
+}
+
+func TestMassChangeScan(t *testing.T) {
+	for _, revert := range []bool{false, true} {
+		t.Run(fmt.Sprintf("revert=%v", revert), func(t *testing.T) {
+			m, ffs, names := setupMassChangeModel(t)
+
+			// Up to the limit is fine.
+			for _, name := range names[:3] {
+				must(t, ffs.Remove(name))
+			}
+			must(t, m.ScanFolder("default"))
+			if _, ok := m.testMassChange("default"); ok {
+				t.Fatal("unexpected held changes")
+			}
+
+			// Beyond it the changes are held.
+			for _, name := range names[3:8] {
+				must(t, ffs.Remove(name))
+			}
+			writeFile(t, ffs, names[8], []byte("changed"))
+			must(t, m.ScanFolder("default"))
+			mc, ok := m.testMassChange("default")
+			if !ok || mc.Source != MassChangeSourceScan || mc.Limit != 3 {
+				t.Fatalf("unexpected held changes %+v", mc)
+			}
+			if state, _, _ := m.State("default"); state != "awaiting-confirmation" {
+				t.Errorf("unexpected state %s", state)
+			}
+			for _, name := range names[3:9] {
+				if fi, _ := m.testCurrentFolderFile("default", name); fi.IsDeleted() || fi.Size != int64(len(name)) {
+					t.Errorf("change to %s wasn't held", name)
+				}
+			}
+
+			must(t, m.ConfirmMassChange("default", revert))
+			if _, ok := m.testMassChange("default"); ok {
+				t.Fatal("changes still held")
+			}
+			for _, name := range names[3:8] {
+				fi, _ := m.testCurrentFolderFile("default", name)
+				if !fi.IsDeleted() {
+					t.Errorf("%s not deleted", name)
+				}
+				// Reverted changes are older than everything else.
+				if fi.Version.IsEmpty() != revert {
+					t.Errorf("unexpected version %v for %s", fi.Version, name)
+				}
+			}
+		})
+	}
+}
+
+func TestMassChangeIndex(t *testing.T) {
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.MassChangeMaxFiles = 3
+	setFolder(t, w, fcfg)
+	m, conn := setupModelWithConnectionFromWrapper(t, w)
+	defer cleanupModel(m)
+
+	ffs := fcfg.Filesystem()
+	var files []protocol.FileInfo
+	for i := range 5 {
+		name := fmt.Sprintf("file%d", i)
+		writeFile(t, ffs, name, []byte(name))
+	}
+	must(t, m.ScanFolder(fcfg.ID))
+	for i := range 5 {
+		fi, _ := m.testCurrentFolderFile(fcfg.ID, fmt.Sprintf("file%d", i))
+		fi.SetDeleted(device1.Short())
+		fi.Version = fi.Version.Update(device1.Short())
+		files = append(files, fi)
+	}
+
+	must(t, m.IndexUpdate(conn, &protocol.IndexUpdate{Folder: fcfg.ID, Files: files}))
+	mc, ok := m.testMassChange(fcfg.ID)
+	if !ok || mc.Source != MassChangeSourceIndex || mc.Device != device1 || mc.Changes != 5 {
+		t.Fatalf("unexpected held changes %+v", mc)
+	}
+
+	// Reverting makes our files win again.
+	must(t, m.ConfirmMassChange(fcfg.ID, true))
+	for _, remote := range files {
+		fi, _ := m.testCurrentFolderFile(fcfg.ID, remote.Name)
+		if fi.IsDeleted() || !fi.Version.GreaterEqual(remote.Version) {
+			t.Errorf("%s not restored: %v", fi.Name, fi)
+		}
+		if _, err := ffs.Stat(fi.Name); err != nil {
+			t.Error(err)
+		}
+	}
+	if need := mustV(m.NeedSize(fcfg.ID, protocol.LocalDeviceID)); need.TotalItems() != 0 {
+		t.Errorf("still needing %v", need)
+	}
+}
+
+func TestMassChangeIndexBatches(t *testing.T) {
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.MassChangeMaxFiles = 3
+	fcfg.PullerDelayS = 1 // the default, letting the updates settle
+	setFolder(t, w, fcfg)
+	m, conn := setupModelWithConnectionFromWrapper(t, w)
+	defer cleanupModel(m)
+
+	ffs := fcfg.Filesystem()
+	var names []string
+	for i := range 5 {
+		name := fmt.Sprintf("file%d", i)
+		writeFile(t, ffs, name, []byte(name))
+		names = append(names, name)
+	}
+	must(t, m.ScanFolder(fcfg.ID))
+
+	// Each update is below the limit, all of them together are not.
+	for _, name := range names {
+		fi, _ := m.testCurrentFolderFile(fcfg.ID, name)
+		fi.SetDeleted(device1.Short())
+		must(t, m.IndexUpdate(conn, &protocol.IndexUpdate{Folder: fcfg.ID, Files: []protocol.FileInfo{fi}}))
+	}
+	waitFor(t, func() bool {
+		_, ok := m.testMassChange(fcfg.ID)
+		return ok
+	})
+	mc, _ := m.testMassChange(fcfg.ID)
+	if mc.Source != MassChangeSourceIndex || mc.Device != device1 || mc.Changes != 5 {
+		t.Fatalf("unexpected held changes %+v", mc)
+	}
+	for _, name := range names {
+		if _, err := ffs.Stat(name); err != nil {
+			t.Errorf("%s deleted before confirmation: %v", name, err)
+		}
+	}
+
+	// Once approved, the deletions are pulled.
+	must(t, m.ConfirmMassChange(fcfg.ID, false))
+	waitFor(t, func() bool {
+		need := mustV(m.NeedSize(fcfg.ID, protocol.LocalDeviceID))
+		return need.TotalItems() == 0
+	})
+	for _, name := range names {
+		if _, err := ffs.Stat(name); !fs.IsNotExist(err) {
+			t.Errorf("%s not deleted: %v", name, err)
+		}
+	}
+}
+
+func TestMassChangeLimit(t *testing.T) {
+	t.Parallel()
+
+	w, fcfg := newDefaultCfgWrapper(t)
+	m := newModel(t, w, myID, nil)
+	for i, tc := range []struct {
+		maxPct, maxFiles, items, limit int
+	}{
+		{0, 0, 1000, 0},
+		{0, 50, 1000, 50},
+		{10, 0, 1000, 100},
+		{10, 50, 1000, 50},
+		{10, 500, 1000, 100},
+		{10, 0, 20, massChangeMinFiles},
+	} {
+		fcfg.MassChangeMaxPct = tc.maxPct
+		fcfg.MassChangeMaxFiles = tc.maxFiles
+		f := newFolder(m.model, nil, fcfg, m.evLogger, nil, nil)
+		files := make([]protocol.FileInfo, tc.items)
+		for j := range files {
+			files[j] = protocol.FileInfo{Name: fmt.Sprintf("%d-%d", i, j), Type: protocol.FileInfoTypeFile, Version: protocol.Vector{}.Update(myID.Short())}
+		}
+		must(t, m.sdb.DropFolder(fcfg.ID))
+		must(t, m.sdb.Update(fcfg.ID, protocol.LocalDeviceID, files))
+		if limit := mustV(f.massChangeLimit()); limit != tc.limit {
+			t.Errorf("%d: limit %d, expected %d", i, limit, tc.limit)
+		}
+	}
+}
+
+func TestSuspiciousChangesScan(t *testing.T) {
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.RansomwareDetection = true
+	fcfg.RansomwareMinFiles = 3
+	setFolder(t, w, fcfg)
+	m := setupModel(t, w)
+	defer cleanupModel(m)
+
+	ffs := fcfg.Filesystem()
+	text := bytes.Repeat([]byte("some notes\n"), 100)
+	var names []string
+	for i := range 6 {
+		name := fmt.Sprintf("notes%d.txt", i)
+		writeFile(t, ffs, name, text)
+		names = append(names, name)
+	}
+	must(t, m.ScanFolder(fcfg.ID))
+	if _, ok := m.testMassChange(fcfg.ID); ok {
+		t.Fatal("unexpected held changes")
+	}
+	versions := make(map[string]protocol.Vector)
+	for _, name := range names {
+		fi, _ := m.testCurrentFolderFile(fcfg.ID, name)
+		versions[name] = fi.Version
+	}
+
+	encrypted := make([]byte, len(text))
+	rand.Read(encrypted)
+
+	// Fewer suspicious files than the minimum go through.
+	for _, name := range names[:2] {
+		writeFile(t, ffs, name, encrypted)
+	}
+	must(t, m.ScanFolder(fcfg.ID))
+	if _, ok := m.testMassChange(fcfg.ID); ok {
+		t.Fatal("unexpected held changes")
+	}
+
+	for _, name := range names[2:5] {
+		writeFile(t, ffs, name, encrypted)
+	}
+	must(t, m.ScanFolder(fcfg.ID))
+	mc, ok := m.testMassChange(fcfg.ID)
+	if !ok || mc.Source != MassChangeSourceSuspicious || mc.Changes != 3 || len(mc.Examples) != 3 {
+		t.Fatalf("unexpected held changes %+v", mc)
+	}
+	for _, name := range names[2:5] {
+		if fi, _ := m.testCurrentFolderFile(fcfg.ID, name); !fi.Version.Equal(versions[name]) {
+			t.Errorf("change to %s wasn't held", name)
+		}
+	}
+
+	must(t, m.ConfirmMassChange(fcfg.ID, false))
+	for _, name := range names[2:5] {
+		if fi, _ := m.testCurrentFolderFile(fcfg.ID, name); fi.Version.Compare(versions[name]) != protocol.Greater {
+			t.Errorf("change to %s wasn't approved", name)
+		}
+	}
+
+	// New files, and changes to files that already looked encrypted, don't
+	// count.
+	for i := range 3 {
+		rand.Read(encrypted)
+		writeFile(t, ffs, fmt.Sprintf("new%d.txt", i), encrypted)
+	}
+	for _, name := range names[:5] {
+		rand.Read(encrypted)
+		writeFile(t, ffs, name, encrypted)
+	}
+	must(t, m.ScanFolder(fcfg.ID))
+	if mc, ok := m.testMassChange(fcfg.ID); ok {
+		t.Fatalf("unexpected held changes %+v", mc)
+	}
+	if _, ok := m.testCurrentFolderFile(fcfg.ID, "new0.txt"); !ok {
+		t.Error("new file wasn't scanned")
+	}
+}
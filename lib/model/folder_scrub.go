@@ -0,0 +1,331 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/syncthing/syncthing/internal/itererr"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	// How long the folder is kept busy scrubbing at a time, before scans
+	// and pulls get a chance to run.
+	scrubSliceDuration = 30 * time.Second
+	scrubSlicePause    = 5 * time.Second
+	scrubBatchFiles    = 100
+)
+
+var (
+	errScrubFileChanged = errors.New("file changed while scrubbing")
+	errScrubNoPeer      = errors.New("no connected device has the same version")
+)
+
+func newScrubLimiter(kibps int) *rate.Limiter {
+	if kibps == 0 {
+		return rate.NewLimiter(rate.Inf, 0)
+	}
+	return rate.NewLimiter(rate.Limit(kibps*1024), protocol.MaxBlockSize)
+}
+
+// scrubEnabled returns whether the folder should be scrubbed at all. The
+// blocks of encrypted folders aren't hashes of what is on disk.
+func (f *folder) scrubEnabled() bool {
+	return f.scrubInterval > 0 && f.Type != config.FolderTypeReceiveEncrypted
+}
+
+// scrubDelay returns when to continue the current scrubbing pass, or start
+// the next one.
+func (f *folder) scrubDelay() time.Duration {
+	if _, ok, _ := f.scrubDB.Int64("sequence"); ok {
+		return scrubSlicePause
+	}
+	last, ok, _ := f.scrubDB.Time("completed")
+	if !ok {
+		return f.scrubInterval
+	}
+	return max(0, time.Until(last.Add(f.scrubInterval)))
+}
+
+func (f *folder) scrubTimerFired(ctx context.Context) {
+	if err := f.scrub(ctx); err != nil && ctx.Err() == nil {
+		f.sl.WarnContext(ctx, "Failed to scrub folder", slogutil.Error(err))
+	}
+	f.scrubTimer.Reset(f.scrubDelay())
+}
+
+// scrub hashes the contents of the local files again and compares them to
+// the recorded blocks, without updating the index. A pass goes through the
+// files in sequence order, for at most scrubSliceDuration at a time.
+func (f *folder) scrub(ctx context.Context) error {
+	return f.scrubUntil(ctx, time.Now().Add(scrubSliceDuration))
+}
+
+// scrubUntil continues the scrubbing pass until the deadline, which is
+// checked after each block so that large files don't hold up the folder.
+func (f *folder) scrubUntil(ctx context.Context, deadline time.Time) error {
+	if f.massChangeHeld() {
+		return nil
+	}
+	if err := f.ioLimiter.TakeWithContext(ctx, 1); err != nil {
+		return err
+	}
+	defer f.ioLimiter.Give(1)
+
+	f.setState(FolderScrubbing)
+	defer f.setState(FolderIdle)
+
+	seq, _, err := f.scrubDB.Int64("sequence")
+	if err != nil {
+		return err
+	}
+	if seq == 0 {
+		f.sl.DebugContext(ctx, "Starting scrubbing pass")
+	}
+	// Where to continue in the file after seq, if we stopped in the
+	// middle of it.
+	partialSeq, _, err := f.scrubDB.Int64("partialSequence")
+	if err != nil {
+		return err
+	}
+	partialBlock, _, err := f.scrubDB.Int64("partialBlock")
+	if err != nil {
+		return err
+	}
+	for {
+		files, err := itererr.Collect(f.db.AllLocalFilesBySequence(f.folderID, protocol.LocalDeviceID, seq+1, scrubBatchFiles))
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			f.sl.DebugContext(ctx, "Completed scrubbing pass")
+			for _, key := range []string{"sequence", "partialSequence", "partialBlock"} {
+				if err := f.scrubDB.Delete(key); err != nil {
+					return err
+				}
+			}
+			return f.scrubDB.PutTime("completed", time.Now())
+		}
+		for _, fi := range files {
+			from := 0
+			if fi.Sequence == partialSeq {
+				from = int(partialBlock)
+			}
+			next, err := f.scrubFile(ctx, fi, from, deadline)
+			if err != nil {
+				return err
+			}
+			if next < len(fi.Blocks) {
+				// Out of time in the middle of the file.
+				if err := f.scrubDB.PutInt64("partialSequence", fi.Sequence); err != nil {
+					return err
+				}
+				if err := f.scrubDB.PutInt64("partialBlock", int64(next)); err != nil {
+					return err
+				}
+				return f.scrubDB.PutInt64("sequence", seq)
+			}
+			seq = fi.Sequence
+			if time.Now().After(deadline) {
+				break
+			}
+		}
+		if err := f.scrubDB.PutInt64("sequence", seq); err != nil {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return nil
+		}
+	}
+}
+
+// scrubFile checks the blocks of one file from the given one on, until the
+// deadline, reporting and optionally repairing corrupted blocks. It returns
+// the block to continue from, which is past the last one when the file is
+// done. Only cancellation is returned as an error; files that can't be
+// read or have changed are left to the scanner.
+func (f *folder) scrubFile(ctx context.Context, fi protocol.FileInfo, from int, deadline time.Time) (int, error) {
+	if from == 0 {
+		// A new check of the file replaces what the last one found.
+		f.setScrubError(fi.Name, nil)
+	}
+	if fi.IsDeleted() || fi.IsInvalid() || fi.Type != protocol.FileInfoTypeFile {
+		return len(fi.Blocks), nil
+	}
+	bad, next, err := f.scrubBlocks(ctx, fi, from, deadline)
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	if err != nil {
+		f.sl.DebugContext(ctx, "Skipping file while scrubbing", slogutil.FilePath(fi.Name), slogutil.Error(err))
+		f.setScrubError(fi.Name, nil)
+		return len(fi.Blocks), nil
+	}
+	if len(bad) == 0 {
+		return next, nil
+	}
+
+	repaired := false
+	if f.ScrubRepair {
+		if err := f.repairBlocks(ctx, fi, bad); err != nil {
+			f.sl.WarnContext(ctx, "Failed to repair corrupted file", slogutil.FilePath(fi.Name), slogutil.Error(err))
+		} else {
+			repaired = true
+		}
+	}
+	f.sl.WarnContext(ctx, "File contents don't match the recorded hashes", slogutil.FilePath(fi.Name), slog.Int("blocks", len(bad)), slog.Bool("repaired", repaired))
+	f.evLogger.Log(events.CorruptionDetected, map[string]any{
+		"folder":   f.ID,
+		"item":     fi.Name,
+		"blocks":   len(bad),
+		"repaired": repaired,
+	})
+	if !repaired {
+		f.setScrubError(fi.Name, fmt.Errorf("scrub: %d blocks don't match the recorded hashes", len(bad)))
+	}
+	return next, nil
+}
+
+// scrubBlocks returns the blocks of the file, from the given one on until
+// the deadline, whose contents don't match their hash, and the block to
+// continue from.
+func (f *folder) scrubBlocks(ctx context.Context, fi protocol.FileInfo, from int, deadline time.Time) ([]protocol.BlockInfo, int, error) {
+	if err := f.scrubUnchanged(fi); err != nil {
+		return nil, 0, err
+	}
+	fd, err := f.mtimefs.Open(fi.Name)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer fd.Close()
+
+	var bad []protocol.BlockInfo
+	buf := make([]byte, fi.BlockSize())
+	next := from
+	for next < len(fi.Blocks) {
+		block := fi.Blocks[next]
+		if err := f.scrubLimiter.WaitN(ctx, block.Size); err != nil {
+			return nil, 0, err
+		}
+		n, err := fd.ReadAt(buf[:block.Size], block.Offset)
+		if err != nil && n != block.Size {
+			return nil, 0, err
+		}
+		if !blockMatches(buf[:block.Size], block) {
+			bad = append(bad, block)
+		}
+		next++
+		if time.Now().After(deadline) {
+			break
+		}
+	}
+
+	// A file modified while we were reading isn't corrupt, just new.
+	if err := f.scrubUnchanged(fi); err != nil {
+		return nil, 0, err
+	}
+	return bad, next, nil
+}
+
+func (f *folder) scrubUnchanged(fi protocol.FileInfo) error {
+	info, err := f.mtimefs.Lstat(fi.Name)
+	if err != nil {
+		return err
+	}
+	if !info.IsRegular() || info.Size() != fi.Size || !protocol.ModTimeEqual(info.ModTime(), fi.ModTime(), f.modTimeWindow) {
+		return errScrubFileChanged
+	}
+	return nil
+}
+
+// repairBlocks overwrites the corrupted blocks with data from other devices
+// that have the same version of the file.
+func (f *folder) repairBlocks(ctx context.Context, fi protocol.FileInfo, bad []protocol.BlockInfo) error {
+	gf, ok, err := f.db.GetGlobalFile(f.folderID, fi.Name)
+	if err != nil {
+		return err
+	}
+	if !ok || !gf.Version.Equal(fi.Version) {
+		return errScrubNoPeer
+	}
+	candidates := f.model.fileAvailability(f.FolderConfiguration, fi)
+	if len(candidates) == 0 {
+		return errScrubNoPeer
+	}
+
+	fd, err := f.mtimefs.OpenFile(fi.Name, fs.OptReadWrite, 0o666)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Keep the modification time so that the scanner doesn't see a
+		// change.
+		_ = f.mtimefs.Chtimes(fi.Name, fi.ModTime(), fi.ModTime())
+	}()
+	defer fd.Close()
+
+	for _, block := range bad {
+		buf, err := f.fetchBlock(ctx, candidates, fi, block)
+		if err != nil {
+			return fmt.Errorf("block at offset %d: %w", block.Offset, err)
+		}
+		if _, err := fd.WriteAt(buf, block.Offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fetchBlock returns the block from the first device that has it intact.
+func (f *folder) fetchBlock(ctx context.Context, candidates []Availability, fi protocol.FileInfo, block protocol.BlockInfo) ([]byte, error) {
+	blockNo := int(block.Offset / int64(fi.BlockSize()))
+	var lastErr error
+	for _, candidate := range candidates {
+		buf, err := f.model.RequestGlobal(ctx, candidate.ID, f.folderID, fi.Name, blockNo, block.Offset, block.Size, block.Hash, false)
+		if err == nil && !blockMatches(buf, block) {
+			err = fmt.Errorf("hash mismatch from %s", candidate.ID.Short())
+		}
+		if err == nil {
+			return buf, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+func blockMatches(buf []byte, block protocol.BlockInfo) bool {
+	hash := sha256.Sum256(buf)
+	return len(buf) == block.Size && bytes.Equal(hash[:], block.Hash)
+}
+
+// setScrubError records, or with a nil error clears, the scrubbing result
+// for the file.
+func (f *folder) setScrubError(name string, err error) {
+	f.errorsMut.Lock()
+	defer f.errorsMut.Unlock()
+	if err == nil {
+		delete(f.scrubErrors, name)
+		return
+	}
+	if f.scrubErrors == nil {
+		f.scrubErrors = make(map[string]FileError)
+	}
+	f.scrubErrors[name] = FileError{Path: name, Err: err.Error()}
+}
@@ -0,0 +1,163 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// corruptFile flips a byte in the middle of the file, keeping its size
+// and modification time as bit rot would.
+func corruptFile(t *testing.T, ffs fs.Filesystem, fi protocol.FileInfo) {
+	t.Helper()
+	fd, err := ffs.OpenFile(fi.Name, fs.OptReadWrite, 0o644)
+	must(t, err)
+	_, err = fd.WriteAt([]byte{'X'}, fi.Size/2)
+	must(t, err)
+	must(t, fd.Close())
+	must(t, ffs.Chtimes(fi.Name, fi.ModTime(), fi.ModTime()))
+}
+
+func (m *testModel) testScrub(folder string) {
+	m.t.Helper()
+	runner, _ := m.folderRunners.Get(folder)
+	f := runner.(*sendReceiveFolder)
+	must(m.t, f.doInSync(f.scrub))
+}
+
+func TestScrubDetectsCorruption(t *testing.T) {
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.ScrubIntervalS = 3600
+	setFolder(t, w, fcfg)
+	m := setupModel(t, w)
+	defer cleanupModel(m)
+
+	ffs := fcfg.Filesystem()
+	data := bytes.Repeat([]byte("abcdefgh"), 1000)
+	writeFile(t, ffs, "file", data)
+	writeFile(t, ffs, "other", []byte("other"))
+	must(t, m.ScanFolder(fcfg.ID))
+	fi, _ := m.testCurrentFolderFile(fcfg.ID, "file")
+
+	m.testScrub(fcfg.ID)
+	if errs := mustV(m.FolderErrors(fcfg.ID)); len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+
+	corruptFile(t, ffs, fi)
+	m.testScrub(fcfg.ID)
+	errs := mustV(m.FolderErrors(fcfg.ID))
+	if len(errs) != 1 || errs[0].Path != "file" {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	// The index is left alone.
+	if cur, _ := m.testCurrentFolderFile(fcfg.ID, "file"); !cur.Version.Equal(fi.Version) {
+		t.Errorf("version changed from %v to %v", fi.Version, cur.Version)
+	}
+
+	// Once the file is good again, the next pass clears the error.
+	writeFile(t, ffs, "file", data)
+	must(t, ffs.Chtimes("file", fi.ModTime(), fi.ModTime()))
+	m.testScrub(fcfg.ID)
+	if errs := mustV(m.FolderErrors(fcfg.ID)); len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
+
+func TestScrubRepair(t *testing.T) {
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.ScrubIntervalS = 3600
+	fcfg.ScrubRepair = true
+	setFolder(t, w, fcfg)
+	m, fc := setupModelWithConnectionFromWrapper(t, w)
+	defer cleanupModel(m)
+
+	ffs := fcfg.Filesystem()
+	data := bytes.Repeat([]byte("abcdefgh"), 1000)
+	writeFile(t, ffs, "file", data)
+	must(t, m.ScanFolder(fcfg.ID))
+	fi, _ := m.testCurrentFolderFile(fcfg.ID, "file")
+
+	// The other device has the same version.
+	fc.fileData = map[string][]byte{"file": data}
+	must(t, m.IndexUpdate(fc, &protocol.IndexUpdate{Folder: fcfg.ID, Files: []protocol.FileInfo{prepareFileInfoForIndex(fi)}}))
+
+	corruptFile(t, ffs, fi)
+	m.testScrub(fcfg.ID)
+	if errs := mustV(m.FolderErrors(fcfg.ID)); len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	fd, err := ffs.Open("file")
+	must(t, err)
+	bs, err := io.ReadAll(fd)
+	fd.Close()
+	must(t, err)
+	if !bytes.Equal(bs, data) {
+		t.Error("file not repaired")
+	}
+	info, err := ffs.Lstat("file")
+	must(t, err)
+	if !info.ModTime().Equal(fi.ModTime()) {
+		t.Errorf("modification time changed from %v to %v", fi.ModTime(), info.ModTime())
+	}
+}
+
+func TestScrubResumesInFile(t *testing.T) {
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.ScrubIntervalS = 3600
+	setFolder(t, w, fcfg)
+	m := setupModel(t, w)
+	defer cleanupModel(m)
+
+	ffs := fcfg.Filesystem()
+	data := bytes.Repeat([]byte("abcdefgh"), 3*protocol.MinBlockSize/8)
+	writeFile(t, ffs, "file", data)
+	must(t, m.ScanFolder(fcfg.ID))
+	fi, _ := m.testCurrentFolderFile(fcfg.ID, "file")
+	if len(fi.Blocks) != 3 {
+		t.Fatalf("expected three blocks, got %d", len(fi.Blocks))
+	}
+	corruptFile(t, ffs, fi)
+
+	runner, _ := m.folderRunners.Get(fcfg.ID)
+	f := runner.(*sendReceiveFolder)
+	scrubBlock := func() {
+		t.Helper()
+		// A deadline that has passed lets one block through at a time.
+		must(t, f.doInSync(func(ctx context.Context) error {
+			return f.scrubUntil(ctx, time.Now())
+		}))
+	}
+
+	// The first block is fine, the second one is not, and the file is
+	// completed with the third.
+	scrubBlock()
+	if errs := mustV(m.FolderErrors(fcfg.ID)); len(errs) != 0 {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	if block, _, _ := f.scrubDB.Int64("partialBlock"); block != 1 {
+		t.Fatalf("expected to continue from block 1, not %d", block)
+	}
+	scrubBlock()
+	if errs := mustV(m.FolderErrors(fcfg.ID)); len(errs) != 1 || errs[0].Path != "file" {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+	scrubBlock()
+	if seq, _, _ := f.scrubDB.Int64("sequence"); seq != fi.Sequence {
+		t.Errorf("file not completed, at sequence %d", seq)
+	}
+	if errs := mustV(m.FolderErrors(fcfg.ID)); len(errs) != 1 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	// At most this many differing files are listed in the summary.
+	seedMaxListed = 1000
+	// How long a folder in seed mode waits for the indexes of the other
+	// devices before scanning without them, and how often it checks
+	// meanwhile.
+	seedIndexTimeout = 10 * time.Minute
+	seedIndexRecheck = 10 * time.Second
+)
+
+// A SeedSummary tells how the files found in a folder in seed mode compared
+// to what the other devices have.
+type SeedSummary struct {
+	Adopted        int      `json:"adopted"`   // identical, now with the global version
+	Differing      int      `json:"differing"` // with other contents than the global file
+	Extra          int      `json:"extra"`     // not on any other device
+	DifferingFiles []string `json:"differingFiles"`
+}
+
+func (s SeedSummary) isZero() bool {
+	return s.Adopted == 0 && s.Differing == 0 && s.Extra == 0
+}
+
+func (s *SeedSummary) add(o SeedSummary) {
+	s.Adopted += o.Adopted
+	s.Differing += o.Differing
+	s.Extra += o.Extra
+	for _, name := range o.DifferingFiles {
+		if len(s.DifferingFiles) < seedMaxListed {
+			s.DifferingFiles = append(s.DifferingFiles, name)
+		}
+	}
+	slices.Sort(s.DifferingFiles)
+}
+
+// seedWaitingForIndex returns whether a folder in seed mode hasn't scanned
+// yet and is missing the index of another device. Scanning before having
+// the full indexes would give the files we don't know about yet new
+// versions, which is what seeding avoids.
+func (f *folder) seedWaitingForIndex() (bool, error) {
+	if !f.SeedMode || f.Type == config.FolderTypeReceiveEncrypted {
+		return false, nil
+	}
+	if seq, err := f.db.GetDeviceSequence(f.folderID, protocol.LocalDeviceID); err != nil || seq > 0 {
+		return false, err
+	}
+	complete, connected, err := f.model.seedIndexesComplete(f.folderID)
+	if err != nil {
+		return false, err
+	}
+	return !connected || !complete, nil
+}
+
+// seedIndexesComplete returns whether all connected devices sharing the
+// folder have sent us their full index for it, that is up to the sequence
+// they announced in their cluster config, and whether there are any.
+func (m *model) seedIndexesComplete(folder string) (complete, connected bool, err error) {
+	remote, err := m.sdb.RemoteSequences(folder)
+	if err != nil {
+		return false, false, err
+	}
+
+	m.mut.RLock()
+	defer m.mut.RUnlock()
+	cfg, ok := m.folderCfgs[folder]
+	if !ok {
+		return false, false, ErrFolderMissing
+	}
+	complete = true
+	for _, dev := range cfg.DeviceIDs() {
+		if _, ok := m.deviceConnIDs[dev]; !ok || dev == m.id {
+			continue
+		}
+		states, ok := m.remoteFolderStates[dev]
+		if !ok {
+			// Connected, but we haven't got its cluster config yet.
+			connected, complete = true, false
+			continue
+		}
+		if states[folder] != remoteFolderValid {
+			continue
+		}
+		connected = true
+		if remote[dev] < m.remoteMaxSequences[dev][folder] {
+			complete = false
+		}
+	}
+	return complete, connected, nil
+}
+
+// SeedSummary returns the result of scanning in seed mode so far.
+func (f *folder) SeedSummary() (SeedSummary, error) {
+	summary := SeedSummary{DifferingFiles: []string{}}
+	bs, ok, err := f.seedDB.Bytes("summary")
+	if err != nil || !ok {
+		return summary, err
+	}
+	err = json.Unmarshal(bs, &summary)
+	return summary, err
+}
+
+func (f *folder) addSeedSummary(ctx context.Context, s SeedSummary) {
+	if s.isZero() {
+		return
+	}
+	f.sl.InfoContext(ctx, "Seeded folder from existing data", slog.Int("adopted", s.Adopted), slog.Int("differing", s.Differing), slog.Int("extra", s.Extra))
+	summary, err := f.SeedSummary()
+	if err != nil {
+		f.sl.WarnContext(ctx, "Failed to load seed summary", slogutil.Error(err))
+		return
+	}
+	summary.add(s)
+	bs, err := json.Marshal(summary)
+	if err == nil {
+		err = f.seedDB.PutBytes("summary", bs)
+	}
+	if err != nil {
+		f.sl.WarnContext(ctx, "Failed to save seed summary", slogutil.Error(err))
+	}
+}
+
+// seed returns the global file in place of the scanned one if we had no
+// record of it and the contents are the same, so that the existing copy
+// is taken as is instead of becoming a new version.
+func (b *scanBatch) seed(fi protocol.FileInfo) (protocol.FileInfo, error) {
+	if fi.IsDeleted() || fi.IsInvalid() {
+		return fi, nil
+	}
+	if _, ok, err := b.f.db.GetDeviceFile(b.f.folderID, protocol.LocalDeviceID, fi.Name); err != nil || ok {
+		return fi, err
+	}
+	gf, ok, err := b.f.db.GetGlobalFile(b.f.folderID, fi.Name)
+	if err != nil {
+		return fi, err
+	}
+	switch {
+	case !ok || gf.IsDeleted() || gf.IsInvalid():
+		b.seedSummary.Extra++
+		return fi, nil
+	case !seedMatches(fi, gf):
+		b.seedSummary.Differing++
+		if len(b.seedSummary.DifferingFiles) < seedMaxListed {
+			b.seedSummary.DifferingFiles = append(b.seedSummary.DifferingFiles, fi.Name)
+		}
+		return fi, nil
+	}
+
+	// The metadata on disk must match what we record, or the next scan
+	// sees a change.
+	if err := b.f.seedMetadata(fi, gf); err != nil {
+		b.f.sl.Warn("Failed to adopt seeded file", slogutil.FilePath(fi.Name), slogutil.Error(err))
+		b.seedSummary.Differing++
+		if len(b.seedSummary.DifferingFiles) < seedMaxListed {
+			b.seedSummary.DifferingFiles = append(b.seedSummary.DifferingFiles, fi.Name)
+		}
+		return fi, nil
+	}
+	b.seedSummary.Adopted++
+	adopted := gf
+	adopted.LocalFlags = fi.LocalFlags
+	adopted.Platform = fi.Platform
+	adopted.Sequence = 0
+	if b.f.IgnorePerms || gf.NoPermissions {
+		adopted.Permissions = fi.Permissions
+		adopted.NoPermissions = fi.NoPermissions
+	}
+	return adopted, nil
+}
+
+func seedMatches(fi, gf protocol.FileInfo) bool {
+	if fi.Type != gf.Type {
+		return false
+	}
+	switch fi.Type {
+	case protocol.FileInfoTypeFile:
+		return fi.Size == gf.Size && fi.BlocksEqual(gf)
+	case protocol.FileInfoTypeSymlink:
+		return slices.Equal(fi.SymlinkTarget, gf.SymlinkTarget)
+	default:
+		return true
+	}
+}
+
+func (f *folder) seedMetadata(fi, gf protocol.FileInfo) error {
+	if !f.IgnorePerms && !gf.NoPermissions && !fi.IsSymlink() && fi.Permissions&0o777 != gf.Permissions&0o777 {
+		if err := f.mtimefs.Chmod(fi.Name, fs.FileMode(gf.Permissions&0o777)); err != nil {
+			return err
+		}
+	}
+	if fi.Type == protocol.FileInfoTypeFile && !protocol.ModTimeEqual(fi.ModTime(), gf.ModTime(), f.modTimeWindow) {
+		return f.mtimefs.Chtimes(fi.Name, gf.ModTime(), gf.ModTime())
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestSeedMode(t *testing.T) {
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.SeedMode = true
+	setFolder(t, w, fcfg)
+
+	ffs := fcfg.Filesystem()
+	old := time.Now().Add(-time.Hour)
+	for name, data := range map[string]string{"same": "same contents", "diff": "our contents", "extra": "extra"} {
+		writeFile(t, ffs, name, []byte(data))
+		must(t, ffs.Chtimes(name, old, old))
+	}
+
+	m := setupModel(t, w)
+	defer cleanupModel(m)
+
+	// Without anything to compare with, nothing is scanned.
+	must(t, m.ScanFolder(fcfg.ID))
+	if _, ok := m.testCurrentFolderFile(fcfg.ID, "same"); ok {
+		t.Fatal("scanned before receiving an index")
+	}
+	if state, _, _ := m.State(fcfg.ID); state != "seed-waiting" {
+		t.Errorf("unexpected state %s", state)
+	}
+
+	// The other device announces two files, and sends them in separate
+	// batches. We wait for both.
+	fc := newFakeConnection(device1, m)
+	fc.folder = fcfg.ID
+	fc.addFile("diff", 0o644, protocol.FileInfoTypeFile, []byte("their contents"))
+	fc.addFile("same", 0o644, protocol.FileInfoTypeFile, []byte("same contents"))
+	m.AddConnection(fc, protocol.Hello{})
+	cc := basicClusterConfig(myID, device1, fcfg.ID)
+	cc.Folders[0].Devices[1].MaxSequence = fc.files[1].Sequence
+	must(t, m.ClusterConfig(fc, cc))
+
+	must(t, m.Index(fc, &protocol.Index{Folder: fcfg.ID, Files: fc.files[:1]}))
+	must(t, m.ScanFolder(fcfg.ID))
+	if _, ok := m.testCurrentFolderFile(fcfg.ID, "same"); ok {
+		t.Fatal("scanned before receiving the full index")
+	}
+	must(t, m.IndexUpdate(fc, &protocol.IndexUpdate{Folder: fcfg.ID, Files: fc.files[1:]}))
+	must(t, m.ScanFolder(fcfg.ID))
+
+	remote := fc.files[1]
+	fi, ok := m.testCurrentFolderFile(fcfg.ID, "same")
+	if !ok || !fi.Version.Equal(remote.Version) || !fi.ModTime().Equal(remote.ModTime()) {
+		t.Fatalf("global file not adopted: %v", fi)
+	}
+
+	summary := mustV(m.SeedSummary(fcfg.ID))
+	if summary.Adopted != 1 || summary.Differing != 1 || summary.Extra != 1 || !slices.Equal(summary.DifferingFiles, []string{"diff"}) {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+
+	// The adopted metadata is what is on disk now, so scanning again
+	// doesn't change anything.
+	must(t, m.ScanFolder(fcfg.ID))
+	if again, _ := m.testCurrentFolderFile(fcfg.ID, "same"); !again.Version.Equal(remote.Version) {
+		t.Errorf("rescan changed the version to %v", again.Version)
+	}
+}
+
+func TestSeedModeEmptyPeer(t *testing.T) {
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.SeedMode = true
+	setFolder(t, w, fcfg)
+	writeFile(t, fcfg.Filesystem(), "extra", []byte("extra"))
+
+	// A peer with nothing to seed from doesn't hold up scanning.
+	m, _ := setupModelWithConnectionFromWrapper(t, w)
+	defer cleanupModel(m)
+	must(t, m.ScanFolder(fcfg.ID))
+	if _, ok := m.testCurrentFolderFile(fcfg.ID, "extra"); !ok {
+		t.Fatal("not scanned with an empty peer")
+	}
+	if state, _, _ := m.State(fcfg.ID); state != "idle" {
+		t.Errorf("unexpected state %s", state)
+	}
+}
@@ -127,10 +127,15 @@ type sendReceiveFolder struct {
 	*folder
 
 	queue              *jobQueue
+	pullPriorities     pullPriorities
 	blockPullReorderer blockPullReorderer
 	writeLimiter       *semaphore.Semaphore
 
 	tempPullErrors map[string]string // pull errors that might be just transient
+
+	// Files put off by the last puller iteration to be linked to another
+	// member of their link group; they're not put off twice in a row.
+	hardLinkDeferred map[string]struct{}
 }
 
 func newSendReceiveFolder(model *model, ignores *ignore.Matcher, cfg config.FolderConfiguration, ver versioner.Versioner, evLogger events.Logger, ioLimiter *semaphore.Semaphore) service {
@@ -142,6 +147,12 @@ func newSendReceiveFolder(model *model, ignores *ignore.Matcher, cfg config.Fold
 	}
 	f.puller = f
 
+	var errs []error
+	f.pullPriorities, errs = newPullPriorities(f.mtimefs, cfg.PullPriorities)
+	for _, err := range errs {
+		f.sl.Warn("Ignoring invalid pull priority pattern", slogutil.Error(err))
+	}
+
 	if f.Copiers == 0 {
 		f.Copiers = defaultCopiers
 	}
@@ -201,7 +212,7 @@ func (f *sendReceiveFolder) pull(ctx context.Context) (bool, error) {
 
 		f.sl.DebugContext(ctx, "Pull iteration completed", "changed", changed, "try", tries+1)
 
-		if changed == 0 {
+		if changed == 0 || f.massChangeHeld() {
 			// No files were changed by the puller, so we are in sync, or we
 			// are unable to make further progress for the moment.
 			break
@@ -301,7 +312,9 @@ func (f *sendReceiveFolder) pullerIteration(ctx context.Context, scanChan chan<-
 	close(finisherChan)
 	doneWg.Wait()
 
-	if err == nil {
+	// Deletions wait for confirmation if a mass change was detected
+	// while pulling.
+	if err == nil && !f.massChangeHeld() {
 		f.processDeletions(ctx, fileDeletions, dirDeletions, dbUpdateChan, scanChan)
 	}
 
@@ -318,6 +331,11 @@ func (f *sendReceiveFolder) processNeeded(ctx context.Context, dbUpdateChan chan
 	var dirDeletions []protocol.FileInfo
 	fileDeletions := map[string]protocol.FileInfo{}
 	buckets := map[string][]protocol.FileInfo{}
+	linkGroups := map[string]struct{}{}
+	hardLinkDeferred := map[string]struct{}{}
+	defer func() {
+		f.hardLinkDeferred = hardLinkDeferred
+	}()
 
 	// Iterate the list of items that we need and sort them into piles.
 	// Regular files to pull goes into the file queue, everything else
@@ -395,10 +413,24 @@ loop:
 				// are only updating metadata, so we don't actually *need* to make the
 				// copy.
 				f.shortcutFile(file, dbUpdateChan)
-			} else {
-				// Queue files for processing after directories and symlinks.
-				f.queue.Push(file.Name, file.Size, file.ModTime())
+				break
 			}
+			if f.linksFile(file) {
+				// Of the members of a link group pulled together, only the
+				// first is pulled now and the rest are linked to it in the
+				// next iteration.
+				key := hardLinkKey(file)
+				_, queued := linkGroups[key]
+				_, deferred := f.hardLinkDeferred[file.Name]
+				if queued && !deferred {
+					f.sl.DebugContext(ctx, "Deferring hard linked file", slogutil.FilePath(file.Name))
+					hardLinkDeferred[file.Name] = struct{}{}
+					break
+				}
+				linkGroups[key] = struct{}{}
+			}
+			// Queue files for processing after directories and symlinks.
+			f.queue.Push(file.Name, file.Size, file.ModTime(), f.pullPriorities.priority(file.Name))
 
 		case (build.IsWindows || build.IsAndroid) && file.IsSymlink():
 			if err := f.handleSymlinkCheckExisting(file, scanChan); err != nil {
@@ -432,6 +464,10 @@ loop:
 	default:
 	}
 
+	if len(f.pullPriorities) > 0 {
+		f.queue.SortPriority()
+	}
+
 	// Process the file queue.
 
 nextFile:
@@ -1142,7 +1178,14 @@ func (f *sendReceiveFolder) handleFile(ctx context.Context, file protocol.FileIn
 	blocks := append([]protocol.BlockInfo{}, file.Blocks...)
 	reused := make([]int, 0, len(file.Blocks))
 
-	if f.Type != config.FolderTypeReceiveEncrypted {
+	if f.linksFile(file) && f.linkTempFile(ctx, file, tempName) {
+		// The temp file is a link to a file with the same contents, all
+		// that remains is moving it into place.
+		blocks = blocks[:0]
+		for i := range file.Blocks {
+			reused = append(reused, i)
+		}
+	} else if f.Type != config.FolderTypeReceiveEncrypted {
 		blocks, reused = f.reuseBlocks(ctx, blocks, reused, file, tempName)
 	}
 
@@ -1328,9 +1371,12 @@ func (f *sendReceiveFolder) shortcutFile(file protocol.FileInfo, dbUpdateChan ch
 // copierRoutine reads copierStates until the in channel closes and performs
 // the relevant copies when possible, or passes it to the puller routine.
 func (f *sendReceiveFolder) copierRoutine(ctx context.Context, in <-chan copyBlocksState, pullChan chan<- pullBlockState, out chan<- *sharedPullerState) {
+	// Blocks are copied from the other folders that allow it, as long as
+	// we can verify them. The hashes of encrypted folders are tokens that
+	// can't be checked, and never match those of other folders anyway.
 	otherFolderFilesystems := make(map[string]fs.Filesystem)
 	for folder, cfg := range f.model.cfg.Folders() {
-		if folder == f.ID {
+		if folder == f.ID || !cfg.ShareBlocksWithFolders || cfg.Type == config.FolderTypeReceiveEncrypted || f.Type == config.FolderTypeReceiveEncrypted {
 			continue
 		}
 		otherFolderFilesystems[folder] = cfg.Filesystem()
@@ -0,0 +1,81 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/syncthing/syncthing/internal/itererr"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// linksFile returns whether the file should be created as a hard link to
+// another member of its link group, if there is one.
+func (f *sendReceiveFolder) linksFile(file protocol.FileInfo) bool {
+	// Empty files have nothing to share, and the temp file of one wouldn't
+	// survive being handled.
+	return f.SyncHardLinks && f.Type != config.FolderTypeReceiveEncrypted && len(file.HardLinkGroup) > 0 && len(file.Blocks) > 0
+}
+
+// hardLinkKey identifies the members of a link group that can be linked to
+// each other, i.e. that have the same contents.
+func hardLinkKey(file protocol.FileInfo) string {
+	return string(file.HardLinkGroup) + string(file.BlocksHash)
+}
+
+// hardLinkSource returns the name of a file we have on disk that is in the
+// same link group as the given file and has the contents it should have.
+func (f *sendReceiveFolder) hardLinkSource(ctx context.Context, file protocol.FileInfo) (string, bool) {
+	for fi, err := range itererr.Zip(f.model.sdb.AllLocalFilesWithBlocksHash(f.folderID, file.BlocksHash)) {
+		if err != nil || ctx.Err() != nil {
+			return "", false
+		}
+		if fi.Name == file.Name || fi.Deleted || fi.Type != protocol.FileInfoTypeFile || fi.IsInvalid() || fi.ShouldConflict() || fi.Size != file.Size {
+			continue
+		}
+
+		// The group is checked on the global file, as the local one may
+		// not have caught up with a metadata change just pulled.
+		global, ok, err := f.model.sdb.GetGlobalFile(f.folderID, fi.Name)
+		if err != nil || !ok || !bytes.Equal(global.HardLinkGroup, file.HardLinkGroup) || !bytes.Equal(global.BlocksHash, file.BlocksHash) {
+			continue
+		}
+
+		// Make sure the file is still what we think it is.
+		info, err := f.mtimefs.Lstat(fi.Name)
+		if err != nil || !info.IsRegular() || info.Size() != file.Size {
+			continue
+		}
+		if !protocol.ModTimeEqual(info.ModTime(), fi.ModTime(), f.modTimeWindow) && !protocol.ModTimeEqual(info.ModTime(), global.ModTime(), f.modTimeWindow) {
+			continue
+		}
+		return fi.Name, true
+	}
+	return "", false
+}
+
+// linkTempFile creates the temp file as a hard link to another member of
+// the link group, returning whether it succeeded.
+func (f *sendReceiveFolder) linkTempFile(ctx context.Context, file protocol.FileInfo, tempName string) bool {
+	source, ok := f.hardLinkSource(ctx, file)
+	if !ok {
+		return false
+	}
+	f.inWritableDir(f.mtimefs.Remove, tempName)
+	err := f.inWritableDir(func(name string) error {
+		return f.mtimefs.CreateHardLink(source, name)
+	}, tempName)
+	if err != nil {
+		f.sl.DebugContext(ctx, "Failed to create hard link", slogutil.FilePath(file.Name), "source", source, slogutil.Error(err))
+		return false
+	}
+	f.sl.DebugContext(ctx, "Linked file", slogutil.FilePath(file.Name), "source", source)
+	return true
+}
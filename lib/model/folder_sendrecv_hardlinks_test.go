@@ -0,0 +1,139 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/build"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestPullHardLinks(t *testing.T) {
+	if build.IsWindows {
+		t.Skip("hard links aren't detected on Windows")
+	}
+
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.FilesystemType = config.FilesystemTypeBasic
+	fcfg.Path = t.TempDir()
+	fcfg.SyncHardLinks = true
+	must(t, fcfg.CreateMarker())
+	setFolder(t, w, fcfg)
+	m, fc := setupModelWithConnectionFromWrapper(t, w)
+	defer cleanupModel(m)
+
+	data := []byte("linked contents")
+	fc.mut.Lock()
+	for _, name := range []string{"a", "b", "c"} {
+		fc.addFileLocked(name, 0o644, protocol.FileInfoTypeFile, data, protocol.Vector{}.Update(fc.id.Short()), 0)
+	}
+	// a and b are links to the same data, c is a copy.
+	group := []byte("0123456789abcdef")
+	fc.files[0].HardLinkGroup = group
+	fc.files[1].HardLinkGroup = group
+	fc.mut.Unlock()
+	fc.sendIndexUpdate()
+
+	ffs := fcfg.Filesystem()
+	inode := func(name string) uint64 {
+		info, err := ffs.Lstat(name)
+		if err != nil {
+			return 0
+		}
+		_, ino, _, _ := fs.HardLinkID(info)
+		return ino
+	}
+	waitFor(t, func() bool {
+		return inode("a") != 0 && inode("b") != 0 && inode("c") != 0
+	})
+	if inode("a") != inode("b") {
+		t.Error("a and b aren't linked")
+	}
+	if inode("c") == inode("a") {
+		t.Error("c is linked to a")
+	}
+
+	// A rescan keeps the group as received and doesn't find changes.
+	must(t, m.ScanFolder(fcfg.ID))
+	for _, name := range []string{"a", "b"} {
+		f, ok := m.testCurrentFolderFile(fcfg.ID, name)
+		if !ok || !bytes.Equal(f.HardLinkGroup, group) || f.Version.Counter(m.shortID) != 0 {
+			t.Errorf("unexpected file after scan: %v", f)
+		}
+	}
+}
+
+func TestScanHardLinks(t *testing.T) {
+	if build.IsWindows {
+		t.Skip("hard links aren't detected on Windows")
+	}
+
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.FilesystemType = config.FilesystemTypeBasic
+	fcfg.Path = t.TempDir()
+	fcfg.SyncHardLinks = true
+	must(t, fcfg.CreateMarker())
+	setFolder(t, w, fcfg)
+	m := setupModel(t, w)
+	defer cleanupModel(m)
+
+	ffs := fcfg.Filesystem()
+	writeFile(t, ffs, "a", []byte("contents"))
+	writeFile(t, ffs, "c", []byte("contents"))
+	must(t, m.ScanFolder(fcfg.ID))
+	a, _ := m.testCurrentFolderFile(fcfg.ID, "a")
+	if a.HardLinkGroup != nil {
+		t.Fatal("unlinked file got a group")
+	}
+
+	// Linking an unchanged file gives it a group, shared with the link.
+	must(t, ffs.CreateHardLink("a", "b"))
+	must(t, m.ScanFolder(fcfg.ID))
+	a, _ = m.testCurrentFolderFile(fcfg.ID, "a")
+	b, _ := m.testCurrentFolderFile(fcfg.ID, "b")
+	c, _ := m.testCurrentFolderFile(fcfg.ID, "c")
+	if len(a.HardLinkGroup) == 0 || !bytes.Equal(a.HardLinkGroup, b.HardLinkGroup) {
+		t.Errorf("expected a shared group, got %x and %x", a.HardLinkGroup, b.HardLinkGroup)
+	}
+	if c.HardLinkGroup != nil {
+		t.Error("unlinked file got a group")
+	}
+
+	// Rewriting one of them breaks the link, and both lose the group.
+	must(t, ffs.Remove("a"))
+	writeFile(t, ffs, "a", []byte("contents"))
+	must(t, m.ScanFolder(fcfg.ID))
+	for _, name := range []string{"a", "b"} {
+		f, _ := m.testCurrentFolderFile(fcfg.ID, name)
+		if f.HardLinkGroup != nil {
+			t.Errorf("%s kept its group after the link was broken", name)
+		}
+	}
+	b2, _ := m.testCurrentFolderFile(fcfg.ID, "b")
+	if b2.Version.Equal(b.Version) {
+		t.Error("losing the group wasn't a change")
+	}
+
+	// Linking again gives a new group, and linking into a group that has
+	// gone separate ways doesn't reuse it for both.
+	must(t, ffs.CreateHardLink("c", "d"))
+	must(t, ffs.Remove("b"))
+	must(t, ffs.CreateHardLink("a", "b"))
+	must(t, m.ScanFolder(fcfg.ID))
+	groups := make(map[string][]byte)
+	for _, name := range []string{"a", "b", "c", "d"} {
+		f, _ := m.testCurrentFolderFile(fcfg.ID, name)
+		groups[name] = f.HardLinkGroup
+	}
+	if len(groups["a"]) == 0 || !bytes.Equal(groups["a"], groups["b"]) || len(groups["c"]) == 0 || !bytes.Equal(groups["c"], groups["d"]) || bytes.Equal(groups["a"], groups["c"]) {
+		t.Errorf("unexpected groups %x", groups)
+	}
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/syncthing/syncthing/lib/fs"
 	"github.com/syncthing/syncthing/lib/ignore"
 	"github.com/syncthing/syncthing/lib/protocol"
+	"github.com/syncthing/syncthing/lib/rand"
 	"github.com/syncthing/syncthing/lib/scanner"
 )
 
@@ -351,7 +352,7 @@ func TestDeregisterOnFailInCopy(t *testing.T) {
 	s := m.evLogger.Subscribe(events.ItemFinished)
 
 	// queue.Done should be called by the finisher routine
-	f.queue.Push("filex", 0, time.Time{})
+	f.queue.Push("filex", 0, time.Time{}, 0)
 	f.queue.Pop()
 
 	if f.queue.lenProgress() != 1 {
@@ -449,7 +450,7 @@ func TestDeregisterOnFailInPull(t *testing.T) {
 	s := m.evLogger.Subscribe(events.ItemFinished)
 
 	// queue.Done should be called by the finisher routine
-	f.queue.Push("filex", 0, time.Time{})
+	f.queue.Push("filex", 0, time.Time{}, 0)
 	f.queue.Pop()
 
 	if f.queue.lenProgress() != 1 {
@@ -1253,3 +1254,41 @@ func startCopier(ctx context.Context, f *sendReceiveFolder, pullChan chan<- pull
 	}()
 	return copyChan, wg
 }
+
+func TestCopyBlocksFromOtherFolder(t *testing.T) {
+	for _, share := range []bool{true, false} {
+		t.Run(fmt.Sprintf("share=%v", share), func(t *testing.T) {
+			w, fcfg := newDefaultCfgWrapper(t)
+			other := newFolderConfiguration(w, "other", "other", config.FilesystemTypeFake, rand.String(32)+"?content=true")
+			other.ShareBlocksWithFolders = share
+			setFolder(t, w, other)
+
+			contents := bytes.Repeat([]byte("contents"), 1000)
+			writeFile(t, other.Filesystem(), "source", contents)
+			m, fc := setupModelWithConnectionFromWrapper(t, w)
+			defer cleanupModel(m)
+
+			// Nothing can be had over the network.
+			fc.RequestCalls(func(context.Context, *protocol.Request) ([]byte, error) {
+				return nil, errors.New("not available")
+			})
+			fc.addFile("file", 0o644, protocol.FileInfoTypeFile, contents)
+			fc.sendIndexUpdate()
+
+			if !share {
+				waitFor(t, func() bool {
+					errs, err := m.FolderErrors(fcfg.ID)
+					return err == nil && len(errs) == 1
+				})
+				return
+			}
+			waitFor(t, func() bool {
+				_, ok := m.testCurrentFolderFile(fcfg.ID, "file")
+				return ok
+			})
+			if err := equalContents(fcfg.Filesystem(), "file", contents); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
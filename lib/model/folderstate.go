@@ -27,6 +27,9 @@ const (
 	FolderCleaning
 	FolderCleanWaiting
 	FolderError
+	FolderAwaitingConfirmation
+	FolderScrubbing
+	FolderSeedWaiting
 )
 
 func (s folderState) String() string {
@@ -49,6 +52,12 @@ func (s folderState) String() string {
 		return "clean-waiting"
 	case FolderError:
 		return "error"
+	case FolderAwaitingConfirmation:
+		return "awaiting-confirmation"
+	case FolderScrubbing:
+		return "scrubbing"
+	case FolderSeedWaiting:
+		return "seed-waiting"
 	default:
 		return "unknown"
 	}
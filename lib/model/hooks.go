@@ -0,0 +1,226 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/osutil"
+	"github.com/syncthing/syncthing/lib/semaphore"
+)
+
+// At most this many changes are passed to one run of a hook; the manifest
+// is marked as truncated when there were more.
+const hookMaxChanges = 100000
+
+// A hookManifest is what a post-sync hook gets on stdin, describing the
+// changes pulled since it last ran.
+type hookManifest struct {
+	Folder    string       `json:"folder"`
+	Label     string       `json:"label"`
+	Path      string       `json:"path"`
+	Changes   []hookChange `json:"changes"`
+	Truncated bool         `json:"truncated"`
+}
+
+type hookChange struct {
+	Path   string `json:"path"`
+	Type   string `json:"type"`   // file, dir or symlink
+	Action string `json:"action"` // update, delete or metadata
+}
+
+type folderHookState struct {
+	changes   []hookChange
+	truncated bool
+	due       bool // the folder went idle with changes to report
+	running   bool
+}
+
+// The hookService runs the post-sync command of a folder when it becomes
+// idle after pulling changes, collecting the changes from ItemFinished
+// events. Only one hook runs per folder at a time; changes pulled in the
+// meantime are reported by the next run.
+type hookService struct {
+	model    *model
+	cfg      config.Wrapper
+	evLogger events.Logger
+	limiter  *semaphore.Semaphore
+
+	mut     sync.Mutex
+	folders map[string]*folderHookState
+	running sync.WaitGroup
+}
+
+func newHookService(m *model) *hookService {
+	return &hookService{
+		model:    m,
+		cfg:      m.cfg,
+		evLogger: m.evLogger,
+		limiter:  semaphore.New(m.cfg.Options().MaxConcurrentHooks),
+		folders:  make(map[string]*folderHookState),
+	}
+}
+
+func (h *hookService) String() string {
+	return fmt.Sprintf("hookService@%p", h)
+}
+
+func (h *hookService) Serve(ctx context.Context) error {
+	sub := h.evLogger.Subscribe(events.ItemFinished | events.StateChanged)
+	defer sub.Unsubscribe()
+	defer h.running.Wait()
+
+	for {
+		select {
+		case ev, ok := <-sub.C():
+			if !ok {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			h.process(ctx, ev)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (h *hookService) process(ctx context.Context, ev events.Event) {
+	data, ok := ev.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+	folder, _ := data["folder"].(string)
+	fcfg, ok := h.cfg.Folder(folder)
+	if !ok || fcfg.PostSyncCommand == "" {
+		return
+	}
+
+	h.mut.Lock()
+	defer h.mut.Unlock()
+	state, ok := h.folders[folder]
+	if !ok {
+		state = &folderHookState{}
+		h.folders[folder] = state
+	}
+
+	switch ev.Type {
+	case events.ItemFinished:
+		if err, _ := data["error"].(*string); err != nil {
+			return
+		}
+		if len(state.changes) >= hookMaxChanges {
+			state.truncated = true
+			return
+		}
+		name, _ := data["item"].(string)
+		typ, _ := data["type"].(string)
+		action, _ := data["action"].(string)
+		state.changes = append(state.changes, hookChange{Path: name, Type: typ, Action: action})
+
+	case events.StateChanged:
+		if to, _ := data["to"].(string); to != FolderIdle.String() || len(state.changes) == 0 {
+			return
+		}
+		state.due = true
+		if !state.running {
+			state.running = true
+			h.running.Add(1)
+			go h.runFolder(ctx, folder, state)
+		}
+	}
+}
+
+// runFolder runs the hook of the folder for as long as there are changes
+// due to be reported.
+func (h *hookService) runFolder(ctx context.Context, folder string, state *folderHookState) {
+	defer h.running.Done()
+	for {
+		h.mut.Lock()
+		if !state.due || ctx.Err() != nil {
+			state.running = false
+			h.mut.Unlock()
+			return
+		}
+		manifest := hookManifest{
+			Folder:    folder,
+			Changes:   state.changes,
+			Truncated: state.truncated,
+		}
+		state.changes = nil
+		state.truncated = false
+		state.due = false
+		h.mut.Unlock()
+
+		fcfg, ok := h.cfg.Folder(folder)
+		if !ok || fcfg.PostSyncCommand == "" {
+			continue
+		}
+		manifest.Label = fcfg.Label
+		manifest.Path = fcfg.Filesystem().URI()
+		h.limiter.SetCapacity(h.cfg.Options().MaxConcurrentHooks)
+		if err := h.limiter.TakeWithContext(ctx, 1); err != nil {
+			continue
+		}
+		err := h.run(ctx, fcfg, manifest)
+		h.limiter.Give(1)
+		if ctx.Err() != nil {
+			continue
+		}
+
+		sl := slog.With(fcfg.LogAttr(), slog.Int("changes", len(manifest.Changes)))
+		if err != nil {
+			sl.Warn("Post-sync hook failed", slogutil.Error(err))
+		} else {
+			sl.Debug("Post-sync hook finished")
+		}
+		h.model.mut.RLock()
+		runner, ok := h.model.folderRunners.Get(folder)
+		h.model.mut.RUnlock()
+		if ok {
+			runner.setHookError(err)
+		}
+	}
+}
+
+func (h *hookService) run(ctx context.Context, fcfg config.FolderConfiguration, manifest hookManifest) error {
+	stdin, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+
+	timeout := time.Duration(fcfg.PostSyncTimeoutS) * time.Second
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd, err := osutil.UserCommand(ctx, fcfg.PostSyncCommand, map[string]string{
+		"%FOLDER_ID%":         fcfg.ID,
+		"%FOLDER_FILESYSTEM%": string(fcfg.FilesystemType),
+		"%FOLDER_PATH%":       manifest.Path,
+	})
+	if err != nil {
+		return err
+	}
+	cmd.Stdin = bytes.NewReader(stdin)
+	// Don't wait forever for children that keep the output open.
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.Output()
+	l.Debugln("post-sync hook output:", string(output))
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %v", timeout)
+	}
+	return osutil.CommandError(err)
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/build"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestPostSyncHook(t *testing.T) {
+	if build.IsWindows {
+		t.Skip("needs a shell")
+	}
+
+	manifestFile := filepath.Join(t.TempDir(), "manifest.json")
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.PostSyncCommand = `sh -c "cat > ` + manifestFile + `"`
+	setFolder(t, w, fcfg)
+	m, fc := setupModelWithConnectionFromWrapper(t, w)
+	defer cleanupModel(m)
+
+	fc.addFile("file", 0o644, protocol.FileInfoTypeFile, []byte("contents"))
+	fc.addFile("dir", 0o755, protocol.FileInfoTypeDirectory, nil)
+	fc.sendIndexUpdate()
+
+	var manifest hookManifest
+	waitFor(t, func() bool {
+		bs, err := os.ReadFile(manifestFile)
+		return err == nil && json.Unmarshal(bs, &manifest) == nil
+	})
+	if manifest.Folder != fcfg.ID || len(manifest.Changes) != 2 || manifest.Truncated {
+		t.Fatalf("unexpected manifest %+v", manifest)
+	}
+	for _, c := range manifest.Changes {
+		if c.Action != "update" || !(c.Path == "file" && c.Type == "file" || c.Path == "dir" && c.Type == "dir") {
+			t.Errorf("unexpected change %+v", c)
+		}
+	}
+
+	// A failing hook shows up among the folder errors.
+	fcfg.PostSyncCommand = `sh -c "echo broken >&2; exit 1"`
+	setFolder(t, w, fcfg)
+	fc.addFile("other", 0o644, protocol.FileInfoTypeFile, []byte("other"))
+	fc.sendIndexUpdate()
+	waitFor(t, func() bool {
+		errs, err := m.FolderErrors(fcfg.ID)
+		return err == nil && len(errs) == 1 && strings.Contains(errs[0].Err, "broken")
+	})
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
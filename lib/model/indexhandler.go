@@ -434,6 +434,9 @@ func (s *indexHandler) receive(fs []protocol.FileInfo, update bool, op string, p
 		})
 	}
 
+	if err := runner.checkIncomingMassChange(deviceID, fs); err != nil {
+		return err
+	}
 	if err := s.sdb.Update(s.folder, deviceID, fs); err != nil {
 		return err
 	}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/lib/config"
 	"github.com/syncthing/syncthing/lib/model"
 	"github.com/syncthing/syncthing/lib/protocol"
 	"github.com/syncthing/syncthing/lib/stats"
@@ -89,6 +90,18 @@ type Model struct {
 		result1 model.FolderCompletion
 		result2 error
 	}
+	ConfirmMassChangeStub        func(string, bool) error
+	confirmMassChangeMutex       sync.RWMutex
+	confirmMassChangeArgsForCall []struct {
+		arg1 string
+		arg2 bool
+	}
+	confirmMassChangeReturns struct {
+		result1 error
+	}
+	confirmMassChangeReturnsOnCall map[int]struct {
+		result1 error
+	}
 	ConnectedToStub        func(protocol.DeviceID) bool
 	connectedToMutex       sync.RWMutex
 	connectedToArgsForCall []struct {
@@ -385,6 +398,21 @@ type Model struct {
 		result1 db.Counts
 		result2 error
 	}
+	MassChangeStub        func(string) (model.MassChange, bool, error)
+	massChangeMutex       sync.RWMutex
+	massChangeArgsForCall []struct {
+		arg1 string
+	}
+	massChangeReturns struct {
+		result1 model.MassChange
+		result2 bool
+		result3 error
+	}
+	massChangeReturnsOnCall map[int]struct {
+		result1 model.MassChange
+		result2 bool
+		result3 error
+	}
 	NeedFolderFilesStub        func(string, int, int) ([]protocol.FileInfo, []protocol.FileInfo, []protocol.FileInfo, error)
 	needFolderFilesMutex       sync.RWMutex
 	needFolderFilesArgsForCall []struct {
@@ -601,6 +629,19 @@ type Model struct {
 	scanFoldersReturnsOnCall map[int]struct {
 		result1 map[string]error
 	}
+	SeedSummaryStub        func(string) (model.SeedSummary, error)
+	seedSummaryMutex       sync.RWMutex
+	seedSummaryArgsForCall []struct {
+		arg1 string
+	}
+	seedSummaryReturns struct {
+		result1 model.SeedSummary
+		result2 error
+	}
+	seedSummaryReturnsOnCall map[int]struct {
+		result1 model.SeedSummary
+		result2 error
+	}
 	SequenceStub        func(string, protocol.DeviceID) (int64, error)
 	sequenceMutex       sync.RWMutex
 	sequenceArgsForCall []struct {
@@ -638,6 +679,31 @@ type Model struct {
 	setIgnoresReturnsOnCall map[int]struct {
 		result1 error
 	}
+	SetSharedIgnoresStub        func(string, []string) error
+	setSharedIgnoresMutex       sync.RWMutex
+	setSharedIgnoresArgsForCall []struct {
+		arg1 string
+		arg2 []string
+	}
+	setSharedIgnoresReturns struct {
+		result1 error
+	}
+	setSharedIgnoresReturnsOnCall map[int]struct {
+		result1 error
+	}
+	SharedIgnoresStub        func(string) (config.SharedIgnores, error)
+	sharedIgnoresMutex       sync.RWMutex
+	sharedIgnoresArgsForCall []struct {
+		arg1 string
+	}
+	sharedIgnoresReturns struct {
+		result1 config.SharedIgnores
+		result2 error
+	}
+	sharedIgnoresReturnsOnCall map[int]struct {
+		result1 config.SharedIgnores
+		result2 error
+	}
 	StateStub        func(string) (string, time.Time, error)
 	stateMutex       sync.RWMutex
 	stateArgsForCall []struct {
@@ -660,6 +726,21 @@ type Model struct {
 		arg2 int
 		arg3 bool
 	}
+	VerifyFolderStub        func(context.Context, string, int) (model.VerifyReport, error)
+	verifyFolderMutex       sync.RWMutex
+	verifyFolderArgsForCall []struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}
+	verifyFolderReturns struct {
+		result1 model.VerifyReport
+		result2 error
+	}
+	verifyFolderReturnsOnCall map[int]struct {
+		result1 model.VerifyReport
+		result2 error
+	}
 	WatchErrorStub        func(string) error
 	watchErrorMutex       sync.RWMutex
 	watchErrorArgsForCall []struct {
@@ -1031,6 +1112,68 @@ func (fake *Model) CompletionReturnsOnCall(i int, result1 model.FolderCompletion
 	}{result1, result2}
 }
 
+func (fake *Model) ConfirmMassChange(arg1 string, arg2 bool) error {
+	fake.confirmMassChangeMutex.Lock()
+	ret, specificReturn := fake.confirmMassChangeReturnsOnCall[len(fake.confirmMassChangeArgsForCall)]
+	fake.confirmMassChangeArgsForCall = append(fake.confirmMassChangeArgsForCall, struct {
+		arg1 string
+		arg2 bool
+	}{arg1, arg2})
+	stub := fake.ConfirmMassChangeStub
+	fakeReturns := fake.confirmMassChangeReturns
+	fake.recordInvocation("ConfirmMassChange", []interface{}{arg1, arg2})
+	fake.confirmMassChangeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Model) ConfirmMassChangeCallCount() int {
+	fake.confirmMassChangeMutex.RLock()
+	defer fake.confirmMassChangeMutex.RUnlock()
+	return len(fake.confirmMassChangeArgsForCall)
+}
+
+func (fake *Model) ConfirmMassChangeCalls(stub func(string, bool) error) {
+	fake.confirmMassChangeMutex.Lock()
+	defer fake.confirmMassChangeMutex.Unlock()
+	fake.ConfirmMassChangeStub = stub
+}
+
+func (fake *Model) ConfirmMassChangeArgsForCall(i int) (string, bool) {
+	fake.confirmMassChangeMutex.RLock()
+	defer fake.confirmMassChangeMutex.RUnlock()
+	argsForCall := fake.confirmMassChangeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Model) ConfirmMassChangeReturns(result1 error) {
+	fake.confirmMassChangeMutex.Lock()
+	defer fake.confirmMassChangeMutex.Unlock()
+	fake.ConfirmMassChangeStub = nil
+	fake.confirmMassChangeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Model) ConfirmMassChangeReturnsOnCall(i int, result1 error) {
+	fake.confirmMassChangeMutex.Lock()
+	defer fake.confirmMassChangeMutex.Unlock()
+	fake.ConfirmMassChangeStub = nil
+	if fake.confirmMassChangeReturnsOnCall == nil {
+		fake.confirmMassChangeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.confirmMassChangeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *Model) ConnectedTo(arg1 protocol.DeviceID) bool {
 	fake.connectedToMutex.Lock()
 	ret, specificReturn := fake.connectedToReturnsOnCall[len(fake.connectedToArgsForCall)]
@@ -2451,6 +2594,73 @@ func (fake *Model) LocalSizeReturnsOnCall(i int, result1 db.Counts, result2 erro
 	}{result1, result2}
 }
 
+func (fake *Model) MassChange(arg1 string) (model.MassChange, bool, error) {
+	fake.massChangeMutex.Lock()
+	ret, specificReturn := fake.massChangeReturnsOnCall[len(fake.massChangeArgsForCall)]
+	fake.massChangeArgsForCall = append(fake.massChangeArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.MassChangeStub
+	fakeReturns := fake.massChangeReturns
+	fake.recordInvocation("MassChange", []interface{}{arg1})
+	fake.massChangeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *Model) MassChangeCallCount() int {
+	fake.massChangeMutex.RLock()
+	defer fake.massChangeMutex.RUnlock()
+	return len(fake.massChangeArgsForCall)
+}
+
+func (fake *Model) MassChangeCalls(stub func(string) (model.MassChange, bool, error)) {
+	fake.massChangeMutex.Lock()
+	defer fake.massChangeMutex.Unlock()
+	fake.MassChangeStub = stub
+}
+
+func (fake *Model) MassChangeArgsForCall(i int) string {
+	fake.massChangeMutex.RLock()
+	defer fake.massChangeMutex.RUnlock()
+	argsForCall := fake.massChangeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Model) MassChangeReturns(result1 model.MassChange, result2 bool, result3 error) {
+	fake.massChangeMutex.Lock()
+	defer fake.massChangeMutex.Unlock()
+	fake.MassChangeStub = nil
+	fake.massChangeReturns = struct {
+		result1 model.MassChange
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *Model) MassChangeReturnsOnCall(i int, result1 model.MassChange, result2 bool, result3 error) {
+	fake.massChangeMutex.Lock()
+	defer fake.massChangeMutex.Unlock()
+	fake.MassChangeStub = nil
+	if fake.massChangeReturnsOnCall == nil {
+		fake.massChangeReturnsOnCall = make(map[int]struct {
+			result1 model.MassChange
+			result2 bool
+			result3 error
+		})
+	}
+	fake.massChangeReturnsOnCall[i] = struct {
+		result1 model.MassChange
+		result2 bool
+		result3 error
+	}{result1, result2, result3}
+}
+
 func (fake *Model) NeedFolderFiles(arg1 string, arg2 int, arg3 int) ([]protocol.FileInfo, []protocol.FileInfo, []protocol.FileInfo, error) {
 	fake.needFolderFilesMutex.Lock()
 	ret, specificReturn := fake.needFolderFilesReturnsOnCall[len(fake.needFolderFilesArgsForCall)]
@@ -3479,6 +3689,70 @@ func (fake *Model) ScanFoldersReturnsOnCall(i int, result1 map[string]error) {
 	}{result1}
 }
 
+func (fake *Model) SeedSummary(arg1 string) (model.SeedSummary, error) {
+	fake.seedSummaryMutex.Lock()
+	ret, specificReturn := fake.seedSummaryReturnsOnCall[len(fake.seedSummaryArgsForCall)]
+	fake.seedSummaryArgsForCall = append(fake.seedSummaryArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SeedSummaryStub
+	fakeReturns := fake.seedSummaryReturns
+	fake.recordInvocation("SeedSummary", []interface{}{arg1})
+	fake.seedSummaryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Model) SeedSummaryCallCount() int {
+	fake.seedSummaryMutex.RLock()
+	defer fake.seedSummaryMutex.RUnlock()
+	return len(fake.seedSummaryArgsForCall)
+}
+
+func (fake *Model) SeedSummaryCalls(stub func(string) (model.SeedSummary, error)) {
+	fake.seedSummaryMutex.Lock()
+	defer fake.seedSummaryMutex.Unlock()
+	fake.SeedSummaryStub = stub
+}
+
+func (fake *Model) SeedSummaryArgsForCall(i int) string {
+	fake.seedSummaryMutex.RLock()
+	defer fake.seedSummaryMutex.RUnlock()
+	argsForCall := fake.seedSummaryArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Model) SeedSummaryReturns(result1 model.SeedSummary, result2 error) {
+	fake.seedSummaryMutex.Lock()
+	defer fake.seedSummaryMutex.Unlock()
+	fake.SeedSummaryStub = nil
+	fake.seedSummaryReturns = struct {
+		result1 model.SeedSummary
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Model) SeedSummaryReturnsOnCall(i int, result1 model.SeedSummary, result2 error) {
+	fake.seedSummaryMutex.Lock()
+	defer fake.seedSummaryMutex.Unlock()
+	fake.SeedSummaryStub = nil
+	if fake.seedSummaryReturnsOnCall == nil {
+		fake.seedSummaryReturnsOnCall = make(map[int]struct {
+			result1 model.SeedSummary
+			result2 error
+		})
+	}
+	fake.seedSummaryReturnsOnCall[i] = struct {
+		result1 model.SeedSummary
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *Model) Sequence(arg1 string, arg2 protocol.DeviceID) (int64, error) {
 	fake.sequenceMutex.Lock()
 	ret, specificReturn := fake.sequenceReturnsOnCall[len(fake.sequenceArgsForCall)]
@@ -3672,6 +3946,137 @@ func (fake *Model) SetIgnoresReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *Model) SetSharedIgnores(arg1 string, arg2 []string) error {
+	var arg2Copy []string
+	if arg2 != nil {
+		arg2Copy = make([]string, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.setSharedIgnoresMutex.Lock()
+	ret, specificReturn := fake.setSharedIgnoresReturnsOnCall[len(fake.setSharedIgnoresArgsForCall)]
+	fake.setSharedIgnoresArgsForCall = append(fake.setSharedIgnoresArgsForCall, struct {
+		arg1 string
+		arg2 []string
+	}{arg1, arg2Copy})
+	stub := fake.SetSharedIgnoresStub
+	fakeReturns := fake.setSharedIgnoresReturns
+	fake.recordInvocation("SetSharedIgnores", []interface{}{arg1, arg2Copy})
+	fake.setSharedIgnoresMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Model) SetSharedIgnoresCallCount() int {
+	fake.setSharedIgnoresMutex.RLock()
+	defer fake.setSharedIgnoresMutex.RUnlock()
+	return len(fake.setSharedIgnoresArgsForCall)
+}
+
+func (fake *Model) SetSharedIgnoresCalls(stub func(string, []string) error) {
+	fake.setSharedIgnoresMutex.Lock()
+	defer fake.setSharedIgnoresMutex.Unlock()
+	fake.SetSharedIgnoresStub = stub
+}
+
+func (fake *Model) SetSharedIgnoresArgsForCall(i int) (string, []string) {
+	fake.setSharedIgnoresMutex.RLock()
+	defer fake.setSharedIgnoresMutex.RUnlock()
+	argsForCall := fake.setSharedIgnoresArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Model) SetSharedIgnoresReturns(result1 error) {
+	fake.setSharedIgnoresMutex.Lock()
+	defer fake.setSharedIgnoresMutex.Unlock()
+	fake.SetSharedIgnoresStub = nil
+	fake.setSharedIgnoresReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Model) SetSharedIgnoresReturnsOnCall(i int, result1 error) {
+	fake.setSharedIgnoresMutex.Lock()
+	defer fake.setSharedIgnoresMutex.Unlock()
+	fake.SetSharedIgnoresStub = nil
+	if fake.setSharedIgnoresReturnsOnCall == nil {
+		fake.setSharedIgnoresReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setSharedIgnoresReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Model) SharedIgnores(arg1 string) (config.SharedIgnores, error) {
+	fake.sharedIgnoresMutex.Lock()
+	ret, specificReturn := fake.sharedIgnoresReturnsOnCall[len(fake.sharedIgnoresArgsForCall)]
+	fake.sharedIgnoresArgsForCall = append(fake.sharedIgnoresArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.SharedIgnoresStub
+	fakeReturns := fake.sharedIgnoresReturns
+	fake.recordInvocation("SharedIgnores", []interface{}{arg1})
+	fake.sharedIgnoresMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Model) SharedIgnoresCallCount() int {
+	fake.sharedIgnoresMutex.RLock()
+	defer fake.sharedIgnoresMutex.RUnlock()
+	return len(fake.sharedIgnoresArgsForCall)
+}
+
+func (fake *Model) SharedIgnoresCalls(stub func(string) (config.SharedIgnores, error)) {
+	fake.sharedIgnoresMutex.Lock()
+	defer fake.sharedIgnoresMutex.Unlock()
+	fake.SharedIgnoresStub = stub
+}
+
+func (fake *Model) SharedIgnoresArgsForCall(i int) string {
+	fake.sharedIgnoresMutex.RLock()
+	defer fake.sharedIgnoresMutex.RUnlock()
+	argsForCall := fake.sharedIgnoresArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *Model) SharedIgnoresReturns(result1 config.SharedIgnores, result2 error) {
+	fake.sharedIgnoresMutex.Lock()
+	defer fake.sharedIgnoresMutex.Unlock()
+	fake.SharedIgnoresStub = nil
+	fake.sharedIgnoresReturns = struct {
+		result1 config.SharedIgnores
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Model) SharedIgnoresReturnsOnCall(i int, result1 config.SharedIgnores, result2 error) {
+	fake.sharedIgnoresMutex.Lock()
+	defer fake.sharedIgnoresMutex.Unlock()
+	fake.SharedIgnoresStub = nil
+	if fake.sharedIgnoresReturnsOnCall == nil {
+		fake.sharedIgnoresReturnsOnCall = make(map[int]struct {
+			result1 config.SharedIgnores
+			result2 error
+		})
+	}
+	fake.sharedIgnoresReturnsOnCall[i] = struct {
+		result1 config.SharedIgnores
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *Model) State(arg1 string) (string, time.Time, error) {
 	fake.stateMutex.Lock()
 	ret, specificReturn := fake.stateReturnsOnCall[len(fake.stateArgsForCall)]
@@ -3773,6 +4178,72 @@ func (fake *Model) UsageReportingStatsArgsForCall(i int) (*contract.Report, int,
 	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
+func (fake *Model) VerifyFolder(arg1 context.Context, arg2 string, arg3 int) (model.VerifyReport, error) {
+	fake.verifyFolderMutex.Lock()
+	ret, specificReturn := fake.verifyFolderReturnsOnCall[len(fake.verifyFolderArgsForCall)]
+	fake.verifyFolderArgsForCall = append(fake.verifyFolderArgsForCall, struct {
+		arg1 context.Context
+		arg2 string
+		arg3 int
+	}{arg1, arg2, arg3})
+	stub := fake.VerifyFolderStub
+	fakeReturns := fake.verifyFolderReturns
+	fake.recordInvocation("VerifyFolder", []interface{}{arg1, arg2, arg3})
+	fake.verifyFolderMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Model) VerifyFolderCallCount() int {
+	fake.verifyFolderMutex.RLock()
+	defer fake.verifyFolderMutex.RUnlock()
+	return len(fake.verifyFolderArgsForCall)
+}
+
+func (fake *Model) VerifyFolderCalls(stub func(context.Context, string, int) (model.VerifyReport, error)) {
+	fake.verifyFolderMutex.Lock()
+	defer fake.verifyFolderMutex.Unlock()
+	fake.VerifyFolderStub = stub
+}
+
+func (fake *Model) VerifyFolderArgsForCall(i int) (context.Context, string, int) {
+	fake.verifyFolderMutex.RLock()
+	defer fake.verifyFolderMutex.RUnlock()
+	argsForCall := fake.verifyFolderArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *Model) VerifyFolderReturns(result1 model.VerifyReport, result2 error) {
+	fake.verifyFolderMutex.Lock()
+	defer fake.verifyFolderMutex.Unlock()
+	fake.VerifyFolderStub = nil
+	fake.verifyFolderReturns = struct {
+		result1 model.VerifyReport
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *Model) VerifyFolderReturnsOnCall(i int, result1 model.VerifyReport, result2 error) {
+	fake.verifyFolderMutex.Lock()
+	defer fake.verifyFolderMutex.Unlock()
+	fake.VerifyFolderStub = nil
+	if fake.verifyFolderReturnsOnCall == nil {
+		fake.verifyFolderReturnsOnCall = make(map[int]struct {
+			result1 model.VerifyReport
+			result2 error
+		})
+	}
+	fake.verifyFolderReturnsOnCall[i] = struct {
+		result1 model.VerifyReport
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *Model) WatchError(arg1 string) error {
 	fake.watchErrorMutex.Lock()
 	ret, specificReturn := fake.watchErrorReturnsOnCall[len(fake.watchErrorArgsForCall)]
@@ -64,8 +64,13 @@ type service interface {
 	WatchError() error
 	ScheduleForceRescan(path string)
 	GetStatistics() (stats.FolderStatistics, error)
+	MassChange() (MassChange, bool)
+	SeedSummary() (SeedSummary, error)
+	ConfirmMassChange(revert bool) error
 
 	getState() (folderState, time.Time, error)
+	setHookError(err error)
+	checkIncomingMassChange(device protocol.DeviceID, fs []protocol.FileInfo) error
 }
 
 type Availability struct {
@@ -88,10 +93,16 @@ type Model interface {
 	WatchError(folder string) error
 	Override(folder string)
 	Revert(folder string)
+	MassChange(folder string) (MassChange, bool, error)
+	ConfirmMassChange(folder string, revert bool) error
+	VerifyFolder(ctx context.Context, folder string, spotChecks int) (VerifyReport, error)
+	SeedSummary(folder string) (SeedSummary, error)
 	BringToFront(folder, file string)
 	LoadIgnores(folder string) ([]string, []string, error)
 	CurrentIgnores(folder string) ([]string, []string, error)
 	SetIgnores(folder string, content []string) error
+	SharedIgnores(folder string) (config.SharedIgnores, error)
+	SetSharedIgnores(folder string, lines []string) error
 
 	GetFolderVersions(folder string) (map[string][]versioner.FileVersion, error)
 	RestoreFolderVersions(folder string, versions map[string]time.Time) (map[string]error, error)
@@ -175,6 +186,7 @@ type model struct {
 	helloMessages                  map[protocol.DeviceID]protocol.Hello
 	deviceDownloads                map[protocol.DeviceID]*deviceDownloadState
 	remoteFolderStates             map[protocol.DeviceID]map[string]remoteFolderState // deviceID -> folders
+	remoteMaxSequences             map[protocol.DeviceID]map[string]int64             // deviceID -> folder -> announced sequence
 	indexHandlers                  *serviceMap[protocol.DeviceID, *indexHandlerRegistry]
 
 	// for testing only
@@ -251,6 +263,7 @@ func NewModel(cfg config.Wrapper, id protocol.DeviceID, sdb db.DB, protectedFile
 		helloMessages:                  make(map[protocol.DeviceID]protocol.Hello),
 		deviceDownloads:                make(map[protocol.DeviceID]*deviceDownloadState),
 		remoteFolderStates:             make(map[protocol.DeviceID]map[string]remoteFolderState),
+		remoteMaxSequences:             make(map[protocol.DeviceID]map[string]int64),
 		indexHandlers:                  newServiceMap[protocol.DeviceID, *indexHandlerRegistry](evLogger),
 	}
 	for devID, cfg := range cfg.Devices() {
@@ -260,6 +273,7 @@ func NewModel(cfg config.Wrapper, id protocol.DeviceID, sdb db.DB, protectedFile
 	m.Add(m.folderRunners)
 	m.Add(m.progressEmitter)
 	m.Add(m.indexHandlers)
+	m.Add(newHookService(m))
 	m.Add(svcutil.AsService(m.serve, m.String()))
 
 	return m
@@ -341,6 +355,11 @@ func (m *model) addAndStartFolderLocked(cfg config.FolderConfiguration, cacheIgn
 		if err := ignores.Load(".stignore"); err != nil && !fs.IsNotExist(err) {
 			slog.Error("Failed to load ignores", slogutil.Error(err))
 		}
+		if cfg.ShareIgnores {
+			if err := ignores.SetShared(cfg.SharedIgnores.Lines); err != nil {
+				slog.Error("Failed to apply shared ignore patterns", cfg.LogAttr(), slogutil.Error(err))
+			}
+		}
 	}
 
 	m.addAndStartFolderLockedWithIgnores(cfg, ignores)
@@ -1294,8 +1313,14 @@ func (m *model) ClusterConfig(conn protocol.Connection, cm *protocol.ClusterConf
 		return err
 	}
 
+	maxSequences := make(map[string]int64, len(ccDeviceInfos))
+	for folder, info := range ccDeviceInfos {
+		maxSequences[folder] = info.remote.MaxSequence
+	}
+
 	m.mut.Lock()
 	m.remoteFolderStates[deviceID] = states
+	m.remoteMaxSequences[deviceID] = maxSequences
 	m.mut.Unlock()
 
 	m.evLogger.Log(events.ClusterConfigReceived, ClusterConfigReceivedEventData{
@@ -1317,6 +1342,8 @@ func (m *model) ClusterConfig(conn protocol.Connection, cm *protocol.ClusterConf
 		}
 	}
 
+	m.handleSharedIgnores(deviceID, cm.Folders)
+
 	if deviceCfg.Introducer {
 		m.cfg.Modify(func(cfg *config.Configuration) {
 			folders, devices, foldersDevices, introduced := m.handleIntroductions(deviceCfg, cm, cfg.FolderMap(), cfg.DeviceMap())
@@ -1902,6 +1929,7 @@ func (m *model) Closed(conn protocol.Connection, err error) {
 		delete(m.connRequestLimiters, deviceID)
 		delete(m.helloMessages, deviceID)
 		delete(m.remoteFolderStates, deviceID)
+		delete(m.remoteMaxSequences, deviceID)
 		delete(m.deviceDownloads, deviceID)
 	} else {
 		// Some connections remain
@@ -2615,6 +2643,7 @@ func (m *model) generateClusterConfigRLocked(device protocol.DeviceID) (*protoco
 		if folderCfg.Paused {
 			protocolFolder.StopReason = protocol.FolderStopReasonPaused
 		}
+		protocolFolder.SharedIgnores = sharedIgnoresToProtocol(folderCfg, device)
 
 	nextDevice:
 		for _, folderDevice := range folderCfg.Devices {
@@ -2729,6 +2758,40 @@ func (m *model) Revert(folder string) {
 	runner.Revert()
 }
 
+func (m *model) MassChange(folder string) (MassChange, bool, error) {
+	m.mut.RLock()
+	err := m.checkFolderRunningRLocked(folder)
+	runner, _ := m.folderRunners.Get(folder)
+	m.mut.RUnlock()
+	if err != nil {
+		return MassChange{}, false, err
+	}
+	mc, ok := runner.MassChange()
+	return mc, ok, nil
+}
+
+func (m *model) SeedSummary(folder string) (SeedSummary, error) {
+	m.mut.RLock()
+	err := m.checkFolderRunningRLocked(folder)
+	runner, _ := m.folderRunners.Get(folder)
+	m.mut.RUnlock()
+	if err != nil {
+		return SeedSummary{}, err
+	}
+	return runner.SeedSummary()
+}
+
+func (m *model) ConfirmMassChange(folder string, revert bool) error {
+	m.mut.RLock()
+	err := m.checkFolderRunningRLocked(folder)
+	runner, _ := m.folderRunners.Get(folder)
+	m.mut.RUnlock()
+	if err != nil {
+		return err
+	}
+	return runner.ConfirmMassChange(revert)
+}
+
 type TreeEntry struct {
 	Name     string       `json:"name"`
 	ModTime  time.Time    `json:"modTime"`
@@ -0,0 +1,49 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+	"github.com/syncthing/syncthing/lib/ignore"
+)
+
+// pullPriorities gives the priority of a file to pull according to the
+// rules of the folder, the first matching rule deciding.
+type pullPriorities []pullPriorityRule
+
+type pullPriorityRule struct {
+	matcher  *ignore.Matcher
+	priority int
+}
+
+// newPullPriorities compiles the rules, returning an error for each
+// pattern that can't be parsed. Such rules are left out.
+func newPullPriorities(filesystem fs.Filesystem, rules []config.PullPriority) (pullPriorities, []error) {
+	var prios pullPriorities
+	var errs []error
+	for _, rule := range rules {
+		matcher := ignore.New(filesystem)
+		if err := matcher.Parse(strings.NewReader(rule.Pattern), ""); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		prios = append(prios, pullPriorityRule{matcher: matcher, priority: rule.Priority})
+	}
+	return prios, errs
+}
+
+func (p pullPriorities) priority(name string) int {
+	for _, rule := range p {
+		if rule.matcher.Match(name).IsIgnored() {
+			return rule.priority
+		}
+	}
+	return 0
+}
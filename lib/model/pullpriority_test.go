@@ -0,0 +1,39 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/fs"
+)
+
+func TestPullPriorities(t *testing.T) {
+	ffs := fs.NewFilesystem(fs.FilesystemTypeFake, t.Name())
+	prios, errs := newPullPriorities(ffs, []config.PullPriority{
+		{Pattern: "/raw/**", Priority: -1},
+		{Pattern: "*.pdf", Priority: 10},
+		{Pattern: "[", Priority: 5},
+	})
+	if len(errs) != 1 || len(prios) != 2 {
+		t.Fatalf("expected one invalid pattern, got %v", errs)
+	}
+
+	for name, prio := range map[string]int{
+		"doc.pdf":         10,
+		"sub/doc.pdf":     10,
+		"raw/doc.pdf":     -1, // the first matching rule decides
+		"raw/sub/img.cr2": -1,
+		"sub/raw/img.cr2": 0,
+		"img.jpg":         0,
+	} {
+		if p := prios.priority(name); p != prio {
+			t.Errorf("priority of %q is %d, expected %d", name, p, prio)
+		}
+	}
+}
@@ -7,6 +7,8 @@
 package model
 
 import (
+	"cmp"
+	"slices"
 	"sync"
 	"time"
 )
@@ -21,19 +23,30 @@ type jobQueueEntry struct {
 	name     string
 	size     int64
 	modified int64
+	priority int
 }
 
 func newJobQueue() *jobQueue {
 	return &jobQueue{}
 }
 
-func (q *jobQueue) Push(file string, size int64, modified time.Time) {
+func (q *jobQueue) Push(file string, size int64, modified time.Time, priority int) {
 	q.mut.Lock()
 	// The range of UnixNano covers a range of reasonable timestamps.
-	q.queued = append(q.queued, jobQueueEntry{file, size, modified.UnixNano()})
+	q.queued = append(q.queued, jobQueueEntry{file, size, modified.UnixNano(), priority})
 	q.mut.Unlock()
 }
 
+// SortPriority orders the queue by priority, highest first, keeping the
+// order in which files of the same priority were pushed.
+func (q *jobQueue) SortPriority() {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	slices.SortStableFunc(q.queued, func(a, b jobQueueEntry) int {
+		return cmp.Compare(b.priority, a.priority)
+	})
+}
+
 func (q *jobQueue) Pop() (string, bool) {
 	q.mut.Lock()
 	defer q.mut.Unlock()
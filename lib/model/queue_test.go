@@ -19,10 +19,10 @@ import (
 func TestJobQueue(t *testing.T) {
 	// Some random actions
 	q := newJobQueue()
-	q.Push("f1", 0, time.Time{})
-	q.Push("f2", 0, time.Time{})
-	q.Push("f3", 0, time.Time{})
-	q.Push("f4", 0, time.Time{})
+	q.Push("f1", 0, time.Time{}, 0)
+	q.Push("f2", 0, time.Time{}, 0)
+	q.Push("f3", 0, time.Time{}, 0)
+	q.Push("f4", 0, time.Time{}, 0)
 
 	progress, queued, _ := q.Jobs(1, 100)
 	if len(progress) != 0 || len(queued) != 4 {
@@ -47,7 +47,7 @@ func TestJobQueue(t *testing.T) {
 			t.Fatal("Wrong length", len(progress), len(queued))
 		}
 
-		q.Push(n, 0, time.Time{})
+		q.Push(n, 0, time.Time{}, 0)
 		progress, queued, _ = q.Jobs(1, 100)
 		if len(progress) != 0 || len(queued) != 4 {
 			t.Fatal("Wrong length")
@@ -124,10 +124,10 @@ func TestJobQueue(t *testing.T) {
 
 func TestBringToFront(t *testing.T) {
 	q := newJobQueue()
-	q.Push("f1", 0, time.Time{})
-	q.Push("f2", 0, time.Time{})
-	q.Push("f3", 0, time.Time{})
-	q.Push("f4", 0, time.Time{})
+	q.Push("f1", 0, time.Time{}, 0)
+	q.Push("f2", 0, time.Time{}, 0)
+	q.Push("f3", 0, time.Time{}, 0)
+	q.Push("f4", 0, time.Time{}, 0)
 
 	_, queued, _ := q.Jobs(1, 100)
 	if diff, equal := messagediff.PrettyDiff([]string{"f1", "f2", "f3", "f4"}, queued); !equal {
@@ -163,12 +163,28 @@ func TestBringToFront(t *testing.T) {
 	}
 }
 
+func TestSortPriority(t *testing.T) {
+	q := newJobQueue()
+	q.Push("f1", 0, time.Time{}, 0)
+	q.Push("f2", 0, time.Time{}, -1)
+	q.Push("f3", 0, time.Time{}, 1)
+	q.Push("f4", 0, time.Time{}, 0)
+	q.Push("f5", 0, time.Time{}, 1)
+
+	q.SortPriority()
+
+	_, queued, _ := q.Jobs(1, 100)
+	if diff, equal := messagediff.PrettyDiff([]string{"f3", "f5", "f1", "f4", "f2"}, queued); !equal {
+		t.Errorf("Order does not match. Diff:\n%s", diff)
+	}
+}
+
 func BenchmarkJobQueueBump(b *testing.B) {
 	files := genFiles(10000)
 
 	q := newJobQueue()
 	for _, f := range files {
-		q.Push(f.Name, 0, time.Time{})
+		q.Push(f.Name, 0, time.Time{}, 0)
 	}
 
 	rng := rand.New(rand.NewSource(int64(b.N)))
@@ -187,7 +203,7 @@ func BenchmarkJobQueuePushPopDone10k(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		q := newJobQueue()
 		for _, f := range files {
-			q.Push(f.Name, 0, time.Time{})
+			q.Push(f.Name, 0, time.Time{}, 0)
 		}
 		for range files {
 			n, _ := q.Pop()
@@ -202,7 +218,7 @@ func TestQueuePagination(t *testing.T) {
 	names := make([]string, 10)
 	for i := 0; i < 10; i++ {
 		names[i] = fmt.Sprint("f", i)
-		q.Push(names[i], 0, time.Time{})
+		q.Push(names[i], 0, time.Time{}, 0)
 	}
 
 	progress, queued, skip := q.Jobs(1, 100)
@@ -0,0 +1,154 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"errors"
+	"log/slog"
+	"slices"
+
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/ignore"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+var errSharedIgnoresDisabled = errors.New("shared ignores are not enabled for the folder")
+
+// SharedIgnores returns the shared ignore patterns of the folder.
+func (m *model) SharedIgnores(folder string) (config.SharedIgnores, error) {
+	cfg, ok := m.cfg.Folder(folder)
+	if !ok {
+		return config.SharedIgnores{}, ErrFolderMissing
+	}
+	if !cfg.ShareIgnores {
+		return config.SharedIgnores{}, errSharedIgnoresDisabled
+	}
+	return cfg.SharedIgnores, nil
+}
+
+// SetSharedIgnores replaces the shared ignore patterns of the folder with a
+// new version, to be adopted by the other devices that accept it.
+func (m *model) SetSharedIgnores(folder string, lines []string) error {
+	cfg, ok := m.cfg.Folder(folder)
+	if !ok {
+		return ErrFolderMissing
+	}
+	if !cfg.ShareIgnores {
+		return errSharedIgnoresDisabled
+	}
+	if err := ignore.New(cfg.Filesystem()).SetShared(lines); err != nil {
+		return err
+	}
+
+	var from, to config.SharedIgnores
+	w, err := m.cfg.Modify(func(c *config.Configuration) {
+		for i := range c.Folders {
+			if c.Folders[i].ID != folder {
+				continue
+			}
+			from = c.Folders[i].SharedIgnores
+			to = config.SharedIgnores{
+				Lines:      slices.Clone(lines),
+				Version:    from.Version + 1,
+				ModifiedBy: m.id,
+			}
+			c.Folders[i].SharedIgnores = to
+		}
+	})
+	if err != nil {
+		return err
+	}
+	w.Wait()
+
+	m.logSharedIgnoresChanged(cfg, m.id, from, to)
+	return nil
+}
+
+// handleSharedIgnores adopts the shared ignore patterns announced by the
+// device, for the folders where they are newer than ours and we accept
+// them.
+func (m *model) handleSharedIgnores(deviceID protocol.DeviceID, folders []protocol.Folder) {
+	announced := make(map[string]protocol.SharedIgnores)
+	for _, folder := range folders {
+		if cfg, ok := m.cfg.Folder(folder.ID); ok && acceptsSharedIgnores(cfg, deviceID, folder.SharedIgnores) {
+			announced[folder.ID] = folder.SharedIgnores
+		}
+	}
+	if len(announced) == 0 {
+		return
+	}
+
+	type change struct {
+		cfg      config.FolderConfiguration
+		from, to config.SharedIgnores
+	}
+	var changes []change
+	m.cfg.Modify(func(c *config.Configuration) {
+		for i := range c.Folders {
+			cfg := &c.Folders[i]
+			remote, ok := announced[cfg.ID]
+			if !ok || !acceptsSharedIgnores(*cfg, deviceID, remote) {
+				continue
+			}
+			if err := ignore.New(cfg.Filesystem()).SetShared(remote.Lines); err != nil {
+				slog.Warn("Not adopting invalid shared ignore patterns", cfg.LogAttr(), deviceID.LogAttr(), slogutil.Error(err))
+				continue
+			}
+			ch := change{cfg: *cfg, from: cfg.SharedIgnores, to: config.SharedIgnores{
+				Lines:      slices.Clone(remote.Lines),
+				Version:    remote.Version,
+				ModifiedBy: remote.ModifiedBy,
+			}}
+			cfg.SharedIgnores = ch.to
+			changes = append(changes, ch)
+		}
+	})
+	for _, ch := range changes {
+		m.logSharedIgnoresChanged(ch.cfg, deviceID, ch.from, ch.to)
+	}
+}
+
+// acceptsSharedIgnores returns whether the folder takes the shared ignores
+// announced by the device.
+func acceptsSharedIgnores(cfg config.FolderConfiguration, deviceID protocol.DeviceID, remote protocol.SharedIgnores) bool {
+	if !cfg.ShareIgnores || !cfg.AcceptSharedIgnores || cfg.Type == config.FolderTypeReceiveEncrypted {
+		return false
+	}
+	// Untrusted devices don't get to see our file names, much less decide
+	// what we ignore.
+	if dev, ok := cfg.Device(deviceID); !ok || dev.EncryptionPassword != "" {
+		return false
+	}
+	return remote.NewerThan(cfg.SharedIgnores.ToProtocol())
+}
+
+func (m *model) logSharedIgnoresChanged(cfg config.FolderConfiguration, source protocol.DeviceID, from, to config.SharedIgnores) {
+	slog.Info("Shared ignore patterns changed", cfg.LogAttr(), slog.String("source", source.String()), slog.Uint64("version", to.Version), slog.String("modifiedBy", to.ModifiedBy.String()))
+	m.evLogger.Log(events.SharedIgnoresChanged, map[string]any{
+		"folder":          cfg.ID,
+		"source":          source.String(),
+		"version":         to.Version,
+		"modifiedBy":      to.ModifiedBy.String(),
+		"lines":           to.Lines,
+		"previousVersion": from.Version,
+		"previousLines":   from.Lines,
+	})
+}
+
+// sharedIgnoresToProtocol returns the shared ignores of the folder to
+// announce to the device, if any.
+func sharedIgnoresToProtocol(cfg config.FolderConfiguration, device protocol.DeviceID) protocol.SharedIgnores {
+	if !cfg.ShareIgnores || cfg.SharedIgnores.Version == 0 || cfg.Type == config.FolderTypeReceiveEncrypted {
+		return protocol.SharedIgnores{}
+	}
+	if dev, ok := cfg.Device(device); !ok || dev.EncryptionPassword != "" {
+		return protocol.SharedIgnores{}
+	}
+	return cfg.SharedIgnores.ToProtocol()
+}
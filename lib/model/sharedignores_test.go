@@ -0,0 +1,75 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestSharedIgnores(t *testing.T) {
+	w, fcfg := newDefaultCfgWrapper(t)
+	fcfg.ShareIgnores = true
+	setFolder(t, w, fcfg)
+	m, fc := setupModelWithConnectionFromWrapper(t, w)
+	defer cleanupModel(m)
+	sub := m.evLogger.Subscribe(events.SharedIgnoresChanged)
+	defer sub.Unsubscribe()
+
+	sendShared := func(lines []string, version uint64) {
+		t.Helper()
+		cc := basicClusterConfig(myID, device1, fcfg.ID)
+		cc.Folders[0].SharedIgnores = protocol.SharedIgnores{Lines: lines, Version: version, ModifiedBy: device1}
+		must(t, m.ClusterConfig(fc, cc))
+	}
+	sharedVersion := func() uint64 {
+		cfg, _ := w.Folder(fcfg.ID)
+		return cfg.SharedIgnores.Version
+	}
+
+	// Remote patterns aren't trusted by default.
+	sendShared([]string{"remote"}, 2)
+	if v := sharedVersion(); v != 0 {
+		t.Fatalf("adopted untrusted shared ignores, version %d", v)
+	}
+
+	fcfg.AcceptSharedIgnores = true
+	setFolder(t, w, fcfg)
+	sendShared([]string{"remote"}, 2)
+	waitFor(t, func() bool {
+		_, patterns, _ := m.CurrentIgnores(fcfg.ID)
+		return slices.Contains(patterns, "remote")
+	})
+	if v := sharedVersion(); v != 2 {
+		t.Fatalf("expected version 2, got %d", v)
+	}
+	ev, err := sub.Poll(time.Second)
+	must(t, err)
+	if data := ev.Data.(map[string]any); data["source"] != device1.String() || data["version"] != uint64(2) {
+		t.Errorf("unexpected event data %v", data)
+	}
+
+	// An older version is ignored.
+	sendShared([]string{"older"}, 1)
+	if v := sharedVersion(); v != 2 {
+		t.Fatalf("expected version 2, got %d", v)
+	}
+
+	// Local changes get a new version and are announced.
+	must(t, m.SetSharedIgnores(fcfg.ID, []string{"local"}))
+	cc, _ := m.generateClusterConfig(device1)
+	if shared := cc.Folders[0].SharedIgnores; shared.Version != 3 || shared.ModifiedBy != myID || !slices.Equal(shared.Lines, []string{"local"}) {
+		t.Errorf("unexpected announced shared ignores %+v", shared)
+	}
+	if err := m.SetSharedIgnores(fcfg.ID, []string{"#include other"}); err == nil {
+		t.Error("expected an error for invalid patterns")
+	}
+}
@@ -194,6 +194,12 @@ func (m *testModel) testCompletion(device protocol.DeviceID, folder string) Fold
 	return comp
 }
 
+func (m *testModel) testMassChange(folder string) (MassChange, bool) {
+	mc, ok, err := m.MassChange(folder)
+	must(m.t, err)
+	return mc, ok
+}
+
 func cleanupModel(m *testModel) {
 	if m.cancel != nil {
 		m.cancel()
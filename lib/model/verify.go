@@ -0,0 +1,260 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/itererr"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+// At most this many files are listed per kind of difference and device;
+// the counts are always complete.
+const verifyMaxListed = 1000
+
+// A VerifyReport compares the files we have in a folder with those each of
+// the other devices sharing it has announced.
+type VerifyReport struct {
+	Folder  string         `json:"folder"`
+	Time    time.Time      `json:"time"`
+	Files   int            `json:"files"` // present here
+	Devices []VerifyDevice `json:"devices"`
+}
+
+type VerifyDevice struct {
+	Device  protocol.DeviceID `json:"device"`
+	Skipped string            `json:"skipped,omitempty"` // why the device wasn't compared
+
+	Identical      int                `json:"identical"`
+	Divergent      int                `json:"divergent"` // present on both with different contents
+	Missing        int                `json:"missing"`   // present here but not on the device
+	Extra          int                `json:"extra"`     // present on the device but not here
+	DivergentFiles []VerifyDifference `json:"divergentFiles"`
+	MissingFiles   []string           `json:"missingFiles"`
+	ExtraFiles     []string           `json:"extraFiles"`
+
+	// Blocks of identical files requested from the device and compared
+	// with their hash.
+	SpotChecked       int                `json:"spotChecked"`
+	SpotCheckSkipped  string             `json:"spotCheckSkipped,omitempty"`
+	SpotCheckFailures []VerifyDifference `json:"spotCheckFailures"`
+}
+
+type VerifyDifference struct {
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// verifyEntry is what is compared of each file.
+type verifyEntry struct {
+	typ        protocol.FileInfoType
+	size       int64
+	blocksHash []byte
+	symlink    []byte
+	version    protocol.Vector
+}
+
+func newVerifyEntry(fi protocol.FileInfo) verifyEntry {
+	return verifyEntry{
+		typ:        fi.Type,
+		size:       fi.Size,
+		blocksHash: fi.BlocksHash,
+		symlink:    fi.SymlinkTarget,
+		version:    fi.Version,
+	}
+}
+
+// verifyPresent returns whether the file exists on the device, as far as
+// its index tells.
+func verifyPresent(fi protocol.FileInfo) bool {
+	return !fi.IsDeleted() && (!fi.IsInvalid() || fi.IsReceiveOnlyChanged())
+}
+
+// difference returns how the remote entry differs from ours, or the empty
+// string if they hold the same data.
+func (e verifyEntry) difference(remote verifyEntry) string {
+	var what string
+	switch {
+	case e.typ != remote.typ:
+		what = "type"
+	case e.typ == protocol.FileInfoTypeSymlink && !bytes.Equal(e.symlink, remote.symlink):
+		what = "symlink target"
+	case e.typ != protocol.FileInfoTypeFile:
+		return ""
+	case e.size != remote.size:
+		what = "size"
+	case !bytes.Equal(e.blocksHash, remote.blocksHash):
+		what = "contents"
+	default:
+		return ""
+	}
+	switch remote.version.Compare(e.version) {
+	case protocol.Equal:
+		return what + " differs with the same version"
+	case protocol.Greater:
+		return what + " differs, device has a newer version"
+	case protocol.Lesser:
+		return what + " differs, device has an older version"
+	default:
+		return what + " differs, versions are in conflict"
+	}
+}
+
+// VerifyFolder compares our files with the index of each other device
+// sharing the folder and, for up to spotChecks identical files per
+// connected device, requests a random block and checks its hash.
+func (m *model) VerifyFolder(ctx context.Context, folder string, spotChecks int) (VerifyReport, error) {
+	m.mut.RLock()
+	err := m.checkFolderRunningRLocked(folder)
+	cfg := m.folderCfgs[folder]
+	m.mut.RUnlock()
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	if cfg.Type == config.FolderTypeReceiveEncrypted {
+		return VerifyReport{}, fmt.Errorf("folder %q holds encrypted data and can't be verified", folder)
+	}
+
+	local := make(map[string]verifyEntry)
+	for fi, err := range itererr.Zip(m.sdb.AllLocalFiles(folder, protocol.LocalDeviceID)) {
+		if err != nil {
+			return VerifyReport{}, err
+		}
+		if verifyPresent(fi) {
+			local[fi.Name] = newVerifyEntry(fi)
+		}
+	}
+
+	report := VerifyReport{
+		Folder: folder,
+		Time:   time.Now().Truncate(time.Second),
+		Files:  len(local),
+	}
+	for _, dev := range cfg.Devices {
+		if dev.DeviceID == m.id {
+			continue
+		}
+		vd, err := m.verifyDevice(ctx, folder, dev, local, spotChecks)
+		if err != nil {
+			return VerifyReport{}, err
+		}
+		report.Devices = append(report.Devices, vd)
+	}
+	return report, nil
+}
+
+func (m *model) verifyDevice(ctx context.Context, folder string, dev config.FolderDeviceConfiguration, local map[string]verifyEntry, spotChecks int) (VerifyDevice, error) {
+	vd := VerifyDevice{
+		Device:            dev.DeviceID,
+		DivergentFiles:    []VerifyDifference{},
+		MissingFiles:      []string{},
+		ExtraFiles:        []string{},
+		SpotCheckFailures: []VerifyDifference{},
+	}
+	if dev.EncryptionPassword != "" {
+		vd.Skipped = "device only has encrypted data"
+		return vd, nil
+	}
+
+	seen := make(map[string]struct{}, len(local))
+	var candidates []string // identical regular files, sampled for spot checks
+	for fi, err := range itererr.Zip(m.sdb.AllLocalFiles(folder, dev.DeviceID)) {
+		if err != nil {
+			return VerifyDevice{}, err
+		}
+		if !verifyPresent(fi) {
+			continue
+		}
+		ours, ok := local[fi.Name]
+		if !ok {
+			vd.Extra++
+			if len(vd.ExtraFiles) < verifyMaxListed {
+				vd.ExtraFiles = append(vd.ExtraFiles, fi.Name)
+			}
+			continue
+		}
+		seen[fi.Name] = struct{}{}
+		if diff := ours.difference(newVerifyEntry(fi)); diff != "" {
+			vd.Divergent++
+			if len(vd.DivergentFiles) < verifyMaxListed {
+				vd.DivergentFiles = append(vd.DivergentFiles, VerifyDifference{Name: fi.Name, Reason: diff})
+			}
+			continue
+		}
+		vd.Identical++
+		if ours.typ == protocol.FileInfoTypeFile && ours.size > 0 && spotChecks > 0 {
+			// Reservoir sampling, to pick evenly among all of them.
+			if len(candidates) < spotChecks {
+				candidates = append(candidates, fi.Name)
+			} else if i := rand.Intn(vd.Identical); i < spotChecks {
+				candidates[i] = fi.Name
+			}
+		}
+	}
+	for name := range local {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		vd.Missing++
+		if len(vd.MissingFiles) < verifyMaxListed {
+			vd.MissingFiles = append(vd.MissingFiles, name)
+		}
+	}
+	slices.Sort(vd.MissingFiles)
+	slices.Sort(vd.ExtraFiles)
+	slices.SortFunc(vd.DivergentFiles, func(a, b VerifyDifference) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	if len(candidates) > 0 {
+		if _, ok := m.requestConnectionForDevice(dev.DeviceID); !ok {
+			vd.SpotCheckSkipped = "device is not connected"
+			return vd, nil
+		}
+	}
+	for _, name := range candidates {
+		if err := m.spotCheck(ctx, folder, dev.DeviceID, name); err != nil {
+			if ctx.Err() != nil {
+				return VerifyDevice{}, ctx.Err()
+			}
+			vd.SpotCheckFailures = append(vd.SpotCheckFailures, VerifyDifference{Name: name, Reason: err.Error()})
+		}
+		vd.SpotChecked++
+	}
+	return vd, nil
+}
+
+// spotCheck requests a random block of the file from the device and
+// compares it with the hash we have.
+func (m *model) spotCheck(ctx context.Context, folder string, device protocol.DeviceID, name string) error {
+	fi, ok, err := m.sdb.GetDeviceFile(folder, protocol.LocalDeviceID, name)
+	if err != nil {
+		return err
+	}
+	if !ok || len(fi.Blocks) == 0 {
+		return errors.New("file changed while verifying")
+	}
+	blockNo := rand.Intn(len(fi.Blocks))
+	block := fi.Blocks[blockNo]
+	buf, err := m.RequestGlobal(ctx, device, folder, name, blockNo, block.Offset, block.Size, block.Hash, false)
+	if err != nil {
+		return fmt.Errorf("requesting block %d: %w", blockNo, err)
+	}
+	if !blockMatches(buf, block) {
+		return fmt.Errorf("block %d doesn't match our hash", blockNo)
+	}
+	return nil
+}
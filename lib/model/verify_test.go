@@ -0,0 +1,64 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package model
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+func TestVerifyFolder(t *testing.T) {
+	w, fcfg := newDefaultCfgWrapper(t)
+	// Nothing is pulled, so that the differences stay.
+	fcfg.Type = config.FolderTypeSendOnly
+	setFolder(t, w, fcfg)
+	m, fc := setupModelWithConnectionFromWrapper(t, w)
+	defer cleanupModel(m)
+
+	ffs := fcfg.Filesystem()
+	writeFile(t, ffs, "same", []byte("same contents"))
+	writeFile(t, ffs, "diff", []byte("our contents"))
+	writeFile(t, ffs, "onlyhere", []byte("only here"))
+	must(t, m.ScanFolder(fcfg.ID))
+
+	same, _ := m.testCurrentFolderFile(fcfg.ID, "same")
+	fc.addFile("diff", 0o644, protocol.FileInfoTypeFile, []byte("their contents"))
+	fc.addFile("onlythere", 0o644, protocol.FileInfoTypeFile, []byte("only there"))
+	fc.mut.Lock()
+	fc.files = append(fc.files, same)
+	fc.fileData["same"] = []byte("same contents")
+	fc.mut.Unlock()
+	fc.sendIndexUpdate()
+
+	report := mustV(m.VerifyFolder(t.Context(), fcfg.ID, 5))
+	if report.Files != 3 || len(report.Devices) != 1 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	vd := report.Devices[0]
+	if vd.Device != device1 || vd.Identical != 1 || vd.Divergent != 1 || vd.Missing != 1 || vd.Extra != 1 {
+		t.Fatalf("unexpected counts %+v", vd)
+	}
+	if vd.DivergentFiles[0].Name != "diff" || !slices.Equal(vd.MissingFiles, []string{"onlyhere"}) || !slices.Equal(vd.ExtraFiles, []string{"onlythere"}) {
+		t.Errorf("unexpected differences %+v", vd)
+	}
+	if vd.SpotChecked != 1 || len(vd.SpotCheckFailures) != 0 {
+		t.Errorf("unexpected spot checks %+v", vd)
+	}
+
+	// The other device returning other data than announced fails the spot
+	// check.
+	fc.mut.Lock()
+	fc.fileData["same"] = []byte("rotten contents")
+	fc.mut.Unlock()
+	vd = mustV(m.VerifyFolder(t.Context(), fcfg.ID, 5)).Devices[0]
+	if vd.SpotChecked != 1 || len(vd.SpotCheckFailures) != 1 || vd.SpotCheckFailures[0].Name != "same" {
+		t.Errorf("unexpected spot checks %+v", vd)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package osutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/build"
+
+	"github.com/kballard/go-shellquote"
+)
+
+// UserCommand prepares a command line configured by the user for running.
+// The command is split into words the way a shell would, each occurrence of
+// a key of vars (e.g. "%FOLDER_PATH%") is replaced by its value, and the
+// GUI credentials are removed from the environment.
+func UserCommand(ctx context.Context, command string, vars map[string]string) (*exec.Cmd, error) {
+	if build.IsWindows {
+		command = strings.ReplaceAll(command, `\`, `\\`)
+	}
+	if command == "" {
+		return nil, errors.New("command is empty, please enter a valid command")
+	}
+
+	words, err := shellquote.Split(command)
+	if err != nil {
+		return nil, fmt.Errorf("command is invalid: %w", err)
+	}
+	if len(words) == 0 {
+		return nil, errors.New("command is empty, please enter a valid command")
+	}
+
+	for i, word := range words {
+		for key, val := range vars {
+			word = strings.ReplaceAll(word, key, val)
+		}
+		words[i] = word
+	}
+
+	cmd := exec.CommandContext(ctx, words[0], words[1:]...)
+	// filter STGUIAUTH and STGUIAPIKEY from environment variables
+	for _, x := range os.Environ() {
+		if !strings.HasPrefix(x, "STGUIAUTH=") && !strings.HasPrefix(x, "STGUIAPIKEY=") {
+			cmd.Env = append(cmd.Env, x)
+		}
+	}
+	return cmd, nil
+}
+
+// CommandError adds what the command wrote to stderr, if known, to the
+// error returned from running it.
+func CommandError(err error) error {
+	eerr := &exec.ExitError{}
+	if errors.As(err, &eerr) && len(eerr.Stderr) > 0 {
+		return fmt.Errorf("%w: %v", err, string(eerr.Stderr))
+	}
+	return err
+}
@@ -68,11 +68,12 @@ func clusterConfigFromWire(w *bep.ClusterConfig) *ClusterConfig {
 }
 
 type Folder struct {
-	ID         string
-	Label      string
-	Type       FolderType
-	StopReason FolderStopReason
-	Devices    []Device
+	ID            string
+	Label         string
+	Type          FolderType
+	StopReason    FolderStopReason
+	SharedIgnores SharedIgnores
+	Devices       []Device
 }
 
 func (f *Folder) toWire() *bep.Folder {
@@ -80,13 +81,19 @@ func (f *Folder) toWire() *bep.Folder {
 	for i, d := range f.Devices {
 		devices[i] = d.toWire()
 	}
-	return &bep.Folder{
+	w := &bep.Folder{
 		Id:         f.ID,
 		Label:      f.Label,
 		Type:       bep.FolderType(f.Type),
 		StopReason: bep.FolderStopReason(f.StopReason),
 		Devices:    devices,
 	}
+	if f.SharedIgnores.Version > 0 {
+		w.SharedIgnores = f.SharedIgnores.Lines
+		w.SharedIgnoresVersion = f.SharedIgnores.Version
+		w.SharedIgnoresModifiedBy = f.SharedIgnores.ModifiedBy[:]
+	}
+	return w
 }
 
 func folderFromWire(w *bep.Folder) Folder {
@@ -94,13 +101,23 @@ func folderFromWire(w *bep.Folder) Folder {
 	for i, d := range w.Devices {
 		devices[i] = deviceFromWire(d)
 	}
-	return Folder{
+	f := Folder{
 		ID:         w.Id,
 		Label:      w.Label,
 		Type:       FolderType(w.Type),
 		StopReason: FolderStopReason(w.StopReason),
 		Devices:    devices,
 	}
+	if w.SharedIgnoresVersion > 0 {
+		// A malformed device ID just loses ties.
+		modifiedBy, _ := DeviceIDFromBytes(w.SharedIgnoresModifiedBy)
+		f.SharedIgnores = SharedIgnores{
+			Lines:      w.SharedIgnores,
+			Version:    w.SharedIgnoresVersion,
+			ModifiedBy: modifiedBy,
+		}
+	}
+	return f
 }
 
 func (f Folder) Description() string {
@@ -127,6 +144,23 @@ func (f Folder) IsRunning() bool {
 	}
 }
 
+// SharedIgnores is the versioned ignore pattern document shared between the
+// devices of a folder. A zero version means there is none.
+type SharedIgnores struct {
+	Lines      []string
+	Version    uint64
+	ModifiedBy DeviceID
+}
+
+// NewerThan returns whether the document supersedes the other one: it has
+// a higher version, or the same version made by a higher device ID.
+func (s SharedIgnores) NewerThan(other SharedIgnores) bool {
+	if s.Version != other.Version {
+		return s.Version > other.Version
+	}
+	return s.ModifiedBy.Compare(other.ModifiedBy) > 0
+}
+
 type Device struct {
 	ID                       DeviceID
 	Name                     string
@@ -124,6 +124,7 @@ type FileInfo struct {
 	BlocksHash         []byte
 	PreviousBlocksHash []byte
 	Encrypted          []byte
+	HardLinkGroup      []byte // shared by the hard links to the same data
 	Platform           PlatformData
 
 	Type         FileInfoType
@@ -173,6 +174,7 @@ func (f *FileInfo) ToWire(withInternalFields bool) *bep.FileInfo {
 		BlocksHash:         f.BlocksHash,
 		PreviousBlocksHash: f.PreviousBlocksHash,
 		Encrypted:          f.Encrypted,
+		HardLinkGroup:      f.HardLinkGroup,
 		Type:               f.Type,
 		Permissions:        f.Permissions,
 		ModifiedNs:         f.ModifiedNs,
@@ -263,7 +265,9 @@ func FileInfoFromWire(w *bep.FileInfo) FileInfo {
 			blocks[j] = BlockInfoFromWire(b)
 		}
 	}
-	return fileInfoFromWireWithBlocks(w, blocks)
+	f := fileInfoFromWireWithBlocks(w, blocks)
+	f.HardLinkGroup = w.HardLinkGroup
+	return f
 }
 
 type FileInfoWithoutBlocks interface {
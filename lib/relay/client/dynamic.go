@@ -67,7 +67,7 @@ func (c *dynamicClient) serve(ctx context.Context) error {
 		return err
 	}
 
-	var addrs []string
+	var relays []dynamicRelay
 	for _, relayAnn := range ann.Relays {
 		ruri, err := url.Parse(relayAnn.URL)
 		if err != nil {
@@ -75,10 +75,10 @@ func (c *dynamicClient) serve(ctx context.Context) error {
 			continue
 		}
 		l.Debugln(c, "found", ruri)
-		addrs = append(addrs, ruri.String())
+		relays = append(relays, dynamicRelay{URL: ruri.String(), Score: relayAnn.Score})
 	}
 
-	for _, addr := range relayAddressesOrder(ctx, addrs) {
+	for _, addr := range relayAddressesOrder(ctx, relays) {
 		select {
 		case <-ctx.Done():
 			l.Debugln(c, "stopping")
@@ -129,29 +129,39 @@ func (c *dynamicClient) URI() *url.URL {
 }
 
 // This is the announcement received from the relay server;
-// {"relays": [{"url": "relay://10.20.30.40:5060"}, ...]}
+// {"relays": [{"url": "relay://10.20.30.40:5060", "score": 0.8}, ...]}
+// The score is optional and only present when the pool server probes the
+// health of its relays.
 type dynamicAnnouncement struct {
-	Relays []struct {
-		URL string
-	}
+	Relays []dynamicRelay
+}
+
+type dynamicRelay struct {
+	URL   string
+	Score *float64
 }
 
-// relayAddressesOrder checks the latency to each relay, rounds latency down to
-// the closest 50ms, and puts them in buckets of 50ms latency ranges. Then
-// shuffles each bucket, and returns all addresses starting with the ones from
-// the lowest latency bucket, ending with the highest latency bucket.
-func relayAddressesOrder(ctx context.Context, input []string) []string {
+// scorePenalty is the latency added to a relay with a health score of zero.
+// Relays with a score of one, or without a score, get no penalty.
+const scorePenalty = 500 * time.Millisecond
+
+// relayAddressesOrder checks the latency to each relay, adds a penalty for
+// relays with a poor health score, rounds the result down to the closest 50ms,
+// and puts them in buckets of 50ms latency ranges. Then shuffles each bucket,
+// and returns all addresses starting with the ones from the lowest latency
+// bucket, ending with the highest latency bucket.
+func relayAddressesOrder(ctx context.Context, input []dynamicRelay) []string {
 	buckets := make(map[int][]string)
 
 	for _, relay := range input {
-		latency, err := osutil.GetLatencyForURL(ctx, relay)
+		latency, err := osutil.GetLatencyForURL(ctx, relay.URL)
 		if err != nil {
 			latency = time.Hour
 		}
 
-		id := int(latency/time.Millisecond) / 50
+		id := relayBucket(latency, relay.Score)
 
-		buckets[id] = append(buckets[id], relay)
+		buckets[id] = append(buckets[id], relay.URL)
 
 		select {
 		case <-ctx.Done():
@@ -175,3 +185,13 @@ func relayAddressesOrder(ctx context.Context, input []string) []string {
 
 	return addresses
 }
+
+// relayBucket returns the latency bucket for a relay with the given measured
+// latency and optional health score.
+func relayBucket(latency time.Duration, score *float64) int {
+	if score != nil {
+		s := min(max(*score, 0), 1)
+		latency += time.Duration((1 - s) * float64(scorePenalty))
+	}
+	return int(latency/time.Millisecond) / 50
+}
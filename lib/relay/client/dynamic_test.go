@@ -0,0 +1,55 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package client
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestRelayBucket(t *testing.T) {
+	score := func(f float64) *float64 { return &f }
+
+	cases := []struct {
+		latency time.Duration
+		score   *float64
+		bucket  int
+	}{
+		{10 * time.Millisecond, nil, 0},
+		{60 * time.Millisecond, nil, 1},
+		{10 * time.Millisecond, score(1), 0},
+		{10 * time.Millisecond, score(0.5), 5},
+		{10 * time.Millisecond, score(0), 10},
+		{10 * time.Millisecond, score(-3), 10},
+		{10 * time.Millisecond, score(7), 0},
+	}
+
+	for _, tc := range cases {
+		if b := relayBucket(tc.latency, tc.score); b != tc.bucket {
+			t.Errorf("relayBucket(%v, %v) = %d, expected %d", tc.latency, tc.score, b, tc.bucket)
+		}
+	}
+}
+
+func TestDynamicAnnouncement(t *testing.T) {
+	data := `{"relays":[{"url":"relay://192.0.2.1:22067"},{"url":"relay://192.0.2.2:22067","score":0.25}]}`
+
+	var ann dynamicAnnouncement
+	if err := json.Unmarshal([]byte(data), &ann); err != nil {
+		t.Fatal(err)
+	}
+	if len(ann.Relays) != 2 {
+		t.Fatalf("expected two relays, got %d", len(ann.Relays))
+	}
+	if ann.Relays[0].Score != nil {
+		t.Error("first relay should have no score")
+	}
+	if ann.Relays[1].Score == nil || *ann.Relays[1].Score != 0.25 {
+		t.Error("second relay should have score 0.25")
+	}
+}
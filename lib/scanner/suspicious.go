@@ -0,0 +1,170 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package scanner
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/syncthing/syncthing/lib/fs"
+)
+
+const (
+	contentSampleSize = 8 << 10
+	// Smaller samples don't say much about the entropy of the content.
+	minContentSampleSize = 512
+	// Bits per byte, where 8 is random. Compressed formats come close,
+	// but have a recognisable header.
+	highEntropy = 7.5
+)
+
+// Extensions that malware gives the files it has encrypted.
+var ransomExtensions = []string{
+	".cerber", ".crinf", ".crypt", ".crypted", ".djvu", ".encrypted",
+	".lockbit", ".locked", ".locky", ".odin", ".ryk", ".wncry", ".wnry",
+	".zepto",
+}
+
+// Extensions of formats that are either text, and so of low entropy, or
+// start with one of the known headers.
+var structuredExtensions = []string{
+	".7z", ".bmp", ".c", ".cpp", ".css", ".csv", ".doc", ".docx", ".flac",
+	".gif", ".go", ".gz", ".h", ".heic", ".htm", ".html", ".ini", ".java",
+	".jpeg", ".jpg", ".js", ".json", ".log", ".md", ".mkv", ".mov", ".mp3",
+	".mp4", ".odp", ".ods", ".odt", ".ogg", ".pdf", ".png", ".ppt",
+	".pptx", ".py", ".rar", ".rs", ".rtf", ".sh", ".sql", ".sqlite",
+	".svg", ".tex", ".tif", ".tiff", ".ts", ".txt", ".wav", ".webp",
+	".xls", ".xlsx", ".xml", ".yaml", ".yml", ".zip",
+}
+
+// Headers of the binary formats above, at the start of the file.
+var magicHeaders = [][]byte{
+	[]byte("PK\x03\x04"),         // zip, and office documents
+	[]byte("\x1f\x8b"),           // gzip
+	[]byte("7z\xbc\xaf\x27\x1c"), // 7-zip
+	[]byte("Rar!"),               // rar
+	[]byte("\xff\xd8\xff"),       // jpeg
+	[]byte("\x89PNG"),            // png
+	[]byte("GIF8"),               // gif
+	[]byte("BM"),                 // bmp
+	[]byte("II*\x00"),            // tiff
+	[]byte("MM\x00*"),            // tiff
+	[]byte("RIFF"),               // wav, webp
+	[]byte("%PDF"),               // pdf
+	[]byte("ID3"),                // mp3
+	[]byte("\xff\xfb"),           // mp3
+	[]byte("OggS"),               // ogg
+	[]byte("fLaC"),               // flac
+	[]byte("\x1a\x45\xdf\xa3"),   // matroska
+	[]byte("\xd0\xcf\x11\xe0\xa1\xb1\x1a\xe1"), // old office documents
+	[]byte("SQLite format 3\x00"),              // sqlite
+	[]byte("{\\rtf"),                           // rtf
+}
+
+// suspiciousContent returns why the file looks like it was encrypted by
+// malware, or the empty string if it doesn't.
+func suspiciousContent(filesystem fs.Filesystem, name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	if slices.Contains(ransomExtensions, ext) {
+		return "extension " + ext
+	}
+	if !slices.Contains(structuredExtensions, ext) {
+		// We don't know what this should look like.
+		return ""
+	}
+
+	fd, err := filesystem.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer fd.Close()
+	sample := make([]byte, contentSampleSize)
+	n, _ := io.ReadFull(fd, sample)
+	return suspiciousSample(sample[:n])
+}
+
+func suspiciousSample(sample []byte) string {
+	if len(sample) < minContentSampleSize || hasMagicHeader(sample) {
+		return ""
+	}
+	if entropy(sample) < highEntropy {
+		return ""
+	}
+	return "high entropy content"
+}
+
+// Types of the atoms that QuickTime and its descendants start with.
+var quickTimeAtoms = [][]byte{
+	[]byte("ftyp"), // mp4, mov, heic
+	[]byte("moov"), // older mov
+	[]byte("mdat"),
+	[]byte("wide"),
+	[]byte("free"),
+	[]byte("skip"),
+	[]byte("pnot"),
+}
+
+// MPEG transport streams are packets of this size, each starting with the
+// sync byte.
+const (
+	mpegTSPacketSize = 188
+	mpegTSSyncByte   = 0x47
+)
+
+func hasMagicHeader(sample []byte) bool {
+	if len(sample) >= 8 {
+		for _, atom := range quickTimeAtoms {
+			if bytes.Equal(sample[4:8], atom) {
+				return true
+			}
+		}
+	}
+	if isMPEGTS(sample) {
+		return true
+	}
+	for _, magic := range magicHeaders {
+		if bytes.HasPrefix(sample, magic) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMPEGTS returns whether the sample is a sequence of MPEG transport
+// stream packets, as .ts files can be those as well as TypeScript.
+func isMPEGTS(sample []byte) bool {
+	if len(sample) < 2*mpegTSPacketSize {
+		return false
+	}
+	for i := 0; i < len(sample); i += mpegTSPacketSize {
+		if sample[i] != mpegTSSyncByte {
+			return false
+		}
+	}
+	return true
+}
+
+// entropy returns the Shannon entropy of the data, in bits per byte.
+func entropy(data []byte) float64 {
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+	var e float64
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / float64(len(data))
+		e -= p * math.Log2(p)
+	}
+	return e
+}
@@ -0,0 +1,80 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/syncthing/syncthing/lib/fs"
+)
+
+func TestSuspiciousContent(t *testing.T) {
+	random := make([]byte, 4096)
+	rand.Read(random)
+	text := []byte(strings.Repeat("The quick brown fox jumps over the lazy dog.\n", 100))
+	zip := append([]byte("PK\x03\x04"), random...)
+	mov := append([]byte("\x00\x00\x00\x08wide"), random...)
+	mpegTS := bytes.Clone(random)
+	for i := 0; i < len(mpegTS); i += 188 {
+		mpegTS[i] = 0x47
+	}
+
+	tfs := fs.NewFilesystem(fs.FilesystemTypeFake, "TestSuspiciousContent?content=true")
+	cases := []struct {
+		name       string
+		data       []byte
+		suspicious bool
+	}{
+		{"report.txt", text, false},
+		{"report.txt.locked", text, true},
+		{"report.TXT", random, true},
+		{"small.txt", random[:100], false},
+		{"archive.zip", zip, false},
+		{"archive.docx", random, true},
+		{"clip.mov", mov, false},
+		{"clip.ts", mpegTS, false},
+		{"script.ts", random, true},
+		{"unknown.bin", random, false},
+	}
+	for _, tc := range cases {
+		if err := fs.WriteFile(tfs, tc.name, tc.data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if reason := suspiciousContent(tfs, tc.name); (reason != "") != tc.suspicious {
+			t.Errorf("%s: got reason %q, expected suspicious %v", tc.name, reason, tc.suspicious)
+		}
+	}
+}
+
+func TestWalkSuspicious(t *testing.T) {
+	random := make([]byte, 4096)
+	rand.Read(random)
+
+	tfs := fs.NewFilesystem(fs.FilesystemTypeFake, "TestWalkSuspicious?content=true&nostfolder=true")
+	fs.WriteFile(tfs, "notes.txt", bytes.Repeat([]byte("notes\n"), 100), 0o644)
+	fs.WriteFile(tfs, "letter.odt", random, 0o644)
+
+	cfg, cancel := testConfig()
+	defer cancel()
+	cfg.Filesystem = tfs
+	var found []string
+	cfg.Suspicious = func(name, _ string) {
+		found = append(found, name)
+	}
+	for res := range Walk(context.TODO(), cfg) {
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+	}
+	if len(found) != 1 || found[0] != "letter.odt" {
+		t.Errorf("unexpected suspicious files %v", found)
+	}
+}
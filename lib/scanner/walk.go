@@ -7,7 +7,10 @@
 package scanner
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"log/slog"
@@ -67,6 +70,13 @@ type Config struct {
 	ScanXattrs bool
 	// Filter for extended attributes
 	XattrFilter XattrFilter
+	// If Suspicious is not nil, changed files are sampled for signs of
+	// having been encrypted by malware, and those that show any are passed
+	// to it with the reason.
+	Suspicious func(name, reason string)
+	// If HardLinks is true, files with more than one link are tagged with
+	// an identifier shared by all links to the same data.
+	HardLinks bool
 }
 
 type CurrentFiler interface {
@@ -95,7 +105,7 @@ func WalkWithoutHashing(ctx context.Context, cfg Config) chan ScanResult {
 }
 
 func newWalker(cfg Config) *walker {
-	w := &walker{cfg}
+	w := &walker{Config: cfg}
 
 	if w.CurrentFiler == nil {
 		w.CurrentFiler = noCurrentFiler{}
@@ -119,6 +129,15 @@ var (
 
 type walker struct {
 	Config
+
+	// The link groups given out in this walk, by inode and the other way
+	// around.
+	linkGroups map[inodeKey][]byte
+	linkInodes map[string]inodeKey
+}
+
+type inodeKey struct {
+	dev, ino uint64
 }
 
 // Walk returns the list of files found in the local folder by scanning the
@@ -456,10 +475,15 @@ func (w *walker) walkRegular(ctx context.Context, relPath string, info fs.FileIn
 	f = w.updateFileInfo(f, curFile)
 	f.NoPermissions = w.IgnorePerms
 	f.RawBlockSize = int32(blockSize)
+	if w.HardLinks {
+		f.HardLinkGroup = w.linkGroup(info, curFile.HardLinkGroup)
+	}
 	l.Debugln(w, "checking:", f)
 
 	if hasCurFile {
-		if curFile.IsEquivalentOptional(f, protocol.FileInfoComparison{
+		// Gaining a link leaves the modification time alone, so the group
+		// is compared separately.
+		if bytes.Equal(curFile.HardLinkGroup, f.HardLinkGroup) && curFile.IsEquivalentOptional(f, protocol.FileInfoComparison{
 			ModTimeWindow:   w.ModTimeWindow,
 			IgnorePerms:     w.IgnorePerms,
 			IgnoreBlocks:    true,
@@ -483,6 +507,13 @@ func (w *walker) walkRegular(ctx context.Context, relPath string, info fs.FileIn
 		l.Debugln(w, "rescan:", curFile)
 	}
 
+	if w.Suspicious != nil {
+		if reason := suspiciousContent(w.Filesystem, relPath); reason != "" {
+			l.Debugln(w, "suspicious:", relPath, reason)
+			w.Suspicious(relPath, reason)
+		}
+	}
+
 	l.Debugln(w, "to hash:", relPath, f)
 
 	select {
@@ -656,6 +687,9 @@ func (w *walker) updateFileInfo(dst, src protocol.FileInfo) protocol.FileInfo {
 	dst.ModifiedBy = w.ShortID
 	dst.LocalFlags = w.LocalFlags
 	dst.PreviousBlocksHash = src.BlocksHash
+	// The link group is kept when we don't look at links, see linkGroup
+	// otherwise.
+	dst.HardLinkGroup = src.HardLinkGroup
 
 	// Copy OS data from src to dst, unless it was already set on dst.
 	dst.Platform.MergeWith(&src.Platform)
@@ -663,6 +697,45 @@ func (w *walker) updateFileInfo(dst, src protocol.FileInfo) protocol.FileInfo {
 	return dst
 }
 
+// linkGroup returns the link group identifier of the file, or nil when it
+// has a single link. A file keeps its current group while that names the
+// same links, as it may have come from another device and devices
+// shouldn't fight over it. Otherwise the group is derived from the device
+// and inode numbers.
+func (w *walker) linkGroup(info fs.FileInfo, cur []byte) []byte {
+	dev, ino, links, ok := fs.HardLinkID(info)
+	if !ok || links < 2 {
+		return nil
+	}
+	key := inodeKey{dev, ino}
+	if group, ok := w.linkGroups[key]; ok {
+		return group
+	}
+	if w.linkGroups == nil {
+		w.linkGroups = make(map[inodeKey][]byte)
+		w.linkInodes = make(map[string]inodeKey)
+	}
+	group := cur
+	if _, taken := w.linkInodes[string(cur)]; len(cur) == 0 || taken {
+		// A new group, or the links of the old one have gone separate
+		// ways.
+		group = hardLinkGroup(w.Folder, dev, ino)
+	}
+	w.linkGroups[key] = group
+	w.linkInodes[string(group)] = key
+	return group
+}
+
+// hardLinkGroup derives a link group identifier from the device and inode
+// numbers, so that it's the same for all links, without exposing them.
+func hardLinkGroup(folder string, dev, ino uint64) []byte {
+	h := sha256.New()
+	h.Write([]byte(folder))
+	h.Write(binary.BigEndian.AppendUint64(nil, dev))
+	h.Write(binary.BigEndian.AppendUint64(nil, ino))
+	return h.Sum(nil)[:16]
+}
+
 func handleError(ctx context.Context, context, path string, err error, finishedChan chan<- ScanResult) {
 	l.Debugf("handle error on '%v': %v: %v", path, context, err)
 	select {
@@ -15,27 +15,27 @@ import (
 	"github.com/syncthing/syncthing/lib/events"
 )
 
-// The auditService subscribes to events and writes these in JSON format, one
-// event per line, to the specified writer.
+// The auditService subscribes to the given events and writes these in JSON
+// format, one event per line, to each of the specified writers.
 type auditService struct {
-	w        io.Writer // audit destination
+	ws       []io.Writer // audit destinations
+	mask     events.EventType
 	evLogger events.Logger
 }
 
-func newAuditService(w io.Writer, evLogger events.Logger) *auditService {
+func newAuditService(mask events.EventType, evLogger events.Logger, ws ...io.Writer) *auditService {
 	return &auditService{
-		w:        w,
+		ws:       ws,
+		mask:     mask,
 		evLogger: evLogger,
 	}
 }
 
 // serve runs the audit service.
 func (s *auditService) Serve(ctx context.Context) error {
-	sub := s.evLogger.Subscribe(events.AllEvents)
+	sub := s.evLogger.Subscribe(s.mask)
 	defer sub.Unsubscribe()
 
-	enc := json.NewEncoder(s.w)
-
 	for {
 		select {
 		case ev, ok := <-sub.C():
@@ -43,7 +43,15 @@ func (s *auditService) Serve(ctx context.Context) error {
 				<-ctx.Done()
 				return ctx.Err()
 			}
-			enc.Encode(ev)
+			bs, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			bs = append(bs, '\n')
+			// One failing destination shouldn't stop the others.
+			for _, w := range s.ws {
+				_, _ = w.Write(bs)
+			}
 		case <-ctx.Done():
 			return ctx.Err()
 		}
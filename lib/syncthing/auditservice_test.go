@@ -9,10 +9,13 @@ package syncthing
 import (
 	"bytes"
 	"context"
+	"net"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/syncthing/syncthing/lib/build"
 	"github.com/syncthing/syncthing/lib/events"
 )
 
@@ -31,7 +34,7 @@ func TestAuditService(t *testing.T) {
 	<-sub.C()
 
 	auditCtx, auditCancel := context.WithCancel(context.Background())
-	service := newAuditService(buf, evLogger)
+	service := newAuditService(events.AllEvents, evLogger, buf)
 	done := make(chan struct{})
 	go func() {
 		service.Serve(auditCtx)
@@ -68,3 +71,62 @@ func TestAuditService(t *testing.T) {
 		t.Error("Missing third event")
 	}
 }
+
+func TestAuditServiceFilterAndSyslog(t *testing.T) {
+	if build.IsWindows {
+		t.Skip("no unix datagram sockets")
+	}
+
+	sock := filepath.Join(t.TempDir(), "log")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: sock, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	sw, err := newSyslogWriter("unixgram://" + sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sw.Close()
+
+	buf := new(bytes.Buffer)
+	evLogger := events.NewLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	go evLogger.Serve(ctx)
+	defer cancel()
+
+	service := newAuditService(events.APIRequest, evLogger, buf, sw)
+	done := make(chan struct{})
+	go func() {
+		service.Serve(ctx)
+		close(done)
+	}()
+
+	// Subscription needs to happen in service.Serve
+	time.Sleep(10 * time.Millisecond)
+
+	evLogger.Log(events.ConfigSaved, "not audited")
+	evLogger.Log(events.APIRequest, map[string]any{"endpoint": "/rest/db/scan"})
+
+	msg := make([]byte, 1024)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(msg[:n])
+	if !strings.HasPrefix(got, "<30>") || !strings.Contains(got, " syncthing[") || !strings.Contains(got, `"endpoint":"/rest/db/scan"`) || strings.HasSuffix(got, "\n") {
+		t.Errorf("unexpected syslog message %q", got)
+	}
+
+	cancel()
+	<-done
+
+	result := buf.String()
+	if strings.Contains(result, "not audited") {
+		t.Error("unexpected filtered event")
+	}
+	if strings.Count(result, "\n") != 1 || !strings.Contains(result, `"type":"APIRequest"`) {
+		t.Errorf("unexpected audit log %q", result)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package syncthing
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// daemon.info
+	auditSyslogPriority = 3<<3 | 6
+	auditSyslogTag      = "syncthing"
+)
+
+// The sockets of the local syslog daemon, or journald, on various systems.
+var defaultSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// A syslogWriter sends each write as a message to a syslog socket,
+// reconnecting as needed.
+type syslogWriter struct {
+	network, addr string
+	hostname      string
+
+	mut  sync.Mutex
+	conn net.Conn
+}
+
+// newSyslogWriter connects to the syslog socket at the given address,
+// which is "default" or a URL like "unixgram:///dev/log" or
+// "udp://loghost:514".
+func newSyslogWriter(address string) (*syslogWriter, error) {
+	w := &syslogWriter{}
+	if address == "default" {
+		for _, path := range defaultSyslogSockets {
+			for _, network := range []string{"unixgram", "unix"} {
+				w.network, w.addr = network, path
+				if err := w.connect(); err == nil {
+					return w, nil
+				}
+			}
+		}
+		return nil, errors.New("no local syslog socket found")
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "unix", "unixgram":
+		w.addr = u.Path
+	case "udp", "tcp":
+		w.addr = u.Host
+		// Remote servers need to know where the message came from.
+		w.hostname, _ = os.Hostname()
+	default:
+		return nil, fmt.Errorf("unsupported syslog address %q", address)
+	}
+	w.network = u.Scheme
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *syslogWriter) connect() error {
+	conn, err := net.DialTimeout(w.network, w.addr, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	w.conn = conn
+	return nil
+}
+
+func (w *syslogWriter) Write(bs []byte) (int, error) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	msg := w.format(bytes.TrimRight(bs, "\n"))
+	if w.conn != nil {
+		if _, err := w.conn.Write(msg); err == nil {
+			return len(bs), nil
+		}
+		// The syslog daemon may have been restarted.
+		w.conn.Close()
+		w.conn = nil
+	}
+	if err := w.connect(); err != nil {
+		return 0, err
+	}
+	if _, err := w.conn.Write(msg); err != nil {
+		return 0, err
+	}
+	return len(bs), nil
+}
+
+// format returns the message in the traditional BSD syslog format, which
+// local daemons and journald understand, or with a full timestamp and
+// host name for remote servers.
+func (w *syslogWriter) format(bs []byte) []byte {
+	var msg string
+	if w.hostname == "" {
+		msg = fmt.Sprintf("<%d>%s %s[%d]: %s", auditSyslogPriority, time.Now().Format(time.Stamp), auditSyslogTag, os.Getpid(), bs)
+	} else {
+		msg = fmt.Sprintf("<%d>%s %s %s[%d]: %s", auditSyslogPriority, time.Now().Format(time.RFC3339), w.hostname, auditSyslogTag, os.Getpid(), bs)
+	}
+	if !strings.HasSuffix(w.network, "gram") && w.network != "udp" {
+		// Messages on streams are separated by newlines.
+		msg += "\n"
+	}
+	return []byte(msg)
+}
+
+func (w *syslogWriter) Close() error {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
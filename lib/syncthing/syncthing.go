@@ -28,6 +28,7 @@ import (
 	"github.com/syncthing/syncthing/lib/api"
 	"github.com/syncthing/syncthing/lib/build"
 	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/confighistory"
 	"github.com/syncthing/syncthing/lib/connections"
 	"github.com/syncthing/syncthing/lib/connections/registry"
 	"github.com/syncthing/syncthing/lib/discover"
@@ -40,6 +41,7 @@ import (
 	"github.com/syncthing/syncthing/lib/tlsutil"
 	"github.com/syncthing/syncthing/lib/upgrade"
 	"github.com/syncthing/syncthing/lib/ur"
+	"github.com/syncthing/syncthing/lib/webhooks"
 )
 
 const (
@@ -120,7 +122,16 @@ func (a *App) startup() error {
 	a.mainService.Add(a.sdb.Service(a.opts.DBMaintenanceInterval))
 
 	if a.opts.AuditWriter != nil {
-		a.mainService.Add(newAuditService(a.opts.AuditWriter, a.evLogger))
+		opts := a.cfg.Options()
+		ws := []io.Writer{a.opts.AuditWriter}
+		if opts.AuditSyslogAddress != "" {
+			if sw, err := newSyslogWriter(opts.AuditSyslogAddress); err != nil {
+				slog.Warn("Failed to connect to syslog for the audit log", slogutil.Address(opts.AuditSyslogAddress), slogutil.Error(err))
+			} else {
+				ws = append(ws, sw)
+			}
+		}
+		a.mainService.Add(newAuditService(opts.AuditEventMask(), a.evLogger, ws...))
 	}
 
 	// Event subscription for the API; must start early to catch the early
@@ -284,9 +295,15 @@ func (a *App) startup() error {
 	usageReportingSvc := ur.New(a.cfg, m, connectionsService, a.opts.NoUpgrade)
 	a.mainService.Add(usageReportingSvc)
 
+	webhooksSvc := webhooks.New(a.cfg, a.evLogger, miscDB)
+	a.mainService.Add(webhooksSvc)
+
+	configHistory := confighistory.New(a.cfg, a.evLogger, miscDB)
+	a.mainService.Add(configHistory)
+
 	// GUI
 
-	if err := a.setupGUI(m, defaultSub, diskSub, discoveryManager, connectionsService, usageReportingSvc, slogutil.ErrorRecorder, slogutil.GlobalRecorder, miscDB); err != nil {
+	if err := a.setupGUI(m, defaultSub, diskSub, discoveryManager, connectionsService, usageReportingSvc, webhooksSvc, configHistory, slogutil.ErrorRecorder, slogutil.GlobalRecorder, miscDB); err != nil {
 		slog.Error("Failed to start API", slogutil.Error(err))
 		return err
 	}
@@ -388,7 +405,7 @@ func (a *App) stopWithErr(stopReason svcutil.ExitStatus, err error) svcutil.Exit
 	return a.exitStatus
 }
 
-func (a *App) setupGUI(m model.Model, defaultSub, diskSub events.BufferedSubscription, discoverer discover.Manager, connectionsService connections.Service, urService *ur.Service, errors, systemLog slogutil.Recorder, miscDB *db.Typed) error {
+func (a *App) setupGUI(m model.Model, defaultSub, diskSub events.BufferedSubscription, discoverer discover.Manager, connectionsService connections.Service, urService *ur.Service, webhooksService *webhooks.Service, configHistory *confighistory.History, errors, systemLog slogutil.Recorder, miscDB *db.Typed) error {
 	guiCfg := a.cfg.GUI()
 
 	if !guiCfg.Enabled {
@@ -402,7 +419,7 @@ func (a *App) setupGUI(m model.Model, defaultSub, diskSub events.BufferedSubscri
 	summaryService := model.NewFolderSummaryService(a.cfg, m, a.myID, a.evLogger)
 	a.mainService.Add(summaryService)
 
-	apiSvc := api.New(a.myID, a.cfg, locations.Get(locations.GUIAssets), tlsDefaultCommonName, m, defaultSub, diskSub, a.evLogger, discoverer, connectionsService, urService, summaryService, errors, systemLog, a.opts.NoUpgrade, miscDB)
+	apiSvc := api.New(a.myID, a.cfg, locations.Get(locations.GUIAssets), tlsDefaultCommonName, m, defaultSub, diskSub, a.evLogger, discoverer, connectionsService, urService, webhooksService, configHistory, summaryService, errors, systemLog, a.opts.NoUpgrade, miscDB)
 	a.mainService.Add(apiSvc)
 
 	if err := apiSvc.WaitForStart(); err != nil {
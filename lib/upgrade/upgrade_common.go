@@ -10,11 +10,13 @@ package upgrade
 import (
 	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path"
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/syncthing/syncthing/lib/build"
 )
@@ -30,6 +32,13 @@ type Release struct {
 
 	// The compatibility information is included with each current release.
 	Compatibility *ReleaseCompatibility `json:"compatibility,omitempty"`
+
+	// The time the release was published, if known.
+	PublishedAt time.Time `json:"published_at,omitzero"`
+
+	// The channel is set for releases served from a private release
+	// channel, and is empty for the regular releases.
+	Channel string `json:"channel,omitempty"`
 }
 
 type Asset struct {
@@ -48,6 +57,76 @@ type ReleaseCompatibility struct {
 	Requirements map[string]string `json:"requirements,omitempty"`
 }
 
+// DefaultChannel is the name of the release channel of the regular,
+// publicly released versions.
+const DefaultChannel = "stable"
+
+// ManifestSignatureHeader is the HTTP header carrying the signature of the
+// release metadata, if the upgrade server signs it. The value is the
+// base64 encoded signature in the format created by lib/signature.
+const ManifestSignatureHeader = "Syncthing-Manifest-Signature"
+
+// Channel describes where to look for upgrades.
+type Channel struct {
+	// The name of the channel, such as "stable", "canary" or "internal".
+	// Anything other than DefaultChannel is requested from the releases
+	// URL with a "channel" query parameter.
+	Name        string
+	ReleasesURL string
+	PreReleases bool
+	// Public keys in PEM format that are trusted to sign releases, in
+	// addition to the built in SigningKey.
+	SigningKeys []string
+}
+
+// NewChannel returns the channel of the given name, the default one when
+// empty, with the extra release signing keys to trust in addition to the
+// built in one.
+func NewChannel(name, releasesURL string, preReleases bool, signingKeys []string) Channel {
+	if name == "" {
+		name = DefaultChannel
+	}
+	return Channel{
+		Name:        name,
+		ReleasesURL: releasesURL,
+		PreReleases: preReleases,
+		SigningKeys: signingKeys,
+	}
+}
+
+// trustedKeys returns the built in signing key followed by the extra
+// signing keys of the channel.
+func (c Channel) trustedKeys() [][]byte {
+	keys := [][]byte{SigningKey}
+	for _, key := range c.SigningKeys {
+		keys = append(keys, []byte(key))
+	}
+	return keys
+}
+
+// requiresSignedManifest returns whether the release metadata must be
+// signed. Other channels, and extra keys, mean releases that aren't the
+// public ones, which an unsigned list could point anywhere.
+func (c Channel) requiresSignedManifest() bool {
+	return len(c.SigningKeys) > 0 || c.Name != "" && c.Name != DefaultChannel
+}
+
+// metadataURL returns the releases URL, with the channel parameter added
+// for channels other than the default one.
+func (c Channel) metadataURL() (string, error) {
+	if c.Name == "" || c.Name == DefaultChannel {
+		return c.ReleasesURL, nil
+	}
+	u, err := url.Parse(c.ReleasesURL)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	q.Set("channel", c.Name)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
 var (
 	ErrNoReleaseDownload  = errors.New("couldn't find a release to download")
 	ErrNoVersionToSelect  = errors.New("no version to select")
@@ -60,7 +139,9 @@ func init() {
 	upgradeUnlocked <- true
 }
 
-func To(rel Release) error {
+// To upgrades the running binary to the given release, which must be signed
+// by one of the keys trusted by the channel.
+func To(rel Release, ch Channel) error {
 	select {
 	case <-upgradeUnlocked:
 		path, err := os.Executable()
@@ -68,7 +149,7 @@ func To(rel Release) error {
 			upgradeUnlocked <- true
 			return err
 		}
-		err = upgradeTo(path, rel)
+		err = upgradeTo(path, rel, ch)
 		// If we've failed to upgrade, unlock so that another attempt could be made
 		if err != nil {
 			upgradeUnlocked <- true
@@ -79,7 +160,9 @@ func To(rel Release) error {
 	}
 }
 
-func ToURL(url string) error {
+// ToURL upgrades the running binary to the release archive at the given
+// URL, which must be signed by one of the keys trusted by the channel.
+func ToURL(url string, ch Channel) error {
 	select {
 	case <-upgradeUnlocked:
 		binary, err := os.Executable()
@@ -87,7 +170,7 @@ func ToURL(url string) error {
 			upgradeUnlocked <- true
 			return err
 		}
-		err = upgradeToURL(path.Base(url), binary, url)
+		err = upgradeToURL(path.Base(url), binary, url, ch)
 		// If we've failed to upgrade, unlock so that another attempt could be made
 		if err != nil {
 			upgradeUnlocked <- true
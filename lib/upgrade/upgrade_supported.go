@@ -14,6 +14,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/gzip"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -24,6 +25,7 @@ import (
 	"path"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -93,29 +95,89 @@ func upgradeClientGet(url, version string) (*http.Response, error) {
 	return upgradeClient.Do(req)
 }
 
-// FetchLatestReleases returns the latest releases. The "current" parameter
-// is used for setting the User-Agent only.
-func FetchLatestReleases(releasesURL, current string) []Release {
+// FetchLatestReleases returns the latest releases of the channel. The
+// "current" parameter is used for setting the User-Agent only. If the
+// server signs the release metadata, or the channel requires it, the
+// signature must be made by one of the keys trusted by the channel.
+func FetchLatestReleases(ch Channel, current string) []Release {
+	releasesURL, err := ch.metadataURL()
+	if err != nil {
+		slog.Warn("Failed to fetch latest release information", slogutil.Error(err))
+		return nil
+	}
 	resp, err := upgradeClientGet(releasesURL, current)
 	if err != nil {
 		slog.Warn("Failed to fetch latest release information", slogutil.Error(err))
 		return nil
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode > 299 {
 		slog.Warn("Failed to fetch latest release information", slogutil.Error(resp.Status))
 		return nil
 	}
 
+	bs, err := io.ReadAll(io.LimitReader(resp.Body, maxMetadataSize))
+	if err != nil {
+		slog.Warn("Failed to fetch latest release information", slogutil.Error(err))
+		return nil
+	}
+
+	if err := checkManifest(ch, bs, resp.Header.Get(ManifestSignatureHeader)); err != nil {
+		slog.Warn("Failed to verify latest release information", slogutil.Error(err))
+		return nil
+	}
+
 	var rels []Release
-	err = json.NewDecoder(io.LimitReader(resp.Body, maxMetadataSize)).Decode(&rels)
+	err = json.Unmarshal(bs, &rels)
 	if err != nil {
 		slog.Warn("Failed to decode latest release information", slogutil.Error(err))
 	}
-	resp.Body.Close()
 
 	return rels
 }
 
+// checkManifest verifies the signature of the release metadata, if there
+// is one or the channel requires it.
+func checkManifest(ch Channel, data []byte, sig string) error {
+	if sig == "" {
+		if ch.requiresSignedManifest() {
+			return errors.New("release metadata is not signed")
+		}
+		return nil
+	}
+	return verifyManifest(ch, data, sig)
+}
+
+func verifyManifest(ch Channel, data []byte, sig string) error {
+	bs, err := base64.StdEncoding.DecodeString(sig)
+	if err != nil {
+		return fmt.Errorf("decoding signature: %w", err)
+	}
+	return verifyTrusted(ch, bs, func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+}
+
+// verifyTrusted verifies the signature of the data returned by open against
+// each of the keys trusted by the channel in turn, succeeding if any of
+// them matches.
+func verifyTrusted(ch Channel, sig []byte, open func() (io.ReadCloser, error)) error {
+	var err error
+	for _, key := range ch.trustedKeys() {
+		var fd io.ReadCloser
+		fd, err = open()
+		if err != nil {
+			return err
+		}
+		err = signature.Verify(key, sig, fd)
+		fd.Close()
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
 type SortByRelease []Release
 
 func (s SortByRelease) Len() int {
@@ -130,9 +192,21 @@ func (s SortByRelease) Less(i, j int) bool {
 	return CompareVersions(s[i].Tag, s[j].Tag) > 0
 }
 
-func LatestRelease(releasesURL, current string, upgradeToPreReleases bool) (Release, error) {
-	rels := FetchLatestReleases(releasesURL, current)
-	return SelectLatestRelease(rels, current, upgradeToPreReleases)
+func LatestRelease(ch Channel, current string) (Release, error) {
+	rels := FetchLatestReleases(ch, current)
+	rels = filterChannel(rels, ch.Name)
+	return SelectLatestRelease(rels, current, ch.PreReleases)
+}
+
+// filterChannel removes the releases that are explicitly marked as
+// belonging to another channel than the given one.
+func filterChannel(rels []Release, name string) []Release {
+	if name == "" {
+		name = DefaultChannel
+	}
+	return slices.DeleteFunc(rels, func(rel Release) bool {
+		return rel.Channel != "" && rel.Channel != name
+	})
 }
 
 func SelectLatestRelease(rels []Release, current string, upgradeToPreReleases bool) (Release, error) {
@@ -182,7 +256,7 @@ func SelectLatestRelease(rels []Release, current string, upgradeToPreReleases bo
 }
 
 // Upgrade to the given release, saving the previous binary with a ".old" extension.
-func upgradeTo(binary string, rel Release) error {
+func upgradeTo(binary string, rel Release, ch Channel) error {
 	expectedReleases := releaseNames(rel.Tag)
 	for _, asset := range rel.Assets {
 		assetName := path.Base(asset.Name)
@@ -190,7 +264,7 @@ func upgradeTo(binary string, rel Release) error {
 
 		for _, expRel := range expectedReleases {
 			if strings.HasPrefix(assetName, expRel) {
-				return upgradeToURL(assetName, binary, asset.URL)
+				return upgradeToURL(assetName, binary, asset.URL, ch)
 			}
 		}
 	}
@@ -199,8 +273,8 @@ func upgradeTo(binary string, rel Release) error {
 }
 
 // Upgrade to the given release, saving the previous binary with a ".old" extension.
-func upgradeToURL(archiveName, binary string, url string) error {
-	fname, err := readRelease(archiveName, filepath.Dir(binary), url)
+func upgradeToURL(archiveName, binary string, url string, ch Channel) error {
+	fname, err := readRelease(archiveName, filepath.Dir(binary), url, ch)
 	if err != nil {
 		return err
 	}
@@ -219,7 +293,7 @@ func upgradeToURL(archiveName, binary string, url string) error {
 	return nil
 }
 
-func readRelease(archiveName, dir, url string) (string, error) {
+func readRelease(archiveName, dir, url string, ch Channel) (string, error) {
 	l.Debugf("loading %q", url)
 
 	req, err := http.NewRequest(http.MethodGet, url, nil)
@@ -236,13 +310,13 @@ func readRelease(archiveName, dir, url string) (string, error) {
 
 	switch path.Ext(archiveName) {
 	case ".zip":
-		return readZip(archiveName, dir, io.LimitReader(resp.Body, maxArchiveSize))
+		return readZip(archiveName, dir, io.LimitReader(resp.Body, maxArchiveSize), ch)
 	default:
-		return readTarGz(archiveName, dir, io.LimitReader(resp.Body, maxArchiveSize))
+		return readTarGz(archiveName, dir, io.LimitReader(resp.Body, maxArchiveSize), ch)
 	}
 }
 
-func readTarGz(archiveName, dir string, r io.Reader) (string, error) {
+func readTarGz(archiveName, dir string, r io.Reader, ch Channel) (string, error) {
 	gr, err := gzip.NewReader(r)
 	if err != nil {
 		return "", err
@@ -279,14 +353,14 @@ func readTarGz(archiveName, dir string, r io.Reader) (string, error) {
 		}
 	}
 
-	if err := verifyUpgrade(archiveName, tempName, sig); err != nil {
+	if err := verifyUpgrade(archiveName, tempName, sig, ch); err != nil {
 		return "", err
 	}
 
 	return tempName, nil
 }
 
-func readZip(archiveName, dir string, r io.Reader) (string, error) {
+func readZip(archiveName, dir string, r io.Reader, ch Channel) (string, error) {
 	body, err := io.ReadAll(r)
 	if err != nil {
 		return "", err
@@ -330,7 +404,7 @@ func readZip(archiveName, dir string, r io.Reader) (string, error) {
 		}
 	}
 
-	if err := verifyUpgrade(archiveName, tempName, sig); err != nil {
+	if err := verifyUpgrade(archiveName, tempName, sig, ch); err != nil {
 		return "", err
 	}
 
@@ -369,7 +443,7 @@ func archiveFileVisitor(dir string, tempFile *string, signature *[]byte, archive
 	return nil
 }
 
-func verifyUpgrade(archiveName, tempName string, sig []byte) error {
+func verifyUpgrade(archiveName, tempName string, sig []byte, ch Channel) error {
 	if tempName == "" {
 		return errors.New("no upgrade found")
 	}
@@ -379,26 +453,28 @@ func verifyUpgrade(archiveName, tempName string, sig []byte) error {
 
 	l.Debugf("checking signature\n%s", sig)
 
-	fd, err := os.Open(tempName)
-	if err != nil {
-		return err
-	}
-
-	// Create a new reader that will serve reads from, in order:
+	// Verify the signature against a reader that will serve reads from,
+	// in order:
 	//
 	// - the archive name ("syncthing-linux-amd64-v0.13.0-beta.4.tar.gz")
 	//   followed by a newline
 	//
 	// - the temp file contents
 	//
-	// We then verify the release signature against the contents of this
+	// We verify the release signature against the contents of this
 	// multireader. This ensures that it is not only a bonafide syncthing
 	// binary, but it is also of exactly the platform and version we expect.
 
-	mr := io.MultiReader(strings.NewReader(archiveName+"\n"), fd)
-	err = signature.Verify(SigningKey, sig, mr)
-	fd.Close()
-
+	err := verifyTrusted(ch, sig, func() (io.ReadCloser, error) {
+		fd, err := os.Open(tempName)
+		if err != nil {
+			return nil, err
+		}
+		return struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(strings.NewReader(archiveName+"\n"), fd), fd}, nil
+	})
 	if err != nil {
 		os.Remove(tempName)
 		return err
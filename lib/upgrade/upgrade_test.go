@@ -10,12 +10,16 @@
 package upgrade
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/syncthing/syncthing/lib/build"
+	"github.com/syncthing/syncthing/lib/signature"
 )
 
 var versions = []struct {
@@ -157,3 +161,81 @@ func TestSelectedReleaseMacOS(t *testing.T) {
 		}
 	}
 }
+
+func TestChannelMetadataURL(t *testing.T) {
+	cases := []struct {
+		ch  Channel
+		url string
+	}{
+		{Channel{ReleasesURL: "https://example.com/meta.json"}, "https://example.com/meta.json"},
+		{Channel{Name: DefaultChannel, ReleasesURL: "https://example.com/meta.json"}, "https://example.com/meta.json"},
+		{Channel{Name: "canary", ReleasesURL: "https://example.com/meta.json"}, "https://example.com/meta.json?channel=canary"},
+		{Channel{Name: "internal", ReleasesURL: "https://example.com/meta.json?a=b"}, "https://example.com/meta.json?a=b&channel=internal"},
+	}
+	for _, tc := range cases {
+		u, err := tc.ch.metadataURL()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if u != tc.url {
+			t.Errorf("metadataURL(%+v) = %q, expected %q", tc.ch, u, tc.url)
+		}
+	}
+}
+
+func TestFilterChannel(t *testing.T) {
+	rels := []Release{
+		{Tag: "v1.0.0"},
+		{Tag: "v1.0.1", Channel: "canary"},
+		{Tag: "v1.0.2", Channel: "internal"},
+	}
+	if res := filterChannel(slices.Clone(rels), ""); len(res) != 1 || res[0].Tag != "v1.0.0" {
+		t.Errorf("unexpected default channel releases %v", res)
+	}
+	if res := filterChannel(slices.Clone(rels), "canary"); len(res) != 2 || res[1].Tag != "v1.0.1" {
+		t.Errorf("unexpected canary channel releases %v", res)
+	}
+}
+
+func TestVerifyManifestExtraKeys(t *testing.T) {
+	priv, pub, err := signature.GenerateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := []byte(`[{"tag_name":"v1.0.0"}]`)
+	sig, err := signature.Sign(priv, bytes.NewReader(manifest))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(sig)
+
+	if err := verifyManifest(Channel{}, manifest, encoded); err == nil {
+		t.Error("manifest signed by an untrusted key should not verify")
+	}
+	ch := Channel{SigningKeys: []string{string(pub)}}
+	if err := verifyManifest(ch, manifest, encoded); err != nil {
+		t.Error("manifest signed by an extra trusted key should verify:", err)
+	}
+	if err := verifyManifest(ch, append(manifest, ' '), encoded); err == nil {
+		t.Error("modified manifest should not verify")
+	}
+}
+
+func TestCheckManifestRequired(t *testing.T) {
+	manifest := []byte(`[{"tag_name":"v1.0.0"}]`)
+	cases := []struct {
+		ch       Channel
+		unsigned bool
+	}{
+		{Channel{}, true},
+		{Channel{Name: DefaultChannel}, true},
+		{NewChannel("", "", false, nil), true},
+		{Channel{Name: "canary"}, false},
+		{Channel{Name: DefaultChannel, SigningKeys: []string{"key"}}, false},
+	}
+	for _, tc := range cases {
+		if err := checkManifest(tc.ch, manifest, ""); (err == nil) != tc.unsigned {
+			t.Errorf("%+v: unexpected result for unsigned manifest: %v", tc.ch, err)
+		}
+	}
+}
@@ -11,14 +11,14 @@ package upgrade
 
 const DisabledByCompilation = true
 
-func upgradeTo(binary string, rel Release) error {
+func upgradeTo(binary string, rel Release, ch Channel) error {
 	return ErrUpgradeUnsupported
 }
 
-func upgradeToURL(archiveName, binary, url string) error {
+func upgradeToURL(archiveName, binary, url string, ch Channel) error {
 	return ErrUpgradeUnsupported
 }
 
-func LatestRelease(releasesURL, current string, upgradeToPreRelease bool) (Release, error) {
+func LatestRelease(ch Channel, current string) (Release, error) {
 	return Release{}, ErrUpgradeUnsupported
 }
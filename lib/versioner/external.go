@@ -9,17 +9,11 @@ package versioner
 import (
 	"context"
 	"errors"
-	"fmt"
-	"os"
-	"os/exec"
-	"strings"
 	"time"
 
-	"github.com/syncthing/syncthing/lib/build"
 	"github.com/syncthing/syncthing/lib/config"
 	"github.com/syncthing/syncthing/lib/fs"
-
-	"github.com/kballard/go-shellquote"
+	"github.com/syncthing/syncthing/lib/osutil"
 )
 
 func init() {
@@ -33,14 +27,8 @@ type external struct {
 }
 
 func newExternal(cfg config.FolderConfiguration) Versioner {
-	command := cfg.Versioning.Params["command"]
-
-	if build.IsWindows {
-		command = strings.ReplaceAll(command, `\`, `\\`)
-	}
-
 	s := external{
-		command:    command,
+		command:    cfg.Versioning.Params["command"],
 		filesystem: cfg.Filesystem(),
 	}
 
@@ -64,48 +52,18 @@ func (v external) Archive(filePath string) error {
 
 	l.Debugln("archiving", filePath)
 
-	if v.command == "" {
-		return errors.New("command is empty, please enter a valid command")
-	}
-
-	words, err := shellquote.Split(v.command)
-	if err != nil {
-		return fmt.Errorf("command is invalid: %w", err)
-	}
-
-	context := map[string]string{
+	cmd, err := osutil.UserCommand(context.Background(), v.command, map[string]string{
 		"%FOLDER_FILESYSTEM%": string(v.filesystem.Type()),
 		"%FOLDER_PATH%":       v.filesystem.URI(),
 		"%FILE_PATH%":         filePath,
+	})
+	if err != nil {
+		return err
 	}
-
-	for i, word := range words {
-		for key, val := range context {
-			word = strings.ReplaceAll(word, key, val)
-		}
-
-		words[i] = word
-	}
-
-	cmd := exec.Command(words[0], words[1:]...)
-	env := os.Environ()
-	// filter STGUIAUTH and STGUIAPIKEY from environment variables
-	var filteredEnv []string
-
-	for _, x := range env {
-		if !strings.HasPrefix(x, "STGUIAUTH=") && !strings.HasPrefix(x, "STGUIAPIKEY=") {
-			filteredEnv = append(filteredEnv, x)
-		}
-	}
-	cmd.Env = filteredEnv
 	combinedOutput, err := cmd.CombinedOutput()
 	l.Debugln("external command output:", string(combinedOutput))
 	if err != nil {
-		eerr := &exec.ExitError{}
-		if errors.As(err, &eerr) && len(eerr.Stderr) > 0 {
-			return fmt.Errorf("%w: %v", err, string(eerr.Stderr))
-		}
-		return err
+		return osutil.CommandError(err)
 	}
 
 	// return error if the file was not removed
@@ -0,0 +1,105 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package webhooks
+
+import (
+	"encoding/json"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/config"
+)
+
+const (
+	deadLettersKey = "webhookDeadLetters"
+	maxDeadLetters = 1000 // the oldest are dropped beyond this
+)
+
+// A DeadLetter is an event that couldn't be delivered to a webhook.
+type DeadLetter struct {
+	Webhook  string          `json:"webhook"`
+	URL      string          `json:"url"`
+	Type     string          `json:"type"`
+	Event    json.RawMessage `json:"event"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error"`
+	Time     time.Time       `json:"time"`
+}
+
+// The deadLetterQueue keeps undelivered events in the database, so that
+// they survive restarts.
+type deadLetterQueue struct {
+	miscDB *db.Typed
+
+	mut     sync.Mutex
+	letters []DeadLetter
+}
+
+func newDeadLetterQueue(miscDB *db.Typed) *deadLetterQueue {
+	q := &deadLetterQueue{miscDB: miscDB}
+	if bs, ok, _ := miscDB.Bytes(deadLettersKey); ok {
+		_ = json.Unmarshal(bs, &q.letters) // best effort
+	}
+	return q
+}
+
+func (q *deadLetterQueue) add(wc config.WebhookConfiguration, d delivery, err error) {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	q.letters = append(q.letters, DeadLetter{
+		Webhook:  wc.ID,
+		URL:      wc.URL,
+		Type:     d.eventType.String(),
+		Event:    d.body,
+		Attempts: d.attempts,
+		Error:    err.Error(),
+		Time:     time.Now().Truncate(time.Second),
+	})
+	if over := len(q.letters) - maxDeadLetters; over > 0 {
+		q.letters = slices.Delete(q.letters, 0, over)
+	}
+	q.saveLocked()
+}
+
+func (q *deadLetterQueue) list() []DeadLetter {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	return slices.Clone(q.letters)
+}
+
+// retry removes the letters that requeue accepts, returning their number.
+func (q *deadLetterQueue) retry(requeue func(DeadLetter) bool) int {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+
+	before := len(q.letters)
+	q.letters = slices.DeleteFunc(q.letters, requeue)
+	if n := before - len(q.letters); n > 0 {
+		q.saveLocked()
+		return n
+	}
+	return 0
+}
+
+func (q *deadLetterQueue) clear() {
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	q.letters = nil
+	q.saveLocked()
+}
+
+func (q *deadLetterQueue) saveLocked() {
+	bs, _ := json.Marshal(q.letters) // can't fail
+	if err := q.miscDB.PutBytes(deadLettersKey, bs); err != nil {
+		slog.Warn("Failed to save undelivered webhook events", slogutil.Error(err))
+	}
+}
@@ -0,0 +1,364 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+// Package webhooks posts selected events to configured HTTP endpoints.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/slogutil"
+	"github.com/syncthing/syncthing/lib/build"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/events"
+	"github.com/syncthing/syncthing/lib/protocol"
+)
+
+const (
+	maxAttempts     = 6
+	initialBackoff  = 5 * time.Second
+	maxBackoff      = 5 * time.Minute
+	queueSize       = 1000 // events per webhook
+	deliveryTimeout = 30 * time.Second
+)
+
+var errQueueFull = errors.New("delivery queue full")
+
+// The Service delivers events to the configured webhooks. Each webhook has
+// its own queue, so a slow or unavailable endpoint doesn't hold up the
+// others. Events that can't be delivered after retrying end up in the
+// dead letter queue.
+type Service struct {
+	cfg         config.Wrapper
+	evLogger    events.Logger
+	client      *http.Client
+	deadLetters *deadLetterQueue
+	reload      chan struct{}
+	backoff     func(attempt int) time.Duration // can be overridden for testing
+
+	mut   sync.Mutex
+	hooks map[string]*hook // currently active, by ID
+}
+
+func New(cfg config.Wrapper, evLogger events.Logger, miscDB *db.Typed) *Service {
+	return &Service{
+		cfg:         cfg,
+		evLogger:    evLogger,
+		client:      &http.Client{Timeout: deliveryTimeout},
+		deadLetters: newDeadLetterQueue(miscDB),
+		reload:      make(chan struct{}, 1),
+		backoff:     exponentialBackoff,
+	}
+}
+
+func (s *Service) Serve(ctx context.Context) error {
+	s.cfg.Subscribe(s)
+	defer s.cfg.Unsubscribe(s)
+
+	for {
+		if err := s.serveHooks(ctx); err != nil {
+			return err
+		}
+	}
+}
+
+// serveHooks runs the currently configured webhooks until the
+// configuration changes (returning nil) or the context is cancelled.
+func (s *Service) serveHooks(ctx context.Context) error {
+	var hooks []*hook
+	var mask events.EventType
+	for _, wc := range s.cfg.RawCopy().Webhooks {
+		if wc.Paused || wc.URL == "" {
+			continue
+		}
+		hooks = append(hooks, newHook(wc))
+		mask |= wc.EventMask()
+	}
+
+	// Subscribe before starting, so that the hooks see all events from
+	// when they are active.
+	var sub events.Subscription
+	if len(hooks) > 0 {
+		sub = s.evLogger.Subscribe(mask)
+		defer sub.Unsubscribe()
+	}
+
+	hctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	s.mut.Lock()
+	s.hooks = make(map[string]*hook, len(hooks))
+	for _, h := range hooks {
+		s.hooks[h.cfg.ID] = h
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.runHook(hctx, h)
+		}()
+	}
+	s.mut.Unlock()
+
+	defer func() {
+		s.mut.Lock()
+		s.hooks = nil
+		s.mut.Unlock()
+		cancel()
+		wg.Wait()
+	}()
+
+	if sub == nil {
+		select {
+		case <-s.reload:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for {
+		select {
+		case ev, ok := <-sub.C():
+			if !ok {
+				<-ctx.Done()
+				return ctx.Err()
+			}
+			s.dispatch(hooks, ev)
+		case <-s.reload:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Service) dispatch(hooks []*hook, ev events.Event) {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		slog.Warn("Failed to encode event for webhooks", slog.String("type", ev.Type.String()), slogutil.Error(err))
+		return
+	}
+	folder, device := eventSubjects(body)
+	for _, h := range hooks {
+		if !h.matches(ev.Type, folder, device) {
+			continue
+		}
+		d := delivery{eventType: ev.Type, body: body}
+		if !h.enqueue(d) {
+			s.deadLetters.add(h.cfg, d, errQueueFull)
+		}
+	}
+}
+
+func (s *Service) runHook(ctx context.Context, h *hook) {
+	for {
+		select {
+		case d := <-h.queue:
+			s.deliver(ctx, h, d)
+		case <-ctx.Done():
+			// Keep what we didn't get to for later.
+			for {
+				select {
+				case d := <-h.queue:
+					s.deadLetters.add(h.cfg, d, ctx.Err())
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver posts the event to the webhook, retrying with backoff, and puts
+// it in the dead letter queue if that doesn't succeed.
+func (s *Service) deliver(ctx context.Context, h *hook, d delivery) {
+	for {
+		d.attempts++
+		err := s.post(ctx, h.cfg, d)
+		if err == nil {
+			return
+		}
+		slog.Debug("Webhook delivery failed", slog.String("webhook", h.cfg.ID), slog.Int("attempt", d.attempts), slogutil.Error(err))
+		var perm *permanentError
+		if d.attempts >= maxAttempts || errors.As(err, &perm) {
+			slog.Warn("Failed to deliver event to webhook", slog.String("webhook", h.cfg.ID), slogutil.URI(h.cfg.URL), slog.String("type", d.eventType.String()), slogutil.Error(err))
+			s.deadLetters.add(h.cfg, d, err)
+			return
+		}
+		select {
+		case <-time.After(s.backoff(d.attempts)):
+		case <-ctx.Done():
+			s.deadLetters.add(h.cfg, d, err)
+			return
+		}
+	}
+}
+
+// A permanentError is a failure that retrying won't fix.
+type permanentError struct {
+	status string
+}
+
+func (e *permanentError) Error() string {
+	return e.status
+}
+
+func (s *Service) post(ctx context.Context, wc config.WebhookConfiguration, d delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wc.URL, bytes.NewReader(d.body))
+	if err != nil {
+		return &permanentError{status: err.Error()}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "syncthing/"+build.Version)
+	req.Header.Set("X-Syncthing-Webhook", wc.ID)
+	req.Header.Set("X-Syncthing-Event", d.eventType.String())
+	if wc.Secret != "" {
+		req.Header.Set("X-Syncthing-Signature", Signature(wc.Secret, d.body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests:
+		return &permanentError{status: resp.Status}
+	default:
+		return fmt.Errorf("unexpected response: %s", resp.Status)
+	}
+}
+
+// Signature returns the value of the signature header for a request body:
+// "sha256=" followed by the hex encoded HMAC-SHA256 of the body.
+func Signature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func exponentialBackoff(attempt int) time.Duration {
+	d := initialBackoff << (attempt - 1)
+	if d <= 0 || d > maxBackoff {
+		return maxBackoff
+	}
+	return d
+}
+
+// DeadLetters returns the events that couldn't be delivered, oldest first.
+func (s *Service) DeadLetters() []DeadLetter {
+	return s.deadLetters.list()
+}
+
+// RetryDeadLetters queues the undelivered events of the active webhooks
+// for delivery again, and returns how many were queued.
+func (s *Service) RetryDeadLetters() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.deadLetters.retry(func(dl DeadLetter) bool {
+		h, ok := s.hooks[dl.Webhook]
+		return ok && h.enqueue(delivery{eventType: events.UnmarshalEventType(dl.Type), body: dl.Event})
+	})
+}
+
+// ClearDeadLetters forgets all undelivered events.
+func (s *Service) ClearDeadLetters() {
+	s.deadLetters.clear()
+}
+
+func (s *Service) CommitConfiguration(from, to config.Configuration) bool {
+	if !reflect.DeepEqual(from.Webhooks, to.Webhooks) {
+		select {
+		case s.reload <- struct{}{}:
+		default:
+		}
+	}
+	return true
+}
+
+func (s *Service) String() string {
+	return fmt.Sprintf("webhooks.Service@%p", s)
+}
+
+type hook struct {
+	cfg   config.WebhookConfiguration
+	mask  events.EventType
+	queue chan delivery
+}
+
+type delivery struct {
+	eventType events.EventType
+	body      []byte
+	attempts  int
+}
+
+func newHook(wc config.WebhookConfiguration) *hook {
+	return &hook{
+		cfg:   wc,
+		mask:  wc.EventMask(),
+		queue: make(chan delivery, queueSize),
+	}
+}
+
+func (h *hook) matches(t events.EventType, folder string, device protocol.DeviceID) bool {
+	if h.mask&t == 0 {
+		return false
+	}
+	if len(h.cfg.Folders) > 0 && !slices.Contains(h.cfg.Folders, folder) {
+		return false
+	}
+	if len(h.cfg.Devices) > 0 && !slices.Contains(h.cfg.Devices, device) {
+		return false
+	}
+	return true
+}
+
+func (h *hook) enqueue(d delivery) bool {
+	d.attempts = 0
+	select {
+	case h.queue <- d:
+		return true
+	default:
+		return false
+	}
+}
+
+// eventSubjects returns the folder and device an encoded event is about,
+// if any.
+func eventSubjects(body []byte) (string, protocol.DeviceID) {
+	var ev struct {
+		Data struct {
+			Folder string `json:"folder"`
+			Device string `json:"device"`
+			ID     string `json:"id"` // device events
+		} `json:"data"`
+	}
+	_ = json.Unmarshal(body, &ev) // data that isn't an object has no subjects
+	dev := ev.Data.Device
+	if dev == "" {
+		dev = ev.Data.ID
+	}
+	id, _ := protocol.DeviceIDFromString(dev)
+	return ev.Data.Folder, id
+}
@@ -0,0 +1,216 @@
+// Copyright (C) 2025 The Syncthing Authors.
+//
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this file,
+// You can obtain one at https://mozilla.org/MPL/2.0/.
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/syncthing/syncthing/internal/db"
+	"github.com/syncthing/syncthing/internal/db/sqlite"
+	"github.com/syncthing/syncthing/lib/config"
+	"github.com/syncthing/syncthing/lib/config/mocks"
+	"github.com/syncthing/syncthing/lib/events"
+)
+
+type received struct {
+	header http.Header
+	body   []byte
+}
+
+// startService runs the service with the given webhooks until the test
+// ends, returning once the webhooks are active.
+func startService(t *testing.T, evLogger events.Logger, miscDB *db.Typed, hooks ...config.WebhookConfiguration) *Service {
+	t.Helper()
+
+	cfg := &mocks.Wrapper{}
+	cfg.RawCopyReturns(config.Configuration{Webhooks: hooks})
+	svc := New(cfg, evLogger, miscDB)
+	svc.backoff = func(int) time.Duration { return time.Millisecond }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		svc.Serve(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	waitFor(t, func() bool {
+		svc.mut.Lock()
+		defer svc.mut.Unlock()
+		return len(svc.hooks) == len(hooks)
+	})
+	return svc
+}
+
+func startLogger(t *testing.T) events.Logger {
+	t.Helper()
+	evLogger := events.NewLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	go evLogger.Serve(ctx)
+	t.Cleanup(cancel)
+	return evLogger
+}
+
+func newMiscDB(t *testing.T) *db.Typed {
+	t.Helper()
+	sdb, err := sqlite.Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		sdb.Close()
+	})
+	return db.NewMiscDB(sdb)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestDeliveryAndFilters(t *testing.T) {
+	t.Parallel()
+
+	var mut sync.Mutex
+	var got []received
+	srv := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		bs, _ := io.ReadAll(r.Body)
+		mut.Lock()
+		got = append(got, received{r.Header, bs})
+		mut.Unlock()
+	}))
+	defer srv.Close()
+
+	evLogger := startLogger(t)
+	startService(t, evLogger, newMiscDB(t), config.WebhookConfiguration{
+		ID:      "chat",
+		URL:     srv.URL,
+		Events:  []events.EventType{events.FolderCompletion},
+		Folders: []string{"photos"},
+		Secret:  "hunter2",
+	})
+
+	evLogger.Log(events.StateChanged, map[string]interface{}{"folder": "photos", "to": "idle"})
+	evLogger.Log(events.FolderCompletion, map[string]interface{}{"folder": "music", "completion": 100})
+	evLogger.Log(events.FolderCompletion, map[string]interface{}{"folder": "photos", "completion": 100})
+
+	waitFor(t, func() bool {
+		mut.Lock()
+		defer mut.Unlock()
+		return len(got) > 0
+	})
+	time.Sleep(50 * time.Millisecond) // anything else would arrive by now
+
+	mut.Lock()
+	defer mut.Unlock()
+	if len(got) != 1 {
+		t.Fatalf("expected one delivery, got %d", len(got))
+	}
+	var ev struct {
+		Type string `json:"type"`
+		Data struct {
+			Folder string `json:"folder"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(got[0].body, &ev); err != nil {
+		t.Fatal(err)
+	}
+	if ev.Type != "FolderCompletion" || ev.Data.Folder != "photos" {
+		t.Errorf("unexpected event %s", got[0].body)
+	}
+	if sig := got[0].header.Get("X-Syncthing-Signature"); sig != Signature("hunter2", got[0].body) {
+		t.Errorf("bad signature %q", sig)
+	}
+	if h := got[0].header.Get("X-Syncthing-Event"); h != "FolderCompletion" {
+		t.Errorf("unexpected event header %q", h)
+	}
+}
+
+func TestRetryAndDeadLetters(t *testing.T) {
+	t.Parallel()
+
+	var failing atomic.Bool
+	failing.Store(true)
+	var attempts, delivered atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if failing.Load() {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		delivered.Add(1)
+	}))
+	defer srv.Close()
+
+	evLogger := startLogger(t)
+	miscDB := newMiscDB(t)
+	svc := startService(t, evLogger, miscDB, config.WebhookConfiguration{ID: "ci", URL: srv.URL})
+
+	evLogger.Log(events.Failure, "something broke")
+	waitFor(t, func() bool { return len(svc.DeadLetters()) == 1 })
+
+	if n := attempts.Load(); n != maxAttempts {
+		t.Errorf("expected %d attempts, got %d", maxAttempts, n)
+	}
+	dl := svc.DeadLetters()[0]
+	if dl.Webhook != "ci" || dl.Type != "Failure" || dl.Attempts != maxAttempts {
+		t.Errorf("unexpected dead letter %+v", dl)
+	}
+
+	// The dead letters are persistent.
+	if letters := newDeadLetterQueue(miscDB).list(); len(letters) != 1 {
+		t.Errorf("expected a persisted dead letter, got %d", len(letters))
+	}
+
+	// Once the endpoint works again they can be redelivered.
+	failing.Store(false)
+	if n := svc.RetryDeadLetters(); n != 1 {
+		t.Errorf("expected one requeued event, got %d", n)
+	}
+	waitFor(t, func() bool { return delivered.Load() == 1 })
+	if letters := svc.DeadLetters(); len(letters) != 0 {
+		t.Errorf("expected no dead letters, got %d", len(letters))
+	}
+}
+
+func TestPermanentFailure(t *testing.T) {
+	t.Parallel()
+
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	evLogger := startLogger(t)
+	svc := startService(t, evLogger, newMiscDB(t), config.WebhookConfiguration{ID: "gone", URL: srv.URL})
+
+	evLogger.Log(events.Failure, "something broke")
+	waitFor(t, func() bool { return len(svc.DeadLetters()) == 1 })
+	if n := attempts.Load(); n != 1 {
+		t.Errorf("client errors should not be retried, got %d attempts", n)
+	}
+}